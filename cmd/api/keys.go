@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-saas-api/internal/config"
+	"go-saas-api/internal/jwtauth"
+)
+
+const keysUsage = `usage: api keys <command>
+
+commands:
+  generate <dir> [ed25519|rsa]  write a new private signing key to <dir>/<kid>.pem (default ed25519)
+
+To rotate: generate a key, point JWT_SIGNING_KEY_ID at it, and replace the old
+private key file with its public key (same name) until issued tokens expire.`
+
+// runKeys implements the `keys` subcommand
+func runKeys(args []string) {
+	if len(args) < 2 || len(args) > 3 || args[0] != "generate" {
+		log.Fatal(keysUsage)
+	}
+	dir, alg := args[1], "ed25519"
+	if len(args) == 3 {
+		alg = args[2]
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch alg {
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		log.Fatal(keysUsage)
+	}
+	if err != nil {
+		log.Fatal("generate key failed:", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		log.Fatal("encode key failed:", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		log.Fatal("create keys dir failed:", err)
+	}
+
+	kid := alg + "-" + time.Now().UTC().Format("20060102150405")
+	path := filepath.Join(dir, kid+".pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		log.Fatal("write key failed:", err)
+	}
+	log.Printf("🔑 Created %s (kid %s)", path, kid)
+}
+
+// loadKeys builds the JWT key set from config, exiting if the keys are unusable
+func loadKeys(cfg *config.Config) *jwtauth.KeySet {
+	keys, err := jwtauth.New(jwtauth.Options{
+		Secret:       cfg.JWTSecret,
+		KeysDir:      cfg.JWTKeysDir,
+		SigningKeyID: cfg.JWTSigningKeyID,
+		Issuer:       cfg.JWTIssuer,
+		Audience:     cfg.JWTAudience,
+	})
+	if err != nil {
+		log.Fatal("JWT keys:", err)
+	}
+	return keys
+}
@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"go-saas-api/internal/config"
 	"go-saas-api/internal/database"
+	"go-saas-api/internal/health"
+	"go-saas-api/internal/jwtauth"
+	"go-saas-api/internal/mailer"
 	"go-saas-api/internal/middleware"
+	"go-saas-api/internal/oidc"
 	"go-saas-api/internal/place"
+	"go-saas-api/internal/shutdown"
+	"go-saas-api/internal/trip"
 	"go-saas-api/internal/user"
+	"go-saas-api/internal/workspace"
+	"go-saas-api/pkg/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -15,6 +30,20 @@ import (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "users" {
+		runUsers(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		runKeys(os.Args[2:])
+		return
+	}
+
 	// Load configuration
 	cfg := config.Load()
 
@@ -23,40 +52,174 @@ func main() {
 	if err != nil {
 		log.Fatal("database connection failed:", err)
 	}
-	defer db.Close()
 	log.Println("✅ Database connected")
 
+	// Shutdown hooks run in reverse order of registration
+	hooks := shutdown.New()
+	hooks.Register("database", func(ctx context.Context) error {
+		return db.Close()
+	})
+
 	// Setup validator
 	v := validator.New()
+	if err := response.SetupValidator(v); err != nil {
+		log.Fatal("validator setup failed:", err)
+	}
+
+	// Setup mailer
+	var mail mailer.Mailer
+	switch cfg.MailDriver {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	default:
+		mail = mailer.NewLogMailer(cfg.MailFrom, cfg.MailLogDir)
+	}
+	log.Printf("📧 Mail driver: %s", cfg.MailDriver)
+
+	// Setup JWT keys
+	keys := loadKeys(cfg)
+	if cfg.JWTKeysDir != "" {
+		log.Printf("🔑 JWT keys loaded from %s", cfg.JWTKeysDir)
+	}
 
 	// Setup middleware
-	authMW := middleware.NewAuthMiddleware(cfg.JWTSecret)
+	userRepo := user.NewRepository(db)
+	var verified middleware.VerificationChecker
+	if cfg.RequireVerifiedEmail {
+		verified = userRepo
+	}
+	authMW := middleware.NewAuthMiddleware(keys, userRepo, verified, user.NewAPIKeyAuthenticator(userRepo), userRepo)
+
+	var rateStore middleware.RateStore = middleware.NewMemoryRateStore()
+	if cfg.RateLimitStore == "postgres" {
+		rateStore = middleware.NewPostgresRateStore(db)
+	}
+	rateLimiter := middleware.NewRateLimiter(rateStore, map[string]middleware.RateRule{
+		"auth":   {Limit: cfg.AuthRateLimit, Window: cfg.AuthRateWindow},
+		"shared": {Limit: cfg.AuthRateLimit, Window: cfg.AuthRateWindow}, // public share links, which can be password protected
+		"api":    {Limit: cfg.APIRateLimit, Window: cfg.APIRateWindow},
+	})
+	log.Printf("🚦 Rate limit store: %s", cfg.RateLimitStore)
 
 	// Setup Gin router
 	r := gin.New()
-	r.Use(gin.Logger(), gin.Recovery())
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("invalid TRUSTED_PROXIES:", err)
+	}
+	r.Use(gin.Logger(), gin.Recovery(), middleware.ErrorHandler())
 
 	// Setup modules
-	setupUserModule(r, db, v, cfg.JWTSecret, authMW)
-	setupPlaceModule(r, db, v, authMW)
+	setupHealthModule(r, db)
+	jwtauth.RegisterRoutes(r, keys)
+	setupUserModule(r, db, v, cfg, keys, mail, authMW, rateLimiter, hooks)
+	workspaces := setupWorkspaceModule(r, db, v, cfg, mail, authMW, rateLimiter)
+	wsMW := middleware.NewWorkspaceMiddleware(workspaces)
+	setupPlaceModule(r, db, v, authMW, wsMW, rateLimiter)
+	setupTripModule(r, db, v, authMW, wsMW, rateLimiter)
 
 	// Start server
-	log.Printf("🚀 Server running on port %s", cfg.Port)
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatal("server failed to start:", err)
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Server running on port %s", cfg.Port)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// Wait for SIGINT/SIGTERM or a server failure
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := false
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("server failed: %v", err)
+			failed = true
+		}
+	case <-ctx.Done():
+		log.Println("🛑 Shutdown signal received, draining requests...")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting requests first, then close modules
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("http server shutdown: %v", err)
+	}
+	if err := hooks.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	if failed {
+		// Modules are closed, so exiting here skips nothing but the deferred cancels
+		cancel()
+		os.Exit(1)
+	}
+	log.Println("👋 Server stopped")
 }
 
-func setupUserModule(r *gin.Engine, db *sqlx.DB, v *validator.Validate, jwtSecret string, authMW *middleware.AuthMiddleware) {
+func setupHealthModule(r *gin.Engine, db *sqlx.DB) {
+	handler := health.NewHandler(db)
+	health.RegisterRoutes(r, handler)
+}
+
+func setupUserModule(r *gin.Engine, db *sqlx.DB, v *validator.Validate, cfg *config.Config, keys *jwtauth.KeySet, mail mailer.Mailer, authMW *middleware.AuthMiddleware, rl *middleware.RateLimiter, hooks *shutdown.Registry) {
 	repo := user.NewRepository(db)
-	service := user.NewService(repo, jwtSecret)
+	service := user.NewService(repo, keys, mail, cfg.AppBaseURL, cfg.AccountDeletionGrace)
+	for _, p := range cfg.OIDCProviders {
+		service.RegisterOIDCProvider(oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil))
+		log.Printf("🔐 OIDC provider: %s (%s)", p.Name, p.Issuer)
+	}
 	handler := user.NewHandler(service, v)
-	user.RegisterRoutes(r, handler, authMW)
+	user.RegisterRoutes(r, handler, authMW, rl)
+
+	// Hard-delete accounts whose deletion grace period has passed
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	go service.RunPurger(purgeCtx, time.Hour)
+	hooks.Register("user purger", func(ctx context.Context) error {
+		stopPurge()
+		return nil
+	})
+	// Let mail queued by requests finish before the database closes
+	hooks.Register("user background jobs", service.WaitBackground)
 }
 
-func setupPlaceModule(r *gin.Engine, db *sqlx.DB, v *validator.Validate, authMW *middleware.AuthMiddleware) {
+// setupWorkspaceModule returns the workspace service, which also resolves the
+// active workspace for other modules
+func setupWorkspaceModule(r *gin.Engine, db *sqlx.DB, v *validator.Validate, cfg *config.Config, mail mailer.Mailer, authMW *middleware.AuthMiddleware, rl *middleware.RateLimiter) *workspace.Service {
+	repo := workspace.NewRepository(db)
+	service := workspace.NewService(repo, user.NewRepository(db), mail, cfg.AppBaseURL)
+	handler := workspace.NewHandler(service, v)
+	workspace.RegisterRoutes(r, handler, authMW, rl)
+	return service
+}
+
+func setupPlaceModule(r *gin.Engine, db *sqlx.DB, v *validator.Validate, authMW *middleware.AuthMiddleware, wsMW *middleware.WorkspaceMiddleware, rl *middleware.RateLimiter) {
 	repo := place.NewRepository(db)
 	service := place.NewService(repo)
 	handler := place.NewHandler(service, v)
-	place.RegisterRoutes(r, handler, authMW)
+	place.RegisterRoutes(r, handler, authMW, wsMW, rl)
+}
+
+func setupTripModule(r *gin.Engine, db *sqlx.DB, v *validator.Validate, authMW *middleware.AuthMiddleware, wsMW *middleware.WorkspaceMiddleware, rl *middleware.RateLimiter) {
+	repo := trip.NewRepository(db)
+	service := trip.NewService(repo, place.NewRepository(db))
+	handler := trip.NewHandler(service, v)
+	trip.RegisterRoutes(r, handler, authMW, wsMW, rl)
 }
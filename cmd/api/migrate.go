@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go-saas-api/db/migrations"
+	"go-saas-api/internal/config"
+	"go-saas-api/internal/database"
+	"go-saas-api/internal/migrate"
+)
+
+const migrationsDir = "db/migrations"
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up [n]         apply all (or the next n) pending migrations
+  down [n]       roll back the last (or last n) applied migrations
+  status         list migrations and whether they are applied
+  baseline <v>   mark migrations up to version v as applied without running them,
+                 for a database whose schema already has them (from db/db.sql: 1)
+  create <name>  create a new empty up/down migration pair in ` + migrationsDir
+
+// runMigrate implements the `migrate` subcommand
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	// create only touches the filesystem, no database needed
+	if args[0] == "create" {
+		if len(args) < 2 {
+			log.Fatal(migrateUsage)
+		}
+		up, down, err := migrate.Create(migrationsDir, args[1])
+		if err != nil {
+			log.Fatal("create migration failed:", err)
+		}
+		log.Printf("✅ Created %s", up)
+		log.Printf("✅ Created %s", down)
+		return
+	}
+
+	steps := 0
+	if len(args) > 1 && args[0] != "baseline" {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			log.Fatal("invalid step count:", args[1])
+		}
+		steps = n
+	}
+
+	cfg := config.Load()
+	db, err := database.NewConnection(cfg.DBDsn)
+	if err != nil {
+		log.Fatal("database connection failed:", err)
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatal("loading migrations failed:", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if args[0] == "baseline" {
+		if len(args) < 2 {
+			log.Fatal(migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 1 {
+			log.Fatal("invalid version:", args[1])
+		}
+		recorded, err := m.Baseline(ctx, version)
+		for _, mig := range recorded {
+			log.Printf("📌 Marked %04d_%s as applied", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal("migrate baseline failed:", err)
+		}
+		if len(recorded) == 0 {
+			log.Println("✅ Already at or past that version")
+		}
+		return
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx, steps)
+		for _, mig := range applied {
+			log.Printf("⬆️  Applied %04d_%s", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal("migrate up failed:", err)
+		}
+		if len(applied) == 0 {
+			log.Println("✅ Database is up to date")
+		}
+	case "down":
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			log.Printf("⬇️  Reverted %04d_%s", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal("migrate down failed:", err)
+		}
+		if len(reverted) == 0 {
+			log.Println("✅ Nothing to roll back")
+		}
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal("migrate status failed:", err)
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-40s %s\n", st.Version, st.Name, applied)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
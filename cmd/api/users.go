@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-saas-api/internal/config"
+	"go-saas-api/internal/database"
+	"go-saas-api/internal/mailer"
+	"go-saas-api/internal/middleware"
+	"go-saas-api/internal/user"
+)
+
+const usersUsage = `usage: api users <command>
+
+commands:
+  set-role <email> <user|admin>  change a user's role (e.g. to create the first admin)`
+
+// runUsers implements the `users` subcommand
+func runUsers(args []string) {
+	if len(args) != 3 || args[0] != "set-role" {
+		log.Fatal(usersUsage)
+	}
+	email, role := args[1], args[2]
+	if role != middleware.RoleUser && role != middleware.RoleAdmin {
+		log.Fatal(usersUsage)
+	}
+
+	cfg := config.Load()
+	db, err := database.NewConnection(cfg.DBDsn)
+	if err != nil {
+		log.Fatal("database connection failed:", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	service := user.NewService(user.NewRepository(db), loadKeys(cfg), mailer.NewLogMailer(cfg.MailFrom, ""), cfg.AppBaseURL, cfg.AccountDeletionGrace)
+	if err := service.SetRoleByEmail(ctx, email, role); err != nil {
+		log.Fatal("set role failed:", err)
+	}
+	log.Printf("✅ %s is now %s", email, role)
+}
@@ -0,0 +1,12 @@
+DROP TRIGGER IF EXISTS update_place_updated_at ON place;
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+
+DROP TABLE IF EXISTS place_category_list CASCADE;
+DROP TABLE IF EXISTS place_category CASCADE;
+DROP TABLE IF EXISTS place CASCADE;
+DROP TABLE IF EXISTS place_status CASCADE;
+DROP TABLE IF EXISTS place_link CASCADE;
+DROP TABLE IF EXISTS revoked_tokens CASCADE;
+DROP TABLE IF EXISTS refresh_tokens CASCADE;
+DROP TABLE IF EXISTS users CASCADE;
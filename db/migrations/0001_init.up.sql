@@ -1,16 +1,6 @@
--- Supabase PostgreSQL Schema
--- Database: golang_api
-
--- ============================================================
--- Drop tables (in dependency order)
--- ============================================================
-
-DROP TABLE IF EXISTS place_category_list CASCADE;
-DROP TABLE IF EXISTS place_category CASCADE;
-DROP TABLE IF EXISTS place_link CASCADE;
-DROP TABLE IF EXISTS place_status CASCADE;
-DROP TABLE IF EXISTS place CASCADE;
-DROP TABLE IF EXISTS users CASCADE;
+-- Initial schema (converted from the former db/db.sql)
+-- Databases created from db.sql already have it: run `migrate baseline 1` there
+-- Supabase PostgreSQL
 
 -- ============================================================
 -- Table: users
@@ -27,6 +17,70 @@ CREATE TABLE users (
 
 CREATE INDEX idx_users_email ON users (email);
 
+-- ============================================================
+-- Table: refresh_tokens
+-- ============================================================
+
+CREATE TABLE refresh_tokens (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  token_hash CHAR(64) NOT NULL UNIQUE,  -- sha256 of the raw token
+  family_id VARCHAR(64) NOT NULL,       -- shared by all tokens rotated from one login
+  access_jti VARCHAR(64) NOT NULL,      -- jti of the access token issued with it
+  access_expires_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ,
+  replaced_by BIGINT REFERENCES refresh_tokens(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+-- ============================================================
+-- Table: revoked_tokens (access token deny-list, by jti)
+-- ============================================================
+
+CREATE TABLE revoked_tokens (
+  jti VARCHAR(64) PRIMARY KEY,
+  user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- ============================================================
+-- Table: place_link
+-- ============================================================
+
+CREATE TABLE place_link (
+  id SMALLSERIAL PRIMARY KEY,
+  domain VARCHAR(50),
+  icon VARCHAR(255)              -- icon social media or g maps for type link
+);
+
+-- ============================================================
+-- Table: place_status
+-- ============================================================
+
+CREATE TABLE place_status (
+  id SMALLSERIAL PRIMARY KEY,
+  name VARCHAR(50),
+  background VARCHAR(8)          -- background for CSS badge with hex code
+);
+
+-- Default lookup values
+INSERT INTO place_link (domain, icon) VALUES
+  ('maps.google.com', 'google-maps'),
+  ('instagram.com', 'instagram'),
+  ('tiktok.com', 'tiktok'),
+  ('other', 'link');                -- fallback when the link domain is unknown
+
+INSERT INTO place_status (name, background) VALUES
+  ('Wishlist', '#6B7280'),
+  ('Planned', '#3B82F6'),
+  ('Visited', '#10B981');
+
 -- ============================================================
 -- Table: place
 -- ============================================================
@@ -36,16 +90,17 @@ CREATE TABLE place (
   user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
   name VARCHAR(255),
   link VARCHAR(255),
-  link_type SMALLINT,
+  link_type SMALLINT REFERENCES place_link(id) ON DELETE SET NULL ON UPDATE CASCADE,
   description TEXT,
   go_at DATE,                    -- rencana tanggal pergi ke tempat tersebut
   go_at_time TIMESTAMP,         -- jam rencana pergi
-  status SMALLINT,
+  status SMALLINT REFERENCES place_status(id) ON DELETE SET NULL ON UPDATE CASCADE,
   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
   updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
 CREATE INDEX idx_place_user_id ON place (user_id);
+CREATE INDEX idx_place_user_created_at ON place (user_id, created_at DESC, id DESC);
 
 -- ============================================================
 -- Table: place_category
@@ -69,30 +124,11 @@ CREATE TABLE place_category_list (
   category_id INTEGER REFERENCES place_category(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 
+CREATE UNIQUE INDEX idx_pcl_place_category ON place_category_list (place_id, category_id);
 CREATE INDEX idx_pcl_place_id ON place_category_list (place_id);
 CREATE INDEX idx_pcl_user_id ON place_category_list (user_id);
 CREATE INDEX idx_pcl_category_id ON place_category_list (category_id);
 
--- ============================================================
--- Table: place_link
--- ============================================================
-
-CREATE TABLE place_link (
-  id SMALLSERIAL PRIMARY KEY,
-  domain VARCHAR(50),
-  icon VARCHAR(255)              -- icon social media or g maps for type link
-);
-
--- ============================================================
--- Table: place_status
--- ============================================================
-
-CREATE TABLE place_status (
-  id SMALLSERIAL PRIMARY KEY,
-  name VARCHAR(50),
-  background VARCHAR(8)          -- background for CSS badge with hex code
-);
-
 -- ============================================================
 -- Auto-update updated_at trigger (replaces MySQL ON UPDATE)
 -- ============================================================
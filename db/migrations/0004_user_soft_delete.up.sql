@@ -0,0 +1,9 @@
+-- ============================================================
+-- Account deletion with a grace period
+-- ============================================================
+
+-- Set by DELETE /me; the row is hard-deleted (cascading to places and
+-- categories) once the grace period has passed
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
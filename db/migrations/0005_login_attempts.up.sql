@@ -0,0 +1,12 @@
+-- ============================================================
+-- Failed login tracking (brute-force protection)
+-- ============================================================
+
+CREATE TABLE login_attempts (
+  key VARCHAR(320) PRIMARY KEY,   -- "email:<address>" or "ip:<address>"
+  failures INT NOT NULL DEFAULT 0,
+  locked_until TIMESTAMPTZ,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_attempts_updated_at ON login_attempts (updated_at);
@@ -0,0 +1,13 @@
+-- ============================================================
+-- Rate limit counters shared by all API replicas
+-- ============================================================
+
+CREATE TABLE rate_limits (
+  key VARCHAR(255) NOT NULL,          -- "<group>:user:<id>" or "<group>:ip:<addr>"
+  window_start TIMESTAMPTZ NOT NULL,
+  count INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMPTZ NOT NULL,    -- once past, the window no longer affects the limit
+  PRIMARY KEY (key, window_start)
+);
+
+CREATE INDEX idx_rate_limits_expires_at ON rate_limits (expires_at);
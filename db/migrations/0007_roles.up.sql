@@ -0,0 +1,7 @@
+-- ============================================================
+-- Roles and disabled accounts
+-- ============================================================
+
+ALTER TABLE users
+  ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+  ADD COLUMN disabled_at TIMESTAMPTZ;   -- set by an admin; disabled users cannot log in
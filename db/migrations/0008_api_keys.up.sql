@@ -0,0 +1,18 @@
+-- ============================================================
+-- Personal API keys
+-- ============================================================
+
+CREATE TABLE api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  name VARCHAR(100) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,           -- first characters of the key, shown to identify it
+  key_hash CHAR(64) NOT NULL UNIQUE,     -- sha256 hex of the full key
+  scopes TEXT[] NOT NULL DEFAULT '{}',
+  expires_at TIMESTAMPTZ,                -- NULL = never expires
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,28 @@
+-- ============================================================
+-- OpenID Connect login
+-- ============================================================
+
+-- External identities linked to a user (one per provider account)
+CREATE TABLE user_identities (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  provider VARCHAR(50) NOT NULL,
+  subject VARCHAR(255) NOT NULL,   -- the provider's "sub" claim
+  email VARCHAR(255),              -- email reported by the provider at link time
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+
+-- Pending authorization requests; each state is redeemed once
+CREATE TABLE oidc_states (
+  state_hash CHAR(64) PRIMARY KEY,  -- sha256 hex of the state sent to the provider
+  provider VARCHAR(50) NOT NULL,
+  code_verifier VARCHAR(128) NOT NULL,
+  nonce VARCHAR(64) NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oidc_states_expires_at ON oidc_states (expires_at);
@@ -0,0 +1,17 @@
+-- ============================================================
+-- Two-factor authentication (TOTP + recovery codes)
+-- ============================================================
+
+ALTER TABLE users
+  ADD COLUMN totp_secret VARCHAR(64),        -- base32; set during enrolment, before it is confirmed
+  ADD COLUMN totp_enabled_at TIMESTAMPTZ,    -- set once enrolment is confirmed
+  ADD COLUMN totp_last_step BIGINT;          -- last accepted time step, so codes cannot be replayed
+
+CREATE TABLE recovery_codes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  code_hash CHAR(64) NOT NULL,   -- sha256 hex of the normalized code
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, code_hash)
+);
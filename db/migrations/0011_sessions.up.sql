@@ -0,0 +1,23 @@
+-- ============================================================
+-- Sessions: one row per login, spanning its refresh token family
+-- ============================================================
+
+CREATE TABLE sessions (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  family_id VARCHAR(64) NOT NULL UNIQUE,      -- refresh_tokens.family_id of the login
+  user_agent VARCHAR(512) NOT NULL DEFAULT '',
+  ip VARCHAR(64) NOT NULL DEFAULT '',         -- client IP at login
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
+
+-- Logins from before sessions existed keep working after their next refresh
+INSERT INTO sessions (user_id, family_id, created_at, last_seen_at)
+SELECT user_id, family_id, MIN(created_at), MAX(created_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL AND expires_at > NOW()
+GROUP BY user_id, family_id;
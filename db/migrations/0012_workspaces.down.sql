@@ -0,0 +1,20 @@
+-- Places go back to being scoped by their creator
+ALTER TABLE place_category_list ADD COLUMN user_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+UPDATE place_category_list pcl SET user_id = p.user_id FROM place p WHERE p.id = pcl.place_id;
+CREATE INDEX idx_pcl_user_id ON place_category_list (user_id);
+
+ALTER TABLE place DROP CONSTRAINT place_user_id_fkey,
+  ADD CONSTRAINT place_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE place_category DROP CONSTRAINT place_category_user_id_fkey,
+  ADD CONSTRAINT place_category_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+DROP INDEX idx_place_workspace_created_at;
+CREATE INDEX idx_place_user_created_at ON place (user_id, created_at DESC, id DESC);
+
+ALTER TABLE place_category_list DROP COLUMN workspace_id;
+ALTER TABLE place_category DROP COLUMN workspace_id;
+ALTER TABLE place DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
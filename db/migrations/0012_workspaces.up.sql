@@ -0,0 +1,77 @@
+-- ============================================================
+-- Workspaces: places and categories belong to a workspace that
+-- users share with an owner, editor or viewer role
+-- ============================================================
+
+CREATE TABLE workspaces (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(120) NOT NULL,
+  personal_user_id BIGINT UNIQUE REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,  -- set on the workspace each user gets automatically
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_workspaces_updated_at
+  BEFORE UPDATE ON workspaces
+  FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE workspace_members (
+  workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX idx_workspace_members_user_id ON workspace_members (user_id);
+
+CREATE TABLE workspace_invitations (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+  token_hash CHAR(64) NOT NULL UNIQUE,  -- sha256 of the raw token
+  invited_by BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,
+  expires_at TIMESTAMPTZ NOT NULL,
+  accepted_at TIMESTAMPTZ,
+  declined_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations (workspace_id);
+
+-- Every existing user gets a personal workspace holding their places
+INSERT INTO workspaces (name, personal_user_id)
+SELECT 'Personal', id FROM users;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, personal_user_id, 'owner' FROM workspaces;
+
+ALTER TABLE place ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE place_category ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE place_category_list ADD COLUMN workspace_id BIGINT REFERENCES workspaces(id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+UPDATE place p SET workspace_id = w.id FROM workspaces w WHERE w.personal_user_id = p.user_id;
+UPDATE place_category pc SET workspace_id = w.id FROM workspaces w WHERE w.personal_user_id = pc.user_id;
+UPDATE place_category_list pcl SET workspace_id = w.id FROM workspaces w WHERE w.personal_user_id = pcl.user_id;
+
+-- Rows without a user were never reachable through the API
+DELETE FROM place_category_list WHERE workspace_id IS NULL;
+DELETE FROM place WHERE workspace_id IS NULL;
+DELETE FROM place_category WHERE workspace_id IS NULL;
+
+ALTER TABLE place ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE place_category ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE place_category_list ALTER COLUMN workspace_id SET NOT NULL;
+
+-- user_id now records who created a place or category; shared rows outlive the creator's account
+ALTER TABLE place DROP CONSTRAINT place_user_id_fkey,
+  ADD CONSTRAINT place_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE;
+ALTER TABLE place_category DROP CONSTRAINT place_category_user_id_fkey,
+  ADD CONSTRAINT place_category_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE;
+ALTER TABLE place_category_list DROP COLUMN user_id;
+
+DROP INDEX idx_place_user_created_at;
+CREATE INDEX idx_place_workspace_created_at ON place (workspace_id, created_at DESC, id DESC);
+CREATE INDEX idx_place_category_workspace_id ON place_category (workspace_id);
+CREATE INDEX idx_pcl_workspace_id ON place_category_list (workspace_id);
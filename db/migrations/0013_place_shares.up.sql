@@ -0,0 +1,26 @@
+-- ============================================================
+-- Public share links for a category or a filtered set of places
+-- ============================================================
+
+CREATE TABLE place_share (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  user_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,  -- creator
+  name VARCHAR(120) NOT NULL DEFAULT '',
+  token_prefix VARCHAR(16) NOT NULL,     -- first characters of the token, shown to identify the link
+  token_hash CHAR(64) NOT NULL UNIQUE,   -- sha256 hex of the token
+  password_hash VARCHAR(255),            -- bcrypt; NULL = no password
+  -- Filter applied to the workspace's places; NULL/empty = not filtered
+  category_id INTEGER REFERENCES place_category(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  status SMALLINT REFERENCES place_status(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  link_type SMALLINT REFERENCES place_link(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  go_at_from DATE,
+  go_at_to DATE,
+  q VARCHAR(255) NOT NULL DEFAULT '',
+  sort VARCHAR(16) NOT NULL DEFAULT 'created_at',
+  sort_order VARCHAR(4) NOT NULL DEFAULT 'desc',
+  expires_at TIMESTAMPTZ,                -- NULL = never expires
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_place_share_workspace_id ON place_share (workspace_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS trip_item;
+DROP TABLE IF EXISTS trip;
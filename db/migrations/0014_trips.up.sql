@@ -0,0 +1,47 @@
+-- ============================================================
+-- Trips: an itinerary of a workspace's places over a date range
+-- ============================================================
+
+CREATE TABLE trip (
+  id BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  user_id BIGINT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,  -- creator
+  name VARCHAR(120) NOT NULL,
+  description TEXT,
+  start_date DATE NOT NULL,
+  end_date DATE NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_trip_workspace_start_date ON trip (workspace_id, start_date, id);
+
+CREATE TRIGGER update_trip_updated_at
+  BEFORE UPDATE ON trip
+  FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================================
+-- Trip items: a place planned on one day of a trip
+-- ============================================================
+
+CREATE TABLE trip_item (
+  id BIGSERIAL PRIMARY KEY,
+  trip_id BIGINT NOT NULL REFERENCES trip(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  place_id BIGINT NOT NULL REFERENCES place(id) ON DELETE CASCADE ON UPDATE CASCADE,
+  day DATE NOT NULL,
+  position INTEGER NOT NULL,       -- order within the day
+  start_at TIMESTAMP,              -- planned visit, same convention as place.go_at_time
+  end_at TIMESTAMP,
+  note TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  CHECK (end_at IS NULL OR (start_at IS NOT NULL AND end_at > start_at))
+);
+
+CREATE INDEX idx_trip_item_trip_day ON trip_item (trip_id, day, position, id);
+CREATE INDEX idx_trip_item_place_id ON trip_item (place_id);
+
+CREATE TRIGGER update_trip_item_updated_at
+  BEFORE UPDATE ON trip_item
+  FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,10 @@
+package buildinfo
+
+// Set at link time, e.g.
+//
+//	go build -ldflags "-X go-saas-api/internal/buildinfo.Version=v1.2.3 -X go-saas-api/internal/buildinfo.Commit=abc123"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
@@ -3,35 +3,209 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const defaultJWTSecret = "dev-secret-change-in-production"
+
 type Config struct {
+	AppEnv    string // defaults to "production"; "development" allows insecure defaults such as the dev JWT secret
 	Port      string
 	DBDsn     string
-	JWTSecret string
+	JWTSecret string // HS256 secret, used only when JWTKeysDir is empty
+
+	// Asymmetric signing: JWTKeysDir holds <kid>.pem files (RSA or Ed25519).
+	// Public-only files keep rotated-out keys verifiable until their tokens expire.
+	JWTKeysDir      string
+	JWTSigningKeyID string // required when the directory holds several private keys
+	JWTIssuer       string
+	JWTAudience     string
+
+	// HTTP server timeouts
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration // max time to drain in-flight requests
+
+	// TrustedProxies may set X-Forwarded-For; empty trusts none so client IPs cannot be spoofed
+	TrustedProxies []string
+
+	// Rate limiting; a limit of 0 disables the group
+	RateLimitStore string // "memory" (per replica) or "postgres" (shared)
+	AuthRateLimit  int    // requests per AuthRateWindow on /auth and on /shared, per client IP
+	AuthRateWindow time.Duration
+	APIRateLimit   int // requests per APIRateWindow on other authenticated routes, per user
+	APIRateWindow  time.Duration
+
+	// AppBaseURL is the frontend URL used to build links in emails
+	AppBaseURL string
+
+	// Mail
+	MailDriver   string // "smtp" or "log"
+	MailFrom     string
+	MailLogDir   string // log driver: also write .eml files here when set
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// AccountDeletionGrace is how long a deleted account can be restored by logging in
+	AccountDeletionGrace time.Duration
+
+	// RequireVerifiedEmail blocks place creation until the user verified their email
+	RequireVerifiedEmail bool
+
+	// OIDCProviders enable social / single sign-on login, see loadOIDCProviders
+	OIDCProviders []OIDCProvider
+}
+
+// OIDCProvider configures one OpenID Connect provider
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // frontend page the provider sends the browser back to
+	Scopes       []string
 }
 
 func Load() *Config {
 	_ = godotenv.Load()
 
 	cfg := &Config{
+		AppEnv:    getEnv("APP_ENV", "production"),
 		Port:      getEnv("PORT", "8080"),
 		DBDsn:     os.Getenv("DB_DSN"),
-		JWTSecret: getEnv("JWT_SECRET", "dev-secret-change-in-production"),
+		JWTSecret: getEnv("JWT_SECRET", defaultJWTSecret),
+
+		JWTKeysDir:      os.Getenv("JWT_KEYS_DIR"),
+		JWTSigningKeyID: os.Getenv("JWT_SIGNING_KEY_ID"),
+		JWTIssuer:       getEnv("JWT_ISSUER", "go-saas-api"),
+		JWTAudience:     getEnv("JWT_AUDIENCE", "go-saas-api"),
+
+		ReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 10*time.Second),
+		ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 15*time.Second),
+		IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout:   getEnvDuration("SHUTDOWN_TIMEOUT", 20*time.Second),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		RateLimitStore: getEnv("RATE_LIMIT_STORE", "memory"),
+		AuthRateLimit:  getEnvInt("RATE_LIMIT_AUTH", 20),
+		AuthRateWindow: getEnvDuration("RATE_LIMIT_AUTH_WINDOW", time.Minute),
+		APIRateLimit:   getEnvInt("RATE_LIMIT_API", 300),
+		APIRateWindow:  getEnvDuration("RATE_LIMIT_API_WINDOW", time.Minute),
+
+		AppBaseURL: getEnv("APP_BASE_URL", "http://localhost:3000"),
+
+		MailDriver:   getEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailLogDir:   os.Getenv("MAIL_LOG_DIR"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+		AccountDeletionGrace: getEnvDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+
+		RequireVerifiedEmail: getEnvBool("REQUIRE_VERIFIED_EMAIL", false),
 	}
+	cfg.OIDCProviders = loadOIDCProviders(cfg.AppBaseURL)
 
 	if cfg.DBDsn == "" {
 		log.Fatal("DB_DSN is required")
 	}
+	if cfg.JWTKeysDir == "" && cfg.JWTSecret == defaultJWTSecret && cfg.AppEnv != "development" {
+		log.Fatal("JWT_SECRET must be changed (or JWT_KEYS_DIR set) when APP_ENV is not development")
+	}
+	if cfg.MailDriver == "smtp" && cfg.SMTPHost == "" {
+		log.Fatal("SMTP_HOST is required when MAIL_DRIVER=smtp")
+	}
 
 	return cfg
 }
 
+// loadOIDCProviders reads OIDC_PROVIDERS (e.g. "google,keycloak") and, for each
+// name, OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES
+func loadOIDCProviders(appBaseURL string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range getEnvList("OIDC_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		p := OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", appBaseURL+"/auth/callback/"+name),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if p.Issuer == "" || p.ClientID == "" {
+			log.Fatalf("%sISSUER and %sCLIENT_ID are required for OIDC provider %q", prefix, prefix, name)
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
+
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return fallback
 }
+
+// getEnvDuration parses values such as "10s" or "1m"
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s must be a duration (e.g. 10s): %v", key, err)
+	}
+	return d
+}
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be an integer: %v", key, err)
+	}
+	return n
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s must be a boolean: %v", key, err)
+	}
+	return b
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
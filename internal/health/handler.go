@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+
+	"go-saas-api/internal/buildinfo"
+	"go-saas-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+type Handler struct {
+	db *sqlx.DB
+}
+
+func NewHandler(db *sqlx.DB) *Handler {
+	return &Handler{db: db}
+}
+
+// GET /healthz - liveness, the process is up and serving
+func (h *Handler) Liveness(c *gin.Context) {
+	response.Success(c, http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /readyz - readiness, dependencies are reachable
+func (h *Handler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	stats := h.db.Stats()
+	pool := gin.H{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
+	}
+
+	start := time.Now()
+	if err := h.db.PingContext(ctx); err != nil {
+		// The error can name hosts and users, so it stays in the server log
+		log.Printf("readiness: database ping: %v", err)
+		response.Success(c, http.StatusServiceUnavailable, gin.H{
+			"status":   "unavailable",
+			"database": gin.H{"status": "down", "pool": pool},
+		})
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"status": "ready",
+		"database": gin.H{
+			"status":     "up",
+			"latency_ms": time.Since(start).Milliseconds(),
+			"pool":       pool,
+		},
+	})
+}
+
+// GET /version
+func (h *Handler) Version(c *gin.Context) {
+	response.Success(c, http.StatusOK, gin.H{
+		"version":    buildinfo.Version,
+		"commit":     buildinfo.Commit,
+		"build_time": buildinfo.BuildTime,
+		"go_version": runtime.Version(),
+	})
+}
@@ -0,0 +1,10 @@
+package health
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(r *gin.Engine, h *Handler) {
+	// Probe routes - public
+	r.GET("/healthz", h.Liveness)
+	r.GET("/readyz", h.Readiness)
+	r.GET("/version", h.Version)
+}
@@ -0,0 +1,186 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is one signing or verification key, identified by the kid header
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	private any              // nil for verify-only (retired) keys
+	public  any              // key used to verify signatures
+	jwk     crypto.PublicKey // published in the JWKS; nil for HMAC
+}
+
+// KeySet signs access tokens with its current key and verifies tokens signed
+// by any of its keys, so keys can rotate without invalidating issued tokens.
+type KeySet struct {
+	Issuer   string
+	Audience string // audience of access tokens
+
+	signing *Key
+	keys    map[string]*Key
+}
+
+// Options configures New. Secret is used (HS256) unless KeysDir is set.
+type Options struct {
+	Secret       string
+	KeysDir      string // directory of <kid>.pem files (RSA or Ed25519)
+	SigningKeyID string // kid of the private key that signs; optional if only one exists
+	Issuer       string
+	Audience     string
+}
+
+func New(opts Options) (*KeySet, error) {
+	if opts.KeysDir == "" {
+		if opts.Secret == "" {
+			return nil, errors.New("jwtauth: either a secret or a keys directory is required")
+		}
+		return NewHMAC(opts.Secret, opts.Issuer, opts.Audience), nil
+	}
+	return LoadDir(opts.KeysDir, opts.SigningKeyID, opts.Issuer, opts.Audience)
+}
+
+// NewHMAC returns a key set with a single HS256 key
+func NewHMAC(secret, issuer, audience string) *KeySet {
+	sum := sha256.Sum256([]byte(secret))
+	key := &Key{
+		ID:      "hs256-" + hex.EncodeToString(sum[:4]),
+		Method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+	return &KeySet{
+		Issuer:   issuer,
+		Audience: audience,
+		signing:  key,
+		keys:     map[string]*Key{key.ID: key},
+	}
+}
+
+// LoadDir loads every <kid>.pem in dir. Private keys (PKCS#1/PKCS#8) can sign,
+// public keys only verify, which is how retired keys are kept during rotation.
+func LoadDir(dir, signingKeyID, issuer, audience string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	ks := &KeySet{Issuer: issuer, Audience: audience, keys: map[string]*Key{}}
+	var signers []*Key
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := parsePEM(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("jwtauth: %s: %w", path, err)
+		}
+		ks.keys[kid] = key
+		if key.private != nil {
+			signers = append(signers, key)
+		}
+	}
+
+	switch {
+	case signingKeyID != "":
+		key, ok := ks.keys[signingKeyID]
+		if !ok || key.private == nil {
+			return nil, fmt.Errorf("jwtauth: no private key %q in %s", signingKeyID, dir)
+		}
+		ks.signing = key
+	case len(signers) == 1:
+		ks.signing = signers[0]
+	default:
+		return nil, fmt.Errorf("jwtauth: found %d private keys in %s, set the signing key id", len(signers), dir)
+	}
+	return ks, nil
+}
+
+func parsePEM(kid string, data []byte) (*Key, error) {
+	if k, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey, jwk: &k.PublicKey}, nil
+	}
+	if k, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		pub := k.(ed25519.PrivateKey).Public()
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, private: k, public: pub, jwk: pub}, nil
+	}
+	if k, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, public: k, jwk: k}, nil
+	}
+	if k, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, public: k, jwk: k}, nil
+	}
+	return nil, errors.New("not an RSA or Ed25519 key in PEM format")
+}
+
+// Sign signs claims with the current key, setting iss, iat and (unless present) aud
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = ks.Issuer
+	claims["iat"] = time.Now().Unix()
+	if _, ok := claims["aud"]; !ok {
+		claims["aud"] = ks.Audience
+	}
+
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.private)
+}
+
+// Parse verifies the signature, the key's algorithm, iss, aud, iat and a required exp
+func (ks *KeySet) Parse(tokenString, audience string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, ks.keyfunc,
+		jwt.WithIssuer(ks.Issuer),
+		jwt.WithAudience(audience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30*time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// keyfunc picks the key by kid and refuses tokens whose alg does not match it
+func (ks *KeySet) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// publicKeys returns the keys published in the JWKS, ordered by kid
+func (ks *KeySet) publicKeys() []*Key {
+	var keys []*Key
+	for _, k := range ks.keys {
+		if k.jwk != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
@@ -0,0 +1,202 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey writes a PKCS#8 private key (or its PKIX public key when public is set) to dir/<kid>.pem
+func writeKey(t *testing.T, dir, kid string, key any, public bool) {
+	t.Helper()
+	var block *pem.Block
+	if public {
+		var pub any
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			pub = &k.PublicKey
+		case ed25519.PrivateKey:
+			pub = k.Public()
+		}
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatalf("marshal public key: %v", err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("marshal private key: %v", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+}
+
+func accessClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "42", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestKeySetSignAndParse(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	edDir, rsaDir := t.TempDir(), t.TempDir()
+	writeKey(t, edDir, "ed", edKey, false)
+	writeKey(t, rsaDir, "rsa", rsaKey, false)
+
+	edKeys, err := LoadDir(edDir, "", "iss", "api")
+	if err != nil {
+		t.Fatalf("LoadDir ed25519: %v", err)
+	}
+	rsaKeys, err := LoadDir(rsaDir, "", "iss", "api")
+	if err != nil {
+		t.Fatalf("LoadDir rsa: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		keys *KeySet
+		alg  string
+	}{
+		{"hmac", NewHMAC("secret", "iss", "api"), "HS256"},
+		{"ed25519", edKeys, "EdDSA"},
+		{"rsa", rsaKeys, "RS256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.keys.Sign(accessClaims())
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if err != nil || parsed.Method.Alg() != tt.alg || parsed.Header["kid"] == nil {
+				t.Fatalf("header = %v, want alg %s with kid (err %v)", parsed.Header, tt.alg, err)
+			}
+
+			claims, err := tt.keys.Parse(token, "api")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if sub, _ := claims.GetSubject(); sub != "42" {
+				t.Errorf("sub = %q, want 42", sub)
+			}
+			if iss, _ := claims.GetIssuer(); iss != "iss" {
+				t.Errorf("iss = %q, want iss", iss)
+			}
+
+			if _, err := tt.keys.Parse(token, "email-verification"); err == nil {
+				t.Error("token accepted for another audience")
+			}
+		})
+	}
+}
+
+func TestKeySetRejects(t *testing.T) {
+	keys := NewHMAC("secret", "iss", "api")
+	other := NewHMAC("other-secret", "iss", "api")
+	otherIssuer := NewHMAC("secret", "someone-else", "api")
+
+	sign := func(ks *KeySet, claims jwt.MapClaims) string {
+		token, err := ks.Sign(claims)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return token
+	}
+
+	// Unsigned token carrying a known kid
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, accessClaims())
+	none.Header["kid"] = keys.signing.ID
+	unsigned, _ := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"unknown key", sign(other, accessClaims())},
+		{"wrong issuer", sign(otherIssuer, accessClaims())},
+		{"expired", sign(keys, jwt.MapClaims{"sub": "42", "exp": time.Now().Add(-time.Hour).Unix()})},
+		{"missing exp", sign(keys, jwt.MapClaims{"sub": "42"})},
+		{"alg none", unsigned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := keys.Parse(tt.token, "api"); err == nil {
+				t.Error("Parse accepted the token")
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	writeKey(t, dir, "2024-old", oldKey, false)
+
+	before, err := LoadDir(dir, "", "iss", "api")
+	if err != nil {
+		t.Fatalf("LoadDir: %v", err)
+	}
+	oldToken, err := before.Sign(accessClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	// Two private keys need an explicit signing key
+	writeKey(t, dir, "2025-new", newKey, false)
+	if _, err := LoadDir(dir, "", "iss", "api"); err == nil {
+		t.Error("LoadDir with two private keys and no signing key id succeeded")
+	}
+	if _, err := LoadDir(dir, "missing", "iss", "api"); err == nil {
+		t.Error("LoadDir with unknown signing key id succeeded")
+	}
+
+	// Retire the old key: keep only its public half
+	writeKey(t, dir, "2024-old", oldKey, true)
+	after, err := LoadDir(dir, "", "iss", "api")
+	if err != nil {
+		t.Fatalf("LoadDir after rotation: %v", err)
+	}
+	if after.signing.ID != "2025-new" {
+		t.Errorf("signing kid = %s, want 2025-new", after.signing.ID)
+	}
+	if _, err := after.Parse(oldToken, "api"); err != nil {
+		t.Errorf("token signed by retired key rejected: %v", err)
+	}
+	newToken, _ := after.Sign(accessClaims())
+	if _, err := before.Parse(newToken, "api"); err == nil {
+		t.Error("old key set accepted a token signed by a key it does not know")
+	}
+
+	jwks := after.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != "2024-old" || jwks.Keys[1].Kid != "2025-new" {
+		t.Fatalf("JWKS = %+v, want both keys", jwks)
+	}
+	if k := jwks.Keys[0]; k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" {
+		t.Errorf("JWK = %+v", k)
+	}
+}
+
+func TestKeySetJWKSOmitsHMAC(t *testing.T) {
+	if keys := NewHMAC("secret", "iss", "api").JWKS().Keys; len(keys) != 0 {
+		t.Errorf("JWKS published %d HMAC keys", len(keys))
+	}
+}
@@ -1,26 +1,108 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"go-saas-api/internal/jwtauth"
+	"go-saas-api/pkg/apperror"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+var (
+	ErrAuthHeaderRequired = apperror.New(apperror.KindUnauthorized, "auth_header_required", "authorization header required")
+	ErrInvalidAuthFormat  = apperror.New(apperror.KindUnauthorized, "invalid_auth_format", "invalid authorization format")
+	ErrInvalidToken       = apperror.New(apperror.KindUnauthorized, "invalid_token", "invalid or expired token")
+	ErrInvalidTokenClaims = apperror.New(apperror.KindUnauthorized, "invalid_token_claims", "invalid token claims")
+	ErrTokenRevoked       = apperror.New(apperror.KindUnauthorized, "token_revoked", "token has been revoked")
+	ErrSessionRevoked     = apperror.New(apperror.KindUnauthorized, "session_revoked", "session has been logged out")
+	ErrEmailNotVerified   = apperror.New(apperror.KindForbidden, "email_not_verified", "email address must be verified first")
+	ErrInsufficientRole   = apperror.New(apperror.KindForbidden, "insufficient_role", "you do not have permission to do this")
+	ErrInvalidAPIKey      = apperror.New(apperror.KindUnauthorized, "invalid_api_key", "invalid, expired or revoked API key")
+	ErrAPIKeyNotAllowed   = apperror.New(apperror.KindForbidden, "api_key_not_allowed", "this endpoint does not accept API keys")
+	ErrInsufficientScope  = apperror.New(apperror.KindForbidden, "insufficient_scope", "API key is missing a required scope")
+)
+
+// Roles carried in the access token's role claim
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Scopes that can be granted to personal API keys. Access tokens are not scoped.
+const (
+	ScopePlacesRead  = "places:read"
+	ScopePlacesWrite = "places:write"
+)
+
+// APIKeyPrefix starts every personal API key, telling "Bearer <key>" apart from a JWT
+const APIKeyPrefix = "sk_"
+
+// APIKeyPrincipal is the owner and grants of an authenticated API key
+type APIKeyPrincipal struct {
+	KeyID  uint64
+	UserID uint64
+	Role   string
+	Scopes []string
+}
+
+// APIKeyAuthenticator resolves a personal API key. It returns nil for unknown,
+// expired or revoked keys and for keys whose owner can no longer log in.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+// RevocationChecker reports whether an access token (by jti) has been revoked
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// VerificationChecker reports whether a user has verified their email address
+type VerificationChecker interface {
+	IsEmailVerified(ctx context.Context, userID uint64) (bool, error)
+}
+
+// SessionTracker reports whether the session an access token belongs to is
+// still live, and records the activity
+type SessionTracker interface {
+	TouchSession(ctx context.Context, id, userID uint64, seenAt time.Time) (bool, error)
+}
+
 type AuthMiddleware struct {
-	jwtSecret []byte
+	keys     *jwtauth.KeySet
+	revoked  RevocationChecker
+	verified VerificationChecker // nil disables RequireVerifiedEmail
+	apiKeys  APIKeyAuthenticator // nil rejects every API key
+	sessions SessionTracker      // nil skips session checks
 }
 
-func NewAuthMiddleware(secret string) *AuthMiddleware {
-	return &AuthMiddleware{jwtSecret: []byte(secret)}
+func NewAuthMiddleware(keys *jwtauth.KeySet, revoked RevocationChecker, verified VerificationChecker, apiKeys APIKeyAuthenticator, sessions SessionTracker) *AuthMiddleware {
+	return &AuthMiddleware{
+		keys:     keys,
+		revoked:  revoked,
+		verified: verified,
+		apiKeys:  apiKeys,
+		sessions: sessions,
+	}
 }
 
-func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
+// RequireAuth authenticates the request with an access token. Routes that pass
+// scopes also accept personal API keys (X-API-Key or "Bearer sk_...") holding
+// all of them; routes without scopes are closed to API keys.
+func (m *AuthMiddleware) RequireAuth(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			m.authenticateAPIKey(c, key, scopes)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
+			c.Error(ErrAuthHeaderRequired)
 			c.Abort()
 			return
 		}
@@ -28,44 +110,197 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		// Format: Bearer <token>
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
+			c.Error(ErrInvalidAuthFormat)
 			c.Abort()
 			return
 		}
 
 		tokenString := parts[1]
+		if strings.HasPrefix(tokenString, APIKeyPrefix) {
+			m.authenticateAPIKey(c, tokenString, scopes)
+			return
+		}
 
-		// Parse & validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return m.jwtSecret, nil
-		})
+		// Parse & validate signature (by kid), iss, aud, iat and exp
+		claims, err := m.keys.Parse(tokenString, m.keys.Audience)
+		if err != nil {
+			c.Error(ErrInvalidToken)
+			c.Abort()
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		// Extract user ID from the subject
+		sub, _ := claims.GetSubject()
+		userID, err := strconv.ParseUint(sub, 10, 64)
+		if err != nil || userID == 0 {
+			c.Error(ErrInvalidTokenClaims)
 			c.Abort()
 			return
 		}
 
-		// Extract user ID from claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			c.Error(ErrInvalidTokenClaims)
 			c.Abort()
 			return
 		}
 
-		userID, ok := claims["user_id"].(float64) // JWT numbers are float64
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
+		exp, err := claims.GetExpirationTime()
+		if err != nil || exp == nil {
+			c.Error(ErrInvalidTokenClaims)
 			c.Abort()
 			return
 		}
 
+		// Tokens issued before roles existed carry no role claim
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = RoleUser
+		}
+
+		// Reject tokens revoked by logout / password change
+		if m.revoked != nil {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+			defer cancel()
+
+			revoked, err := m.revoked.IsTokenRevoked(ctx, jti)
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.Error(ErrTokenRevoked)
+				c.Abort()
+				return
+			}
+		}
+
+		// Reject tokens of sessions logged out from another device
+		var sessionID uint64
+		if m.sessions != nil {
+			sid, _ := claims["sid"].(string)
+			sessionID, err = strconv.ParseUint(sid, 10, 64)
+			if err != nil || sessionID == 0 {
+				c.Error(ErrInvalidTokenClaims)
+				c.Abort()
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+			defer cancel()
+
+			active, err := m.sessions.TouchSession(ctx, sessionID, userID, time.Now())
+			if err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			if !active {
+				c.Error(ErrSessionRevoked)
+				c.Abort()
+				return
+			}
+		}
+
 		// Set userID in context for use in handlers
-		c.Set("userID", uint64(userID))
+		c.Set("userID", userID)
+		c.Set("sessionID", sessionID)
+		c.Set("tokenJTI", jti)
+		c.Set("tokenExpiresAt", exp.Time)
+		c.Set("userRole", role)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey authenticates with a personal API key that must hold every scope
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, key string, scopes []string) {
+	if len(scopes) == 0 {
+		c.Error(ErrAPIKeyNotAllowed)
+		c.Abort()
+		return
+	}
+	if m.apiKeys == nil {
+		c.Error(ErrInvalidAPIKey)
+		c.Abort()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	principal, err := m.apiKeys.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	if principal == nil {
+		c.Error(ErrInvalidAPIKey)
+		c.Abort()
+		return
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(principal.Scopes, scope) {
+			c.Error(ErrInsufficientScope.WithDetails(gin.H{"required": scopes}))
+			c.Abort()
+			return
+		}
+	}
+
+	c.Set("userID", principal.UserID)
+	c.Set("userRole", principal.Role)
+	c.Set("apiKeyID", principal.KeyID)
+	c.Next()
+}
+
+// RequireRole rejects users whose role is not one of roles. Must run after RequireAuth.
+func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("userRole")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.Error(ErrInsufficientRole)
+		c.Abort()
+	}
+}
+
+// RequireVerifiedEmail rejects users whose email is not verified yet.
+// Must run after RequireAuth; it is a no-op when no VerificationChecker is configured.
+func (m *AuthMiddleware) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.verified == nil {
+			c.Next()
+			return
+		}
+
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.Error(apperror.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		verified, err := m.verified.IsEmailVerified(ctx, userID.(uint64))
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.Error(ErrEmailNotVerified)
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
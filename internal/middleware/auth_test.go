@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-saas-api/internal/jwtauth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// staticAPIKeys authenticates the keys in the map
+type staticAPIKeys map[string]*APIKeyPrincipal
+
+func (s staticAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	return s[key], nil
+}
+
+// staticSessions knows the live sessions of user 7
+type staticSessions map[uint64]bool
+
+func (s staticSessions) TouchSession(ctx context.Context, id, userID uint64, seenAt time.Time) (bool, error) {
+	return userID == 7 && s[id], nil
+}
+
+func TestRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := jwtauth.NewHMAC("test-secret", "test", "test")
+	apiKeys := staticAPIKeys{
+		"sk_read": {KeyID: 1, UserID: 7, Role: RoleUser, Scopes: []string{ScopePlacesRead}},
+	}
+	auth := NewAuthMiddleware(keys, nil, nil, apiKeys, nil)
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return token
+	}
+	exp := time.Now().Add(time.Minute).Unix()
+	access := sign(jwt.MapClaims{"sub": "7", "jti": "j1", "exp": exp})
+	otherAudience := sign(jwt.MapClaims{"sub": "7", "jti": "j2", "exp": exp, "aud": "email-verification"})
+	noSubject := sign(jwt.MapClaims{"jti": "j3", "exp": exp})
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "%d", c.GetUint64("userID")) }
+	r.GET("/me", auth.RequireAuth(), ok)
+	r.GET("/read", auth.RequireAuth(ScopePlacesRead), ok)
+	r.GET("/write", auth.RequireAuth(ScopePlacesWrite), ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"access token", "/me", "Authorization", "Bearer " + access, http.StatusOK},
+		{"access token on scoped route", "/write", "Authorization", "Bearer " + access, http.StatusOK},
+		{"missing header", "/me", "", "", http.StatusUnauthorized},
+		{"wrong audience", "/me", "Authorization", "Bearer " + otherAudience, http.StatusUnauthorized},
+		{"missing subject", "/me", "Authorization", "Bearer " + noSubject, http.StatusUnauthorized},
+		{"api key with scope", "/read", "X-API-Key", "sk_read", http.StatusOK},
+		{"api key as bearer", "/read", "Authorization", "Bearer sk_read", http.StatusOK},
+		{"api key missing scope", "/write", "X-API-Key", "sk_read", http.StatusForbidden},
+		{"api key on unscoped route", "/me", "X-API-Key", "sk_read", http.StatusForbidden},
+		{"unknown api key", "/read", "X-API-Key", "sk_unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code == http.StatusOK && w.Body.String() != "7" {
+				t.Errorf("userID = %s, want 7", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAuthSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := jwtauth.NewHMAC("test-secret", "test", "test")
+	auth := NewAuthMiddleware(keys, nil, nil, nil, staticSessions{1: true, 2: false})
+
+	exp := time.Now().Add(time.Minute).Unix()
+	sign := func(sid any) string {
+		claims := jwt.MapClaims{"sub": "7", "jti": "j1", "exp": exp}
+		if sid != nil {
+			claims["sid"] = sid
+		}
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return token
+	}
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/me", auth.RequireAuth(), func(c *gin.Context) { c.String(http.StatusOK, "%d", c.GetUint64("sessionID")) })
+
+	tests := []struct {
+		name       string
+		sid        any
+		wantStatus int
+	}{
+		{"live session", "1", http.StatusOK},
+		{"revoked session", "2", http.StatusUnauthorized},
+		{"unknown session", "3", http.StatusUnauthorized},
+		{"missing session", nil, http.StatusUnauthorized},
+		{"numeric claim", 1, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+sign(tt.sid))
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code == http.StatusOK && w.Body.String() != "1" {
+				t.Errorf("sessionID = %s, want 1", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"go-saas-api/pkg/apperror"
+	"go-saas-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+var statusByKind = map[apperror.Kind]int{
+	apperror.KindInternal:        http.StatusInternalServerError,
+	apperror.KindInvalid:         http.StatusBadRequest,
+	apperror.KindUnauthorized:    http.StatusUnauthorized,
+	apperror.KindForbidden:       http.StatusForbidden,
+	apperror.KindNotFound:        http.StatusNotFound,
+	apperror.KindConflict:        http.StatusConflict,
+	apperror.KindTooManyRequests: http.StatusTooManyRequests,
+}
+
+// ErrorHandler writes the response for the last error added with c.Error.
+// *apperror.Error values are mapped to their status and code; anything else
+// is logged and reported as a 500 without leaking its message.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		var appErr *apperror.Error
+		if !errors.As(err, &appErr) {
+			log.Printf("unhandled error on %s %s: %v", c.Request.Method, c.FullPath(), err)
+			appErr = apperror.ErrInternal
+		}
+
+		status, ok := statusByKind[appErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		if appErr.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
+		response.ErrorWithCode(c, status, appErr.Code, appErr.Message, appErr.Details)
+	}
+}
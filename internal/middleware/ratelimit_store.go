@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sweepInterval is how often stores drop windows that no longer matter
+const sweepInterval = time.Minute
+
+// MemoryRateStore keeps counters in process. Limits are per replica.
+type MemoryRateStore struct {
+	mu        sync.Mutex
+	windows   map[string]rateWindow
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	start    time.Time
+	window   time.Duration
+	current  int
+	previous int
+}
+
+var _ RateStore = (*MemoryRateStore)(nil)
+
+func NewMemoryRateStore() *MemoryRateStore {
+	return &MemoryRateStore{windows: map[string]rateWindow{}}
+}
+
+func (s *MemoryRateStore) Hit(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, w := range s.windows {
+			if now.Sub(w.start) > 2*w.window {
+				delete(s.windows, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	w, ok := s.windows[key]
+	switch {
+	case !ok || windowStart.Sub(w.start) > window:
+		w = rateWindow{start: windowStart, window: window}
+	case windowStart.After(w.start):
+		// Roll over into the next window
+		w = rateWindow{start: windowStart, window: window, previous: w.current}
+	}
+	w.current++
+	s.windows[key] = w
+	return w.current, w.previous, nil
+}
+
+// PostgresRateStore keeps counters in the rate_limits table so every replica shares them
+type PostgresRateStore struct {
+	db *sqlx.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+var _ RateStore = (*PostgresRateStore)(nil)
+
+func NewPostgresRateStore(db *sqlx.DB) *PostgresRateStore {
+	return &PostgresRateStore{db: db}
+}
+
+func (s *PostgresRateStore) Hit(ctx context.Context, key string, windowStart time.Time, window time.Duration) (int, int, error) {
+	s.sweep()
+
+	var counts struct {
+		Current  int `db:"current"`
+		Previous int `db:"previous"`
+	}
+	err := s.db.GetContext(ctx, &counts,
+		`WITH hit AS (
+			INSERT INTO rate_limits (key, window_start, count, expires_at)
+			VALUES ($1, $2, 1, $3)
+			ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limits.count + 1
+			RETURNING count
+		)
+		SELECT
+			(SELECT count FROM hit) AS current,
+			COALESCE((SELECT count FROM rate_limits WHERE key = $1 AND window_start = $4), 0) AS previous`,
+		key, windowStart, windowStart.Add(2*window), windowStart.Add(-window),
+	)
+	return counts.Current, counts.Previous, err
+}
+
+// sweep deletes expired windows at most once per sweepInterval per replica
+func (s *PostgresRateStore) sweep() {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE expires_at < NOW()`); err != nil {
+			log.Printf("rate limit sweep: %v", err)
+		}
+	}()
+}
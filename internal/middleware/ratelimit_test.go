@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-saas-api/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stores lists every RateStore implementation the suite runs against
+var stores = []struct {
+	name string
+	new  func(t *testing.T) RateStore
+}{
+	{"memory", func(t *testing.T) RateStore { return NewMemoryRateStore() }},
+	{"postgres", func(t *testing.T) RateStore { return NewPostgresRateStore(testutil.PostgresDB(t)) }},
+}
+
+func TestRateStoreSlidingWindow(t *testing.T) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := s.new(t)
+			start := time.Now().Truncate(time.Hour)
+
+			for want := 1; want <= 2; want++ {
+				current, previous, err := store.Hit(ctx, "k", start, time.Hour)
+				if err != nil || current != want || previous != 0 {
+					t.Fatalf("Hit = %d, %d, %v; want %d, 0", current, previous, err, want)
+				}
+			}
+
+			// The next window starts fresh and reports the previous count
+			current, previous, _ := store.Hit(ctx, "k", start.Add(time.Hour), time.Hour)
+			if current != 1 || previous != 2 {
+				t.Errorf("next window Hit = %d, %d; want 1, 2", current, previous)
+			}
+
+			if current, _, _ := store.Hit(ctx, "other", start, time.Hour); current != 1 {
+				t.Errorf("other key count = %d, want 1", current)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewRateLimiter(NewMemoryRateStore(), map[string]RateRule{
+		"auth": {Limit: 2, Window: time.Hour},
+	})
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.GET("/limited", limiter.Limit("auth"), ok)
+	r.GET("/user", func(c *gin.Context) { c.Set("userID", uint64(1)) }, limiter.Limit("auth"), ok)
+	r.GET("/unlimited", limiter.Limit("missing"), ok)
+
+	do := func(path, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name          string
+		path, ip      string
+		wantStatus    int
+		wantRemaining string
+	}{
+		{"first request", "/limited", "192.0.2.1", http.StatusNoContent, "1"},
+		{"second request", "/limited", "192.0.2.1", http.StatusNoContent, "0"},
+		{"over the limit", "/limited", "192.0.2.1", http.StatusTooManyRequests, "0"},
+		{"other IP has its own budget", "/limited", "192.0.2.2", http.StatusNoContent, "1"},
+		{"users are keyed by ID, not IP", "/user", "192.0.2.1", http.StatusNoContent, "1"},
+		{"group without a rule", "/unlimited", "192.0.2.1", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.path, tt.ip)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("X-RateLimit-Remaining"); got != tt.wantRemaining {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", got, tt.wantRemaining)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Error("Retry-After header missing")
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go-saas-api/pkg/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInvalidWorkspaceID        = apperror.New(apperror.KindInvalid, "invalid_workspace_id", "invalid X-Workspace-ID header")
+	ErrInsufficientWorkspaceRole = apperror.New(apperror.KindForbidden, "insufficient_workspace_role", "your role in this workspace does not allow this")
+)
+
+// Workspace roles, from least to most privileged
+const (
+	WorkspaceRoleViewer = "viewer"
+	WorkspaceRoleEditor = "editor"
+	WorkspaceRoleOwner  = "owner"
+)
+
+// WorkspaceHeader selects the workspace a request acts in. Without it requests
+// act in the user's personal workspace.
+const WorkspaceHeader = "X-Workspace-ID"
+
+var workspaceRoleRank = map[string]int{
+	WorkspaceRoleViewer: 1,
+	WorkspaceRoleEditor: 2,
+	WorkspaceRoleOwner:  3,
+}
+
+// HasWorkspaceRole reports whether role grants at least what minRole does
+func HasWorkspaceRole(role, minRole string) bool {
+	rank, ok := workspaceRoleRank[role]
+	return ok && rank >= workspaceRoleRank[minRole]
+}
+
+// WorkspaceResolver returns the workspace a user acts in and their role there.
+// workspaceID 0 stands for the user's personal workspace. Workspaces the user is
+// not a member of are reported as an error.
+type WorkspaceResolver interface {
+	ResolveWorkspace(ctx context.Context, userID, workspaceID uint64) (uint64, string, error)
+}
+
+type WorkspaceMiddleware struct {
+	resolver WorkspaceResolver
+}
+
+func NewWorkspaceMiddleware(resolver WorkspaceResolver) *WorkspaceMiddleware {
+	return &WorkspaceMiddleware{resolver: resolver}
+}
+
+// Require sets workspaceID and workspaceRole for the active workspace and
+// rejects users whose role there is below minRole. Must run after RequireAuth.
+func (m *WorkspaceMiddleware) Require(minRole string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.Error(apperror.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		var workspaceID uint64
+		if header := c.GetHeader(WorkspaceHeader); header != "" {
+			id, err := strconv.ParseUint(header, 10, 64)
+			if err != nil || id == 0 {
+				c.Error(ErrInvalidWorkspaceID)
+				c.Abort()
+				return
+			}
+			workspaceID = id
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		workspaceID, role, err := m.resolver.ResolveWorkspace(ctx, userID.(uint64), workspaceID)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if !HasWorkspaceRole(role, minRole) {
+			c.Error(ErrInsufficientWorkspaceRole)
+			c.Abort()
+			return
+		}
+
+		c.Set("workspaceID", workspaceID)
+		c.Set("workspaceRole", role)
+		c.Next()
+	}
+}
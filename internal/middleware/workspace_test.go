@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-saas-api/pkg/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// staticWorkspaces gives user 7 personal workspace 1 and the roles in the map
+type staticWorkspaces map[uint64]string
+
+func (s staticWorkspaces) ResolveWorkspace(ctx context.Context, userID, workspaceID uint64) (uint64, string, error) {
+	if workspaceID == 0 {
+		return 1, WorkspaceRoleOwner, nil
+	}
+	role, ok := s[workspaceID]
+	if userID != 7 || !ok {
+		return 0, "", apperror.New(apperror.KindNotFound, "workspace_not_found", "workspace not found")
+	}
+	return workspaceID, role, nil
+}
+
+func TestRequireWorkspace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ws := NewWorkspaceMiddleware(staticWorkspaces{2: WorkspaceRoleViewer, 3: WorkspaceRoleEditor})
+	asUser := func(c *gin.Context) { c.Set("userID", uint64(7)) }
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "%d", c.GetUint64("workspaceID")) }
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/read", asUser, ws.Require(WorkspaceRoleViewer), ok)
+	r.GET("/write", asUser, ws.Require(WorkspaceRoleEditor), ok)
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantID     string
+	}{
+		{"personal workspace by default", "/write", "", http.StatusOK, "1"},
+		{"viewer can read", "/read", "2", http.StatusOK, "2"},
+		{"viewer cannot write", "/write", "2", http.StatusForbidden, ""},
+		{"editor can write", "/write", "3", http.StatusOK, "3"},
+		{"not a member", "/read", "4", http.StatusNotFound, ""},
+		{"malformed header", "/read", "abc", http.StatusBadRequest, ""},
+		{"zero id", "/read", "0", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(WorkspaceHeader, tt.header)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code == http.StatusOK && w.Body.String() != tt.wantID {
+				t.Errorf("workspaceID = %s, want %s", w.Body.String(), tt.wantID)
+			}
+		})
+	}
+}
+
+func TestHasWorkspaceRole(t *testing.T) {
+	tests := []struct {
+		role, min string
+		want      bool
+	}{
+		{WorkspaceRoleOwner, WorkspaceRoleViewer, true},
+		{WorkspaceRoleEditor, WorkspaceRoleEditor, true},
+		{WorkspaceRoleViewer, WorkspaceRoleEditor, false},
+		{WorkspaceRoleEditor, WorkspaceRoleOwner, false},
+		{"", WorkspaceRoleViewer, false},
+		{"admin", WorkspaceRoleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := HasWorkspaceRole(tt.role, tt.min); got != tt.want {
+			t.Errorf("HasWorkspaceRole(%q, %q) = %v, want %v", tt.role, tt.min, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"go-saas-api/internal/testutil"
+)
+
+func TestProviderLogin(t *testing.T) {
+	ctx := context.Background()
+	stub := testutil.NewOIDCProvider(t)
+	identity := testutil.OIDCIdentity{Subject: "user-1", Email: "a@example.com", EmailVerified: true, Name: "Alice"}
+
+	newProvider := func(clientID string) *Provider {
+		return NewProvider(Config{
+			Name:         "stub",
+			Issuer:       stub.Issuer,
+			ClientID:     clientID,
+			ClientSecret: stub.ClientSecret,
+			RedirectURL:  "http://app.test/auth/callback/stub",
+		}, nil)
+	}
+	p := newProvider(stub.ClientID)
+
+	// login runs the browser part of the flow and returns the ID token
+	login := func(t *testing.T, p *Provider, nonce, verifier, exchangeVerifier string) (string, error) {
+		t.Helper()
+		authURL, err := p.AuthCodeURL(ctx, "state-1", nonce, verifier)
+		if err != nil {
+			t.Fatalf("AuthCodeURL: %v", err)
+		}
+		u, _ := url.Parse(authURL)
+		if u.Query().Get("code_challenge") != CodeChallenge(verifier) || u.Query().Get("scope") != "openid email profile" {
+			t.Fatalf("authorization URL = %s", authURL)
+		}
+
+		code, state := stub.Login(t, authURL, identity)
+		if state != "state-1" {
+			t.Fatalf("state = %q, want state-1", state)
+		}
+		return p.Exchange(ctx, code, exchangeVerifier)
+	}
+
+	t.Run("valid login", func(t *testing.T) {
+		idToken, err := login(t, p, "nonce-1", "verifier-verifier-verifier-verifier-verifier", "verifier-verifier-verifier-verifier-verifier")
+		if err != nil {
+			t.Fatalf("Exchange: %v", err)
+		}
+		claims, err := p.VerifyIDToken(ctx, idToken, "nonce-1")
+		if err != nil {
+			t.Fatalf("VerifyIDToken: %v", err)
+		}
+		if *claims != (Claims{Subject: "user-1", Email: "a@example.com", EmailVerified: true, Name: "Alice"}) {
+			t.Errorf("claims = %+v", claims)
+		}
+
+		if _, err := p.VerifyIDToken(ctx, idToken, "other-nonce"); err == nil {
+			t.Error("token accepted with the wrong nonce")
+		}
+		if _, err := newProvider("other-client").VerifyIDToken(ctx, idToken, "nonce-1"); err == nil {
+			t.Error("token accepted by a client it was not issued to")
+		}
+	})
+
+	t.Run("wrong PKCE verifier", func(t *testing.T) {
+		if _, err := login(t, p, "nonce-2", "verifier-verifier-verifier-verifier-verifier", "something-else-entirely-something-else"); err == nil {
+			t.Error("code redeemed with the wrong verifier")
+		}
+	})
+
+	t.Run("issuer mismatch", func(t *testing.T) {
+		bad := NewProvider(Config{Name: "stub", Issuer: stub.Issuer + "/", ClientID: stub.ClientID}, nil)
+		if _, err := bad.AuthCodeURL(ctx, "s", "n", "v"); err == nil {
+			t.Error("discovery accepted a document for another issuer")
+		}
+	})
+}
+
+func TestParseJWK(t *testing.T) {
+	tests := []struct {
+		name    string
+		jwk     string
+		wantErr bool
+	}{
+		{"ed25519", `{"kty":"OKP","crv":"Ed25519","kid":"k","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`, false},
+		{"encryption key", `{"kty":"OKP","crv":"Ed25519","kid":"k","use":"enc","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`, true},
+		{"point not on curve", `{"kty":"EC","crv":"P-256","kid":"k","x":"AQ","y":"AQ"}`, true},
+		{"unsupported type", `{"kty":"oct","kid":"k","k":"c2VjcmV0"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kid, key, err := parseJWK([]byte(tt.jwk))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJWK err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (kid != "k" || key == nil) {
+				t.Errorf("parseJWK = %q, %v", kid, key)
+			}
+		})
+	}
+}
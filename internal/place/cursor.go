@@ -0,0 +1,83 @@
+package place
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"go-saas-api/pkg/customtime"
+)
+
+// placeCursor marks the last place of a page for keyset pagination
+type placeCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"` // sort column value of the last row, as text
+	ID    uint64 `json:"id"`
+}
+
+// placeSortColumn describes how a sort key is ordered and compared in SQL.
+// NULLs are coalesced so that keyset comparisons stay total.
+type placeSortColumn struct {
+	expr string
+	cast string
+}
+
+var placeSortColumns = map[string]placeSortColumn{
+	"go_at":      {expr: "COALESCE(go_at, '-infinity'::date)", cast: "date"},
+	"created_at": {expr: "created_at", cast: "timestamptz"},
+	"name":       {expr: "COALESCE(name, '')", cast: "text"},
+}
+
+func encodeCursor(c placeCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*placeCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c placeCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, ok := placeSortColumns[c.Sort]; !ok || (c.Order != "asc" && c.Order != "desc") {
+		return nil, ErrInvalidCursor
+	}
+
+	// The value is cast in SQL, so one that would not parse must stop here
+	// rather than fail the query
+	switch c.Sort {
+	case "go_at":
+		if c.Value != "-infinity" {
+			_, err = time.Parse(customtime.DateFormat, c.Value)
+		}
+	case "created_at":
+		_, err = time.Parse(time.RFC3339Nano, c.Value)
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// cursorFor builds the cursor pointing after p for the given sort key
+func cursorFor(p *Place, sort, order string) placeCursor {
+	c := placeCursor{Sort: sort, Order: order, ID: p.ID}
+	switch sort {
+	case "go_at":
+		if p.GoAt.Valid {
+			c.Value = p.GoAt.Time.Format(customtime.DateFormat)
+		} else {
+			c.Value = "-infinity"
+		}
+	case "created_at":
+		c.Value = p.CreatedAt.Format(time.RFC3339Nano)
+	case "name":
+		c.Value = p.Name.String
+	}
+	return c
+}
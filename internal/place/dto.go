@@ -9,11 +9,12 @@ import (
 type CreatePlaceReq struct {
 	Name        *string              `json:"name" validate:"required,max=255"`
 	Link        *string              `json:"link" validate:"omitempty,max=255"`
-	LinkType    *int                 `json:"link_type" validate:"omitempty,min=0"`
+	LinkType    *int                 `json:"link_type" validate:"omitempty,min=0"` // detected from link when omitted
 	Description *string              `json:"description" validate:"omitempty"`
 	GoAt        *customtime.Date     `json:"go_at" validate:"omitempty"`
 	GoAtTime    *customtime.DateTime `json:"go_at_time" validate:"omitempty"`
 	Status      *int                 `json:"status" validate:"omitempty,min=0"`
+	CategoryIDs []uint               `json:"category_ids" validate:"omitempty,dive,min=1"`
 }
 
 type UpdatePlaceReq struct {
@@ -24,20 +25,42 @@ type UpdatePlaceReq struct {
 	GoAt        *customtime.Date     `json:"go_at" validate:"omitempty"`
 	GoAtTime    *customtime.DateTime `json:"go_at_time" validate:"omitempty"`
 	Status      *int                 `json:"status" validate:"omitempty,min=0"`
+	CategoryIDs []uint               `json:"category_ids" validate:"omitempty,dive,min=1"` // nil = unchanged, [] = clear
+}
+
+type SetPlaceCategoriesReq struct {
+	CategoryIDs []uint `json:"category_ids" validate:"required,dive,min=1"`
+}
+
+type ListPlacesQuery struct {
+	Status     *int   `form:"status" validate:"omitempty,min=0"`
+	LinkType   *int   `form:"link_type" validate:"omitempty,min=0"`
+	CategoryID *uint  `form:"category" validate:"omitempty,min=1"`
+	GoAtFrom   string `form:"go_at_from" validate:"omitempty,datetime=2006-01-02"`
+	GoAtTo     string `form:"go_at_to" validate:"omitempty,datetime=2006-01-02"`
+	Q          string `form:"q" validate:"omitempty,max=255"` // case-insensitive name match
+	Sort       string `form:"sort" validate:"omitempty,oneof=go_at created_at name"`
+	Order      string `form:"order" validate:"omitempty,oneof=asc desc"`
+	Limit      int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor     string `form:"cursor" validate:"omitempty,max=512"`
 }
 
 type PlaceResponse struct {
-	ID          uint64               `json:"id"`
-	UserID      uint64               `json:"user_id"`
-	Name        *string              `json:"name"`
-	Link        *string              `json:"link"`
-	LinkType    *int                 `json:"link_type"`
-	Description *string              `json:"description"`
-	GoAt        *customtime.Date     `json:"go_at"`
-	GoAtTime    *customtime.DateTime `json:"go_at_time"`
-	Status      *int                 `json:"status"`
-	CreatedAt   string               `json:"created_at"`
-	UpdatedAt   string               `json:"updated_at"`
+	ID          uint64                  `json:"id"`
+	WorkspaceID uint64                  `json:"workspace_id"`
+	UserID      *uint64                 `json:"user_id"` // creator
+	Name        *string                 `json:"name"`
+	Link        *string                 `json:"link"`
+	LinkType    *int                    `json:"link_type"`
+	Description *string                 `json:"description"`
+	GoAt        *customtime.Date        `json:"go_at"`
+	GoAtTime    *customtime.DateTime    `json:"go_at_time"`
+	Status      *int                    `json:"status"`
+	StatusInfo  *PlaceStatusResponse    `json:"status_info"`
+	LinkInfo    *PlaceLinkResponse      `json:"link_info"`
+	Categories  []PlaceCategoryResponse `json:"categories"`
+	CreatedAt   string                  `json:"created_at"`
+	UpdatedAt   string                  `json:"updated_at"`
 }
 
 // PlaceCategory DTOs
@@ -51,7 +74,110 @@ type UpdatePlaceCategoryReq struct {
 }
 
 type PlaceCategoryResponse struct {
-	ID     uint   `json:"id"`
-	UserID uint64 `json:"user_id"`
-	Name   string `json:"name"`
+	ID          uint    `json:"id"`
+	WorkspaceID uint64  `json:"workspace_id"`
+	UserID      *uint64 `json:"user_id"` // creator
+	Name        string  `json:"name"`
+}
+
+// PlaceShare DTOs
+
+type CreatePlaceShareReq struct {
+	Name          string  `json:"name" validate:"omitempty,max=120"`
+	CategoryID    *uint   `json:"category_id" validate:"omitempty,min=1"`
+	Status        *int    `json:"status" validate:"omitempty,min=0"`
+	LinkType      *int    `json:"link_type" validate:"omitempty,min=0"`
+	GoAtFrom      string  `json:"go_at_from" validate:"omitempty,datetime=2006-01-02"`
+	GoAtTo        string  `json:"go_at_to" validate:"omitempty,datetime=2006-01-02"`
+	Q             string  `json:"q" validate:"omitempty,max=255"`
+	Sort          string  `json:"sort" validate:"omitempty,oneof=go_at created_at name"`
+	Order         string  `json:"order" validate:"omitempty,oneof=asc desc"`
+	Password      *string `json:"password" validate:"omitempty,min=4,max=72"`         // omit for a link anyone can open
+	ExpiresInDays *int    `json:"expires_in_days" validate:"omitempty,min=1,max=365"` // omit for a link that never expires
+}
+
+type SharedPlacesQuery struct {
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor" validate:"omitempty,max=512"`
+}
+
+type PlaceShareResponse struct {
+	ID          uint64  `json:"id"`
+	Name        string  `json:"name"`
+	TokenPrefix string  `json:"token_prefix"`
+	CategoryID  *uint64 `json:"category_id"`
+	Status      *int    `json:"status"`
+	LinkType    *int    `json:"link_type"`
+	GoAtFrom    *string `json:"go_at_from"`
+	GoAtTo      *string `json:"go_at_to"`
+	Q           string  `json:"q"`
+	Sort        string  `json:"sort"`
+	Order       string  `json:"order"`
+	HasPassword bool    `json:"has_password"`
+	ExpiresAt   *string `json:"expires_at"`
+	UserID      *uint64 `json:"user_id"` // creator
+	CreatedAt   string  `json:"created_at"`
+}
+
+// CreatedPlaceShareResponse is returned once, when the link is created
+type CreatedPlaceShareResponse struct {
+	PlaceShareResponse
+	Token string `json:"token"` // opens GET /shared/:token
+}
+
+// SharedPlaceResponse is a place as shown through a public link, without
+// workspace or user IDs
+type SharedPlaceResponse struct {
+	ID          uint64                   `json:"id"`
+	Name        *string                  `json:"name"`
+	Link        *string                  `json:"link"`
+	LinkType    *int                     `json:"link_type"`
+	Description *string                  `json:"description"`
+	GoAt        *customtime.Date         `json:"go_at"`
+	GoAtTime    *customtime.DateTime     `json:"go_at_time"`
+	Status      *int                     `json:"status"`
+	StatusInfo  *PlaceStatusResponse     `json:"status_info"`
+	LinkInfo    *PlaceLinkResponse       `json:"link_info"`
+	Categories  []SharedCategoryResponse `json:"categories"`
+	CreatedAt   string                   `json:"created_at"`
+	UpdatedAt   string                   `json:"updated_at"`
+}
+
+type SharedCategoryResponse struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// Lookup DTOs
+
+type CreatePlaceStatusReq struct {
+	Name       string `json:"name" validate:"required,min=1,max=50"`
+	Background string `json:"background" validate:"required,hexcolor,max=8"`
+}
+
+type UpdatePlaceStatusReq struct {
+	Name       *string `json:"name" validate:"omitempty,min=1,max=50"`
+	Background *string `json:"background" validate:"omitempty,hexcolor,max=8"`
+}
+
+type CreatePlaceLinkReq struct {
+	Domain string `json:"domain" validate:"required,min=1,max=50"` // e.g. "tiktok.com"; "other" is the fallback
+	Icon   string `json:"icon" validate:"omitempty,max=255"`
+}
+
+type UpdatePlaceLinkReq struct {
+	Domain *string `json:"domain" validate:"omitempty,min=1,max=50"`
+	Icon   *string `json:"icon" validate:"omitempty,max=255"`
+}
+
+type PlaceStatusResponse struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Background string `json:"background"`
+}
+
+type PlaceLinkResponse struct {
+	ID     int    `json:"id"`
+	Domain string `json:"domain"`
+	Icon   string `json:"icon"`
 }
@@ -0,0 +1,20 @@
+package place
+
+import "go-saas-api/pkg/apperror"
+
+var (
+	ErrPlaceNotFound     = apperror.New(apperror.KindNotFound, "place_not_found", "place not found")
+	ErrCategoryNotFound  = apperror.New(apperror.KindNotFound, "category_not_found", "category not found")
+	ErrStatusNotFound    = apperror.New(apperror.KindNotFound, "status_not_found", "status not found")
+	ErrLinkTypeNotFound  = apperror.New(apperror.KindNotFound, "link_type_not_found", "link type not found")
+	ErrNameRequired      = apperror.New(apperror.KindInvalid, "name_required", "name is required")
+	ErrNoFieldsToUpdate  = apperror.New(apperror.KindInvalid, "no_fields_to_update", "no fields to update")
+	ErrInvalidStatus     = apperror.New(apperror.KindInvalid, "invalid_status", "invalid status")
+	ErrInvalidLinkType   = apperror.New(apperror.KindInvalid, "invalid_link_type", "invalid link_type")
+	ErrInvalidCursor     = apperror.New(apperror.KindInvalid, "invalid_cursor", "invalid cursor")
+	ErrInvalidDate       = apperror.New(apperror.KindInvalid, "invalid_date", "invalid date")
+	ErrInvalidCategoryID = apperror.New(apperror.KindInvalid, "invalid_category_id", "invalid category id")
+	ErrShareNotFound     = apperror.New(apperror.KindNotFound, "share_not_found", "share link not found or expired")
+	ErrPasswordRequired  = apperror.New(apperror.KindUnauthorized, "share_password_required", "this share link is password protected")
+	ErrInvalidPassword   = apperror.New(apperror.KindUnauthorized, "invalid_share_password", "invalid share link password")
+)
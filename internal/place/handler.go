@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"go-saas-api/pkg/apperror"
 	"go-saas-api/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -30,26 +31,31 @@ func NewHandler(service *Service, v *validator.Validate) *Handler {
 func (h *Handler) CreatePlace(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	var req CreatePlaceReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid json")
+		c.Error(apperror.ErrInvalidJSON)
 		return
 	}
 	if err := h.v.Struct(req); err != nil {
-		response.Error(c, http.StatusBadRequest, "validation failed")
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	id, err := h.service.CreatePlace(ctx, userID.(uint64), req)
+	id, err := h.service.CreatePlace(ctx, workspaceID.(uint64), userID.(uint64), req)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
@@ -58,18 +64,28 @@ func (h *Handler) CreatePlace(c *gin.Context) {
 
 // GET /places
 func (h *Handler) ListPlaces(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var query ListPlacesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.ErrInvalidQuery)
+		return
+	}
+	if err := h.v.Struct(query); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	items, err := h.service.ListPlaces(ctx, userID.(uint64), 100)
+	items, nextCursor, err := h.service.ListPlaces(ctx, workspaceID.(uint64), query)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
@@ -79,34 +95,35 @@ func (h *Handler) ListPlaces(c *gin.Context) {
 		responses[i] = ToPlaceResponse(&item)
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"items": responses})
+	var next *string
+	if nextCursor != "" {
+		next = &nextCursor
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": responses, "next_cursor": next})
 }
 
 // GET /places/:id
 func (h *Handler) GetPlace(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid id")
+		c.Error(apperror.ErrInvalidID)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	place, err := h.service.GetPlaceByID(ctx, id, userID.(uint64))
+	place, err := h.service.GetPlaceByID(ctx, id, workspaceID.(uint64))
 	if err != nil {
-		if err.Error() == "place not found" {
-			response.Error(c, http.StatusNotFound, "place not found")
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
@@ -116,48 +133,40 @@ func (h *Handler) GetPlace(c *gin.Context) {
 
 // PATCH /places/:id
 func (h *Handler) UpdatePlace(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid id")
+		c.Error(apperror.ErrInvalidID)
 		return
 	}
 
 	var req UpdatePlaceReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid json")
+		c.Error(apperror.ErrInvalidJSON)
 		return
 	}
 	if err := h.v.Struct(req); err != nil {
-		response.Error(c, http.StatusBadRequest, "validation failed")
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	updated, err := h.service.UpdatePlace(ctx, id, userID.(uint64), req)
+	updated, err := h.service.UpdatePlace(ctx, id, workspaceID.(uint64), req)
 	if err != nil {
-		if err.Error() == "place not found" {
-			response.Error(c, http.StatusNotFound, "place not found")
-			return
-		}
-		if err.Error() == "no fields to update" {
-			response.Error(c, http.StatusBadRequest, "no fields to update")
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
 	if !updated {
-		response.Error(c, http.StatusNotFound, "place not found")
+		c.Error(ErrPlaceNotFound)
 		return
 	}
 
@@ -166,66 +175,168 @@ func (h *Handler) UpdatePlace(c *gin.Context) {
 
 // DELETE /places/:id
 func (h *Handler) DeletePlace(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid id")
+		c.Error(apperror.ErrInvalidID)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	deleted, err := h.service.DeletePlace(ctx, id, userID.(uint64))
+	deleted, err := h.service.DeletePlace(ctx, id, workspaceID.(uint64))
 	if err != nil {
-		if err.Error() == "place not found" {
-			response.Error(c, http.StatusNotFound, "place not found")
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
 	if !deleted {
-		response.Error(c, http.StatusNotFound, "place not found")
+		c.Error(ErrPlaceNotFound)
 		return
 	}
 
 	response.Success(c, http.StatusOK, gin.H{"message": "place deleted successfully"})
 }
 
+// Place <-> Category Handlers
+
+// PUT /places/:id/categories
+func (h *Handler) SetPlaceCategories(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req SetPlaceCategoriesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	err = h.service.SetPlaceCategories(ctx, id, workspaceID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "place categories updated successfully"})
+}
+
+// POST /places/:id/categories/:categoryId
+func (h *Handler) AttachPlaceCategory(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	categoryID, err := strconv.ParseUint(c.Param("categoryId"), 10, 32)
+	if err != nil {
+		c.Error(ErrInvalidCategoryID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	err = h.service.AttachPlaceCategory(ctx, id, workspaceID.(uint64), uint(categoryID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "category attached successfully"})
+}
+
+// DELETE /places/:id/categories/:categoryId
+func (h *Handler) DetachPlaceCategory(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	categoryID, err := strconv.ParseUint(c.Param("categoryId"), 10, 32)
+	if err != nil {
+		c.Error(ErrInvalidCategoryID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	err = h.service.DetachPlaceCategory(ctx, id, workspaceID.(uint64), uint(categoryID))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "category detached successfully"})
+}
+
 // PlaceCategory Handlers
 
 // POST /place-categories
 func (h *Handler) CreatePlaceCategory(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	var req CreatePlaceCategoryReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid json")
+		c.Error(apperror.ErrInvalidJSON)
 		return
 	}
 	if err := h.v.Struct(req); err != nil {
-		response.Error(c, http.StatusBadRequest, "validation failed")
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	id, err := h.service.CreatePlaceCategory(ctx, userID.(uint64), req)
+	id, err := h.service.CreatePlaceCategory(ctx, workspaceID.(uint64), userID.(uint64), req)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
@@ -234,18 +345,18 @@ func (h *Handler) CreatePlaceCategory(c *gin.Context) {
 
 // GET /place-categories
 func (h *Handler) ListPlaceCategories(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	items, err := h.service.ListPlaceCategories(ctx, userID.(uint64), 100)
+	items, err := h.service.ListPlaceCategories(ctx, workspaceID.(uint64), 100)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
@@ -260,29 +371,25 @@ func (h *Handler) ListPlaceCategories(c *gin.Context) {
 
 // GET /place-categories/:id
 func (h *Handler) GetPlaceCategory(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid id")
+		c.Error(apperror.ErrInvalidID)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	category, err := h.service.GetPlaceCategoryByID(ctx, uint(id), userID.(uint64))
+	category, err := h.service.GetPlaceCategoryByID(ctx, uint(id), workspaceID.(uint64))
 	if err != nil {
-		if err.Error() == "category not found" {
-			response.Error(c, http.StatusNotFound, "category not found")
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
@@ -292,48 +399,40 @@ func (h *Handler) GetPlaceCategory(c *gin.Context) {
 
 // PATCH /place-categories/:id
 func (h *Handler) UpdatePlaceCategory(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid id")
+		c.Error(apperror.ErrInvalidID)
 		return
 	}
 
 	var req UpdatePlaceCategoryReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid json")
+		c.Error(apperror.ErrInvalidJSON)
 		return
 	}
 	if err := h.v.Struct(req); err != nil {
-		response.Error(c, http.StatusBadRequest, "validation failed")
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	updated, err := h.service.UpdatePlaceCategory(ctx, uint(id), userID.(uint64), req)
+	updated, err := h.service.UpdatePlaceCategory(ctx, uint(id), workspaceID.(uint64), req)
 	if err != nil {
-		if err.Error() == "category not found" {
-			response.Error(c, http.StatusNotFound, "category not found")
-			return
-		}
-		if err.Error() == "no fields to update" {
-			response.Error(c, http.StatusBadRequest, "no fields to update")
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
 	if !updated {
-		response.Error(c, http.StatusNotFound, "category not found")
+		c.Error(ErrCategoryNotFound)
 		return
 	}
 
@@ -342,36 +441,384 @@ func (h *Handler) UpdatePlaceCategory(c *gin.Context) {
 
 // DELETE /place-categories/:id
 func (h *Handler) DeletePlaceCategory(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	workspaceID, exists := c.Get("workspaceID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid id")
+		c.Error(apperror.ErrInvalidID)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	deleted, err := h.service.DeletePlaceCategory(ctx, uint(id), userID.(uint64))
+	deleted, err := h.service.DeletePlaceCategory(ctx, uint(id), workspaceID.(uint64))
 	if err != nil {
-		if err.Error() == "category not found" {
-			response.Error(c, http.StatusNotFound, "category not found")
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
 	if !deleted {
-		response.Error(c, http.StatusNotFound, "category not found")
+		c.Error(ErrCategoryNotFound)
 		return
 	}
 
 	response.Success(c, http.StatusOK, gin.H{"message": "category deleted successfully"})
 }
+
+// PlaceShare Handlers
+
+// POST /place-shares
+func (h *Handler) CreatePlaceShare(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req CreatePlaceShareReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.CreatePlaceShare(ctx, workspaceID.(uint64), userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, result)
+}
+
+// GET /place-shares
+func (h *Handler) ListPlaceShares(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListPlaceShares(ctx, workspaceID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
+
+// DELETE /place-shares/:id
+func (h *Handler) RevokePlaceShare(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.RevokePlaceShare(ctx, id, workspaceID.(uint64)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "share link revoked successfully"})
+}
+
+// GET /shared/:token (public)
+func (h *Handler) ListSharedPlaces(c *gin.Context) {
+	var query SharedPlacesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.ErrInvalidQuery)
+		return
+	}
+	if err := h.v.Struct(query); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	share, items, nextCursor, err := h.service.ListSharedPlaces(ctx, c.Param("token"), c.GetHeader(SharePasswordHeader), query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	responses := make([]SharedPlaceResponse, len(items))
+	for i := range items {
+		responses[i] = toSharedPlaceResponse(share, &items[i])
+	}
+
+	var next *string
+	if nextCursor != "" {
+		next = &nextCursor
+	}
+	var expiresAt *string
+	if share.ExpiresAt.Valid {
+		t := share.ExpiresAt.Time.Format(time.RFC3339)
+		expiresAt = &t
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"name":        share.Name,
+		"expires_at":  expiresAt,
+		"items":       responses,
+		"next_cursor": next,
+	})
+}
+
+// Lookup Handlers
+
+// GET /place-statuses
+func (h *Handler) ListPlaceStatuses(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListPlaceStatuses(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	responses := make([]PlaceStatusResponse, len(items))
+	for i := range items {
+		responses[i] = ToPlaceStatusResponse(&items[i])
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": responses})
+}
+
+// GET /place-statuses/:id
+func (h *Handler) GetPlaceStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 16)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	status, err := h.service.GetPlaceStatusByID(ctx, int(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, ToPlaceStatusResponse(status))
+}
+
+// GET /place-links
+func (h *Handler) ListPlaceLinks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListPlaceLinks(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	responses := make([]PlaceLinkResponse, len(items))
+	for i := range items {
+		responses[i] = ToPlaceLinkResponse(&items[i])
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": responses})
+}
+
+// GET /place-links/:id
+func (h *Handler) GetPlaceLink(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 16)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	link, err := h.service.GetPlaceLinkByID(ctx, int(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, ToPlaceLinkResponse(link))
+}
+
+// Admin Lookup Handlers
+
+// POST /admin/place-statuses (admin only)
+func (h *Handler) CreatePlaceStatus(c *gin.Context) {
+	var req CreatePlaceStatusReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := h.service.CreatePlaceStatus(ctx, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{"id": id})
+}
+
+// PATCH /admin/place-statuses/:id (admin only)
+func (h *Handler) UpdatePlaceStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 16)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req UpdatePlaceStatusReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.UpdatePlaceStatus(ctx, int(id), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "status updated successfully"})
+}
+
+// DELETE /admin/place-statuses/:id (admin only)
+func (h *Handler) DeletePlaceStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 16)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DeletePlaceStatus(ctx, int(id)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "status deleted successfully"})
+}
+
+// POST /admin/place-links (admin only)
+func (h *Handler) CreatePlaceLink(c *gin.Context) {
+	var req CreatePlaceLinkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	id, err := h.service.CreatePlaceLink(ctx, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{"id": id})
+}
+
+// PATCH /admin/place-links/:id (admin only)
+func (h *Handler) UpdatePlaceLink(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 16)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req UpdatePlaceLinkReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.UpdatePlaceLink(ctx, int(id), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "link type updated successfully"})
+}
+
+// DELETE /admin/place-links/:id (admin only)
+func (h *Handler) DeletePlaceLink(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 16)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DeletePlaceLink(ctx, int(id)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "link type deleted successfully"})
+}
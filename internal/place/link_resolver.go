@@ -0,0 +1,100 @@
+package place
+
+import (
+	"net/url"
+	"strings"
+)
+
+// otherLinkDomain is the place_link.domain used when no other domain matches
+const otherLinkDomain = "other"
+
+// linkHostAlias maps short-link / alternative hosts to a canonical place_link.domain
+type linkHostAlias struct {
+	host       string
+	pathPrefix string
+	domain     string
+}
+
+var linkHostAliases = []linkHostAlias{
+	{host: "maps.app.goo.gl", domain: "maps.google.com"},
+	{host: "goo.gl", pathPrefix: "/maps", domain: "maps.google.com"},
+	{host: "google.com", pathPrefix: "/maps", domain: "maps.google.com"},
+	{host: "instagr.am", domain: "instagram.com"},
+}
+
+// resolveLinkType returns the ID of the place_link whose domain matches the host of rawURL.
+// Subdomains match their parent domain (vm.tiktok.com -> tiktok.com) and the most specific
+// domain wins. Falls back to the "other" link type, or nil when that does not exist either.
+func resolveLinkType(rawURL string, links []PlaceLink) *int {
+	host, path, ok := normalizeLinkURL(rawURL)
+	if !ok {
+		return nil
+	}
+
+	for _, alias := range linkHostAliases {
+		if hostMatches(host, alias.host) && strings.HasPrefix(path, alias.pathPrefix) {
+			host = alias.domain
+			break
+		}
+	}
+
+	var best *PlaceLink
+	var other *PlaceLink
+	for i := range links {
+		domain := normalizeHost(links[i].Domain)
+		if domain == "" {
+			continue
+		}
+		if domain == otherLinkDomain {
+			other = &links[i]
+			continue
+		}
+		if hostMatches(host, domain) && (best == nil || len(domain) > len(normalizeHost(best.Domain))) {
+			best = &links[i]
+		}
+	}
+
+	if best == nil {
+		best = other
+	}
+	if best == nil {
+		return nil
+	}
+	id := best.ID
+	return &id
+}
+
+// normalizeLinkURL parses a user supplied link (scheme optional) into a normalized host and path
+func normalizeLinkURL(rawURL string) (string, string, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", "", false
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", false
+	}
+
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return "", "", false
+	}
+	return host, u.Path, true
+}
+
+// normalizeHost lowercases a host and strips the trailing dot and www./m. prefixes
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	host = strings.TrimPrefix(host, "www.")
+	host = strings.TrimPrefix(host, "m.")
+	return host
+}
+
+// hostMatches reports whether host is domain or one of its subdomains
+func hostMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,747 @@
+package place
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-saas-api/pkg/customtime"
+)
+
+// errForeignKeyViolation stands in for the Postgres foreign key error
+var errForeignKeyViolation = errors.New("insert or update violates foreign key constraint")
+
+// placeCategoryLink is a place_category_list row
+type placeCategoryLink struct {
+	workspaceID uint64
+	placeID     uint64
+	categoryID  uint
+}
+
+// MemoryRepository is an in-process PlaceRepository for tests and local experiments.
+// It mirrors the Postgres repository: workspace_id scoping, sql.ErrNoRows for missing rows,
+// cascading deletes and the default lookup rows seeded by migration 0001.
+// Name ordering uses byte order rather than the database collation.
+type MemoryRepository struct {
+	mu             sync.Mutex
+	places         map[uint64]Place
+	categories     map[uint]PlaceCategory
+	links          []placeCategoryLink
+	shares         map[uint64]PlaceShare
+	statuses       []PlaceStatus
+	linkTypes      []PlaceLink
+	nextPlaceID    uint64
+	nextCategoryID uint
+	nextShareID    uint64
+}
+
+var _ PlaceRepository = (*MemoryRepository)(nil)
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		places:     map[uint64]Place{},
+		categories: map[uint]PlaceCategory{},
+		shares:     map[uint64]PlaceShare{},
+		statuses: []PlaceStatus{
+			{ID: 1, Name: "Wishlist", Background: "#6B7280"},
+			{ID: 2, Name: "Planned", Background: "#3B82F6"},
+			{ID: 3, Name: "Visited", Background: "#10B981"},
+		},
+		linkTypes: []PlaceLink{
+			{ID: 1, Domain: "maps.google.com", Icon: "google-maps"},
+			{ID: 2, Domain: "instagram.com", Icon: "instagram"},
+			{ID: 3, Domain: "tiktok.com", Icon: "tiktok"},
+			{ID: 4, Domain: "other", Icon: "link"},
+		},
+	}
+}
+
+// Place Repository Methods
+
+func (r *MemoryRepository) CreatePlace(ctx context.Context, workspaceID, userID uint64, req CreatePlaceReq) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkLookups(req.Status, req.LinkType); err != nil {
+		return 0, err
+	}
+	if len(req.CategoryIDs) > 0 && !r.ownsCategories(workspaceID, req.CategoryIDs) {
+		return 0, sql.ErrNoRows
+	}
+
+	r.nextPlaceID++
+	now := time.Now()
+	p := Place{ID: r.nextPlaceID, WorkspaceID: workspaceID, UserID: creatorID(userID), CreatedAt: now, UpdatedAt: now}
+	applyPlaceFields(&p, req.Name, req.Link, req.LinkType, req.Description, req.GoAt, req.GoAtTime, req.Status)
+	r.places[p.ID] = p
+
+	for _, categoryID := range req.CategoryIDs {
+		r.links = append(r.links, placeCategoryLink{workspaceID: workspaceID, placeID: p.ID, categoryID: categoryID})
+	}
+	return int64(p.ID), nil
+}
+
+func (r *MemoryRepository) ListPlaces(ctx context.Context, workspaceID uint64, f PlaceFilter) ([]Place, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var after *placeSortKey
+	if f.After != nil {
+		key, err := cursorSortKey(f.After)
+		if err != nil {
+			return nil, err
+		}
+		after = &key
+	}
+
+	name := strings.ToLower(f.Name)
+	var items []Place
+	for _, p := range r.places {
+		if p.WorkspaceID != workspaceID {
+			continue
+		}
+		if f.Status != nil && (!p.Status.Valid || int(p.Status.Int32) != *f.Status) {
+			continue
+		}
+		if f.LinkType != nil && (!p.LinkType.Valid || int(p.LinkType.Int32) != *f.LinkType) {
+			continue
+		}
+		if f.CategoryID != nil && !r.hasCategory(p.ID, *f.CategoryID) {
+			continue
+		}
+		if f.GoAtFrom != nil && (!p.GoAt.Valid || p.GoAt.Time.Before(*f.GoAtFrom)) {
+			continue
+		}
+		if f.GoAtTo != nil && (!p.GoAt.Valid || p.GoAt.Time.After(*f.GoAtTo)) {
+			continue
+		}
+		if name != "" && (!p.Name.Valid || !strings.Contains(strings.ToLower(p.Name.String), name)) {
+			continue
+		}
+
+		key := placeSortKeyOf(&p, f.Sort)
+		if after != nil {
+			cmp := comparePlaceSortKeys(key, *after)
+			if (f.Order == "asc" && cmp <= 0) || (f.Order != "asc" && cmp >= 0) {
+				continue
+			}
+		}
+		items = append(items, p)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		cmp := comparePlaceSortKeys(placeSortKeyOf(&items[i], f.Sort), placeSortKeyOf(&items[j], f.Sort))
+		if f.Order == "asc" {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+
+	if f.Limit > 0 && len(items) > f.Limit {
+		items = items[:f.Limit]
+	}
+	return items, nil
+}
+
+func (r *MemoryRepository) GetPlaceByID(ctx context.Context, id, workspaceID uint64) (*Place, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.places[id]
+	if !ok || p.WorkspaceID != workspaceID {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (r *MemoryRepository) UpdatePlace(ctx context.Context, id, workspaceID uint64, req UpdatePlaceReq) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hasFields := req.Name != nil || req.Link != nil || req.LinkType != nil || req.Description != nil ||
+		req.GoAt != nil || req.GoAtTime != nil || req.Status != nil
+	if !hasFields && req.CategoryIDs == nil {
+		return false, nil
+	}
+
+	if err := r.checkLookups(req.Status, req.LinkType); err != nil {
+		return false, err
+	}
+
+	p, owned := r.places[id]
+	owned = owned && p.WorkspaceID == workspaceID
+
+	// Validate categories before touching anything, like the rolled back transaction
+	if len(req.CategoryIDs) > 0 && (!owned || !r.ownsCategories(workspaceID, req.CategoryIDs)) {
+		return false, sql.ErrNoRows
+	}
+
+	updated := false
+	if hasFields && owned {
+		applyPlaceFields(&p, req.Name, req.Link, req.LinkType, req.Description, req.GoAt, req.GoAtTime, req.Status)
+		p.UpdatedAt = time.Now()
+		r.places[id] = p
+		updated = true
+	}
+
+	if req.CategoryIDs != nil {
+		if owned {
+			r.replaceLinks(id, workspaceID, req.CategoryIDs)
+		}
+		updated = true
+	}
+	return updated, nil
+}
+
+func (r *MemoryRepository) DeletePlace(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.places[id]
+	if !ok || p.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	delete(r.places, id)
+	r.removeLinks(func(l placeCategoryLink) bool { return l.placeID == id })
+	return true, nil
+}
+
+// Place <-> Category Repository Methods
+
+func (r *MemoryRepository) SetPlaceCategories(ctx context.Context, placeID, workspaceID uint64, categoryIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(categoryIDs) > 0 {
+		p, ok := r.places[placeID]
+		if !ok || p.WorkspaceID != workspaceID || !r.ownsCategories(workspaceID, categoryIDs) {
+			return sql.ErrNoRows
+		}
+	}
+	r.replaceLinks(placeID, workspaceID, categoryIDs)
+	return nil
+}
+
+func (r *MemoryRepository) AttachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.places[placeID]; !ok {
+		return errForeignKeyViolation
+	}
+	if _, ok := r.categories[categoryID]; !ok {
+		return errForeignKeyViolation
+	}
+	if r.hasCategory(placeID, categoryID) {
+		return nil // ON CONFLICT DO NOTHING
+	}
+	r.links = append(r.links, placeCategoryLink{workspaceID: workspaceID, placeID: placeID, categoryID: categoryID})
+	return nil
+}
+
+func (r *MemoryRepository) DetachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := r.removeLinks(func(l placeCategoryLink) bool {
+		return l.placeID == placeID && l.workspaceID == workspaceID && l.categoryID == categoryID
+	})
+	return removed > 0, nil
+}
+
+func (r *MemoryRepository) ListCategoriesByPlaceIDs(ctx context.Context, workspaceID uint64, placeIDs []uint64) (map[uint64][]PlaceCategory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[uint64]struct{}, len(placeIDs))
+	for _, id := range placeIDs {
+		wanted[id] = struct{}{}
+	}
+
+	result := make(map[uint64][]PlaceCategory, len(placeIDs))
+	for _, l := range r.links {
+		if _, ok := wanted[l.placeID]; !ok || l.workspaceID != workspaceID {
+			continue
+		}
+		if pc, ok := r.categories[l.categoryID]; ok {
+			result[l.placeID] = append(result[l.placeID], pc)
+		}
+	}
+
+	for _, categories := range result {
+		sort.Slice(categories, func(i, j int) bool {
+			if categories[i].Name != categories[j].Name {
+				return categories[i].Name < categories[j].Name
+			}
+			return categories[i].ID < categories[j].ID
+		})
+	}
+	return result, nil
+}
+
+// PlaceCategory Repository Methods
+
+func (r *MemoryRepository) CreatePlaceCategory(ctx context.Context, workspaceID, userID uint64, name string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextCategoryID++
+	r.categories[r.nextCategoryID] = PlaceCategory{ID: r.nextCategoryID, WorkspaceID: workspaceID, UserID: creatorID(userID), Name: name}
+	return int64(r.nextCategoryID), nil
+}
+
+func (r *MemoryRepository) ListPlaceCategories(ctx context.Context, workspaceID uint64, limit int) ([]PlaceCategory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []PlaceCategory
+	for _, pc := range r.categories {
+		if pc.WorkspaceID == workspaceID {
+			items = append(items, pc)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (r *MemoryRepository) GetPlaceCategoryByID(ctx context.Context, id uint, workspaceID uint64) (*PlaceCategory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pc, ok := r.categories[id]
+	if !ok || pc.WorkspaceID != workspaceID {
+		return nil, sql.ErrNoRows
+	}
+	return &pc, nil
+}
+
+func (r *MemoryRepository) UpdatePlaceCategory(ctx context.Context, id uint, workspaceID uint64, name *string) (bool, error) {
+	if name == nil {
+		return false, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pc, ok := r.categories[id]
+	if !ok || pc.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	pc.Name = *name
+	r.categories[id] = pc
+	return true, nil
+}
+
+func (r *MemoryRepository) DeletePlaceCategory(ctx context.Context, id uint, workspaceID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pc, ok := r.categories[id]
+	if !ok || pc.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	delete(r.categories, id)
+	r.removeLinks(func(l placeCategoryLink) bool { return l.categoryID == id })
+	r.removeShares(func(ps PlaceShare) bool { return ps.CategoryID.Valid && uint(ps.CategoryID.Int64) == id })
+	return true, nil
+}
+
+// PlaceShare Repository Methods
+
+func (r *MemoryRepository) CreatePlaceShare(ctx context.Context, share *PlaceShare) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if share.CategoryID.Valid {
+		pc, ok := r.categories[uint(share.CategoryID.Int64)]
+		if !ok || pc.WorkspaceID != share.WorkspaceID {
+			return errForeignKeyViolation
+		}
+	}
+	var status, linkType *int
+	if share.Status.Valid {
+		v := int(share.Status.Int32)
+		status = &v
+	}
+	if share.LinkType.Valid {
+		v := int(share.LinkType.Int32)
+		linkType = &v
+	}
+	if err := r.checkLookups(status, linkType); err != nil {
+		return err
+	}
+
+	r.nextShareID++
+	share.ID = r.nextShareID
+	share.CreatedAt = time.Now()
+	r.shares[share.ID] = *share
+	return nil
+}
+
+func (r *MemoryRepository) ListPlaceShares(ctx context.Context, workspaceID uint64) ([]PlaceShare, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []PlaceShare
+	for _, ps := range r.shares {
+		if ps.WorkspaceID == workspaceID {
+			items = append(items, ps)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	return items, nil
+}
+
+func (r *MemoryRepository) GetPlaceShareByHash(ctx context.Context, tokenHash string) (*PlaceShare, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ps := range r.shares {
+		if ps.TokenHash == tokenHash {
+			return &ps, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) DeletePlaceShare(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ps, ok := r.shares[id]
+	if !ok || ps.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	delete(r.shares, id)
+	return true, nil
+}
+
+// Lookup Repository Methods
+
+func (r *MemoryRepository) ListPlaceStatuses(ctx context.Context) ([]PlaceStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PlaceStatus(nil), r.statuses...), nil
+}
+
+func (r *MemoryRepository) GetPlaceStatusByID(ctx context.Context, id int) (*PlaceStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ps := range r.statuses {
+		if ps.ID == id {
+			return &ps, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) ListPlaceLinks(ctx context.Context) ([]PlaceLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PlaceLink(nil), r.linkTypes...), nil
+}
+
+func (r *MemoryRepository) GetPlaceLinkByID(ctx context.Context, id int) (*PlaceLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, pl := range r.linkTypes {
+		if pl.ID == id {
+			return &pl, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// Admin Lookup Repository Methods
+
+func (r *MemoryRepository) CreatePlaceStatus(ctx context.Context, name, background string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := 1
+	for _, ps := range r.statuses {
+		id = max(id, ps.ID+1)
+	}
+	r.statuses = append(r.statuses, PlaceStatus{ID: id, Name: name, Background: background})
+	return id, nil
+}
+
+func (r *MemoryRepository) UpdatePlaceStatus(ctx context.Context, id int, name, background *string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name == nil && background == nil {
+		return false, nil
+	}
+	for i := range r.statuses {
+		if r.statuses[i].ID != id {
+			continue
+		}
+		if name != nil {
+			r.statuses[i].Name = *name
+		}
+		if background != nil {
+			r.statuses[i].Background = *background
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) DeletePlaceStatus(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, ps := range r.statuses {
+		if ps.ID != id {
+			continue
+		}
+		r.statuses = append(r.statuses[:i], r.statuses[i+1:]...)
+		// ON DELETE SET NULL
+		for pid, p := range r.places {
+			if p.Status.Valid && int(p.Status.Int32) == id {
+				p.Status = sql.NullInt32{}
+				r.places[pid] = p
+			}
+		}
+		// Share links filtering on the status are deleted (ON DELETE CASCADE)
+		r.removeShares(func(ps PlaceShare) bool { return ps.Status.Valid && int(ps.Status.Int32) == id })
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) CreatePlaceLink(ctx context.Context, domain, icon string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := 1
+	for _, pl := range r.linkTypes {
+		id = max(id, pl.ID+1)
+	}
+	r.linkTypes = append(r.linkTypes, PlaceLink{ID: id, Domain: domain, Icon: icon})
+	return id, nil
+}
+
+func (r *MemoryRepository) UpdatePlaceLink(ctx context.Context, id int, domain, icon *string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if domain == nil && icon == nil {
+		return false, nil
+	}
+	for i := range r.linkTypes {
+		if r.linkTypes[i].ID != id {
+			continue
+		}
+		if domain != nil {
+			r.linkTypes[i].Domain = *domain
+		}
+		if icon != nil {
+			r.linkTypes[i].Icon = *icon
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) DeletePlaceLink(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, pl := range r.linkTypes {
+		if pl.ID != id {
+			continue
+		}
+		r.linkTypes = append(r.linkTypes[:i], r.linkTypes[i+1:]...)
+		// ON DELETE SET NULL
+		for pid, p := range r.places {
+			if p.LinkType.Valid && int(p.LinkType.Int32) == id {
+				p.LinkType = sql.NullInt32{}
+				r.places[pid] = p
+			}
+		}
+		// Share links filtering on the link type are deleted (ON DELETE CASCADE)
+		r.removeShares(func(ps PlaceShare) bool { return ps.LinkType.Valid && int(ps.LinkType.Int32) == id })
+		return true, nil
+	}
+	return false, nil
+}
+
+// Helpers - callers hold mu
+
+// checkLookups mimics the place.status / place.link_type foreign keys
+func (r *MemoryRepository) checkLookups(status, linkType *int) error {
+	if status != nil {
+		found := false
+		for _, ps := range r.statuses {
+			found = found || ps.ID == *status
+		}
+		if !found {
+			return errForeignKeyViolation
+		}
+	}
+	if linkType != nil {
+		found := false
+		for _, pl := range r.linkTypes {
+			found = found || pl.ID == *linkType
+		}
+		if !found {
+			return errForeignKeyViolation
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ownsCategories(workspaceID uint64, categoryIDs []uint) bool {
+	for _, id := range categoryIDs {
+		pc, ok := r.categories[id]
+		if !ok || pc.WorkspaceID != workspaceID {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MemoryRepository) hasCategory(placeID uint64, categoryID uint) bool {
+	for _, l := range r.links {
+		if l.placeID == placeID && l.categoryID == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) replaceLinks(placeID, workspaceID uint64, categoryIDs []uint) {
+	r.removeLinks(func(l placeCategoryLink) bool { return l.placeID == placeID && l.workspaceID == workspaceID })
+	for _, categoryID := range categoryIDs {
+		r.links = append(r.links, placeCategoryLink{workspaceID: workspaceID, placeID: placeID, categoryID: categoryID})
+	}
+}
+
+func (r *MemoryRepository) removeLinks(match func(placeCategoryLink) bool) int {
+	kept := r.links[:0]
+	removed := 0
+	for _, l := range r.links {
+		if match(l) {
+			removed++
+			continue
+		}
+		kept = append(kept, l)
+	}
+	r.links = kept
+	return removed
+}
+
+func (r *MemoryRepository) removeShares(match func(PlaceShare) bool) {
+	for id, ps := range r.shares {
+		if match(ps) {
+			delete(r.shares, id)
+		}
+	}
+}
+
+// creatorID is the user_id column of a row created by userID
+func creatorID(userID uint64) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(userID), Valid: true}
+}
+
+// applyPlaceFields sets every non-nil field on p, converting to the nullable column types
+func applyPlaceFields(p *Place, name, link *string, linkType *int, description *string,
+	goAt *customtime.Date, goAtTime *customtime.DateTime, status *int) {
+	if name != nil {
+		p.Name = sql.NullString{String: *name, Valid: true}
+	}
+	if link != nil {
+		p.Link = sql.NullString{String: *link, Valid: true}
+	}
+	if linkType != nil {
+		p.LinkType = sql.NullInt32{Int32: int32(*linkType), Valid: true}
+	}
+	if description != nil {
+		p.Description = sql.NullString{String: *description, Valid: true}
+	}
+	if goAt != nil {
+		// DATE column: keep the calendar day only
+		y, m, d := goAt.Date()
+		p.GoAt = sql.NullTime{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC), Valid: !goAt.IsZero()}
+	}
+	if goAtTime != nil {
+		p.GoAtTime = sql.NullTime{Time: goAtTime.Time, Valid: !goAtTime.IsZero()}
+	}
+	if status != nil {
+		p.Status = sql.NullInt32{Int32: int32(*status), Valid: true}
+	}
+}
+
+// placeSortKey is the (sort column, id) pair used for ordering and keyset comparisons
+type placeSortKey struct {
+	t  time.Time
+	s  string
+	id uint64
+}
+
+// placeSortKeyOf mirrors placeSortColumns: NULL go_at sorts as -infinity, NULL name as ""
+func placeSortKeyOf(p *Place, sortBy string) placeSortKey {
+	key := placeSortKey{id: p.ID}
+	switch sortBy {
+	case "go_at":
+		if p.GoAt.Valid {
+			key.t = p.GoAt.Time
+		}
+	case "created_at":
+		key.t = p.CreatedAt
+	case "name":
+		key.s = p.Name.String
+	}
+	return key
+}
+
+func cursorSortKey(c *placeCursor) (placeSortKey, error) {
+	key := placeSortKey{id: c.ID}
+	switch c.Sort {
+	case "go_at":
+		if c.Value != "-infinity" {
+			t, err := time.Parse(customtime.DateFormat, c.Value)
+			if err != nil {
+				return key, ErrInvalidCursor
+			}
+			key.t = t
+		}
+	case "created_at":
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return key, ErrInvalidCursor
+		}
+		key.t = t
+	case "name":
+		key.s = c.Value
+	}
+	return key, nil
+}
+
+func comparePlaceSortKeys(a, b placeSortKey) int {
+	if c := a.t.Compare(b.t); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.s, b.s); c != 0 {
+		return c
+	}
+	switch {
+	case a.id < b.id:
+		return -1
+	case a.id > b.id:
+		return 1
+	}
+	return 0
+}
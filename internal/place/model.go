@@ -8,7 +8,8 @@ import (
 // Place represents the place domain model
 type Place struct {
 	ID          uint64         `db:"id" json:"id"`
-	UserID      uint64         `db:"user_id" json:"user_id"`
+	WorkspaceID uint64         `db:"workspace_id" json:"workspace_id"`
+	UserID      sql.NullInt64  `db:"user_id" json:"user_id"` // creator; NULL once their account is deleted
 	Name        sql.NullString `db:"name" json:"name"`
 	Link        sql.NullString `db:"link" json:"link"`
 	LinkType    sql.NullInt32  `db:"link_type" json:"link_type"`
@@ -18,11 +19,74 @@ type Place struct {
 	Status      sql.NullInt32  `db:"status" json:"status"`
 	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+
+	// Categories is loaded separately from place_category_list
+	Categories []PlaceCategory `db:"-" json:"categories"`
+	// StatusInfo and LinkInfo are resolved from the place_status / place_link lookups
+	StatusInfo *PlaceStatus `db:"-" json:"status_info"`
+	LinkInfo   *PlaceLink   `db:"-" json:"link_info"`
+}
+
+// PlaceFilter holds the parsed filters, sort and keyset position for listing places
+type PlaceFilter struct {
+	Status     *int
+	LinkType   *int
+	CategoryID *uint
+	GoAtFrom   *time.Time
+	GoAtTo     *time.Time
+	Name       string
+	Sort       string // go_at, created_at or name
+	Order      string // asc or desc
+	Limit      int
+	After      *placeCursor
 }
 
 // PlaceCategory represents the place_category domain model
 type PlaceCategory struct {
-	ID     uint   `db:"id" json:"id"`
-	UserID uint64 `db:"user_id" json:"user_id"`
-	Name   string `db:"name" json:"name"`
+	ID          uint          `db:"id" json:"id"`
+	WorkspaceID uint64        `db:"workspace_id" json:"workspace_id"`
+	UserID      sql.NullInt64 `db:"user_id" json:"user_id"` // creator; NULL once their account is deleted
+	Name        string        `db:"name" json:"name"`
+}
+
+// placeCategoryRow is a PlaceCategory joined with the place it is assigned to
+type placeCategoryRow struct {
+	PlaceID uint64 `db:"place_id"`
+	PlaceCategory
+}
+
+// PlaceShare is a public, read-only link to the places of a workspace that match
+// its filter. Only the hash of its token is stored.
+type PlaceShare struct {
+	ID           uint64         `db:"id"`
+	WorkspaceID  uint64         `db:"workspace_id"`
+	UserID       sql.NullInt64  `db:"user_id"` // creator; NULL once their account is deleted
+	Name         string         `db:"name"`
+	TokenPrefix  string         `db:"token_prefix"`
+	TokenHash    string         `db:"token_hash"`
+	PasswordHash sql.NullString `db:"password_hash"`
+	CategoryID   sql.NullInt64  `db:"category_id"`
+	Status       sql.NullInt32  `db:"status"`
+	LinkType     sql.NullInt32  `db:"link_type"`
+	GoAtFrom     sql.NullTime   `db:"go_at_from"`
+	GoAtTo       sql.NullTime   `db:"go_at_to"`
+	Q            string         `db:"q"`
+	Sort         string         `db:"sort"`
+	Order        string         `db:"sort_order"`
+	ExpiresAt    sql.NullTime   `db:"expires_at"`
+	CreatedAt    time.Time      `db:"created_at"`
+}
+
+// PlaceStatus represents the place_status lookup (e.g. wishlist, visited)
+type PlaceStatus struct {
+	ID         int    `db:"id" json:"id"`
+	Name       string `db:"name" json:"name"`
+	Background string `db:"background" json:"background"` // CSS hex colour
+}
+
+// PlaceLink represents the place_link lookup (link type by domain)
+type PlaceLink struct {
+	ID     int    `db:"id" json:"id"`
+	Domain string `db:"domain" json:"domain"`
+	Icon   string `db:"icon" json:"icon"`
 }
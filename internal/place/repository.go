@@ -2,53 +2,164 @@ package place
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"go-saas-api/pkg/customtime"
 	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// PlaceRepository is the storage used by Service.
+// Repository (Postgres) and MemoryRepository implement it. Every place and
+// category query is scoped to workspaceID; userID only records who created a
+// row. Lookups that find nothing return sql.ErrNoRows and updates/deletes of
+// missing rows return false.
+type PlaceRepository interface {
+	CreatePlace(ctx context.Context, workspaceID, userID uint64, req CreatePlaceReq) (int64, error)
+	ListPlaces(ctx context.Context, workspaceID uint64, f PlaceFilter) ([]Place, error)
+	GetPlaceByID(ctx context.Context, id, workspaceID uint64) (*Place, error)
+	UpdatePlace(ctx context.Context, id, workspaceID uint64, req UpdatePlaceReq) (bool, error)
+	DeletePlace(ctx context.Context, id, workspaceID uint64) (bool, error)
+
+	SetPlaceCategories(ctx context.Context, placeID, workspaceID uint64, categoryIDs []uint) error
+	AttachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) error
+	DetachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) (bool, error)
+	ListCategoriesByPlaceIDs(ctx context.Context, workspaceID uint64, placeIDs []uint64) (map[uint64][]PlaceCategory, error)
+
+	CreatePlaceCategory(ctx context.Context, workspaceID, userID uint64, name string) (int64, error)
+	ListPlaceCategories(ctx context.Context, workspaceID uint64, limit int) ([]PlaceCategory, error)
+	GetPlaceCategoryByID(ctx context.Context, id uint, workspaceID uint64) (*PlaceCategory, error)
+	UpdatePlaceCategory(ctx context.Context, id uint, workspaceID uint64, name *string) (bool, error)
+	DeletePlaceCategory(ctx context.Context, id uint, workspaceID uint64) (bool, error)
+
+	CreatePlaceShare(ctx context.Context, share *PlaceShare) error
+	ListPlaceShares(ctx context.Context, workspaceID uint64) ([]PlaceShare, error)
+	GetPlaceShareByHash(ctx context.Context, tokenHash string) (*PlaceShare, error)
+	DeletePlaceShare(ctx context.Context, id, workspaceID uint64) (bool, error)
+
+	ListPlaceStatuses(ctx context.Context) ([]PlaceStatus, error)
+	GetPlaceStatusByID(ctx context.Context, id int) (*PlaceStatus, error)
+	ListPlaceLinks(ctx context.Context) ([]PlaceLink, error)
+	GetPlaceLinkByID(ctx context.Context, id int) (*PlaceLink, error)
+
+	CreatePlaceStatus(ctx context.Context, name, background string) (int, error)
+	UpdatePlaceStatus(ctx context.Context, id int, name, background *string) (bool, error)
+	DeletePlaceStatus(ctx context.Context, id int) (bool, error)
+	CreatePlaceLink(ctx context.Context, domain, icon string) (int, error)
+	UpdatePlaceLink(ctx context.Context, id int, domain, icon *string) (bool, error)
+	DeletePlaceLink(ctx context.Context, id int) (bool, error)
+}
+
 type Repository struct {
 	db *sqlx.DB
 }
 
+var _ PlaceRepository = (*Repository)(nil)
+
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{db: db}
 }
 
 // Place Repository Methods
 
-func (r *Repository) CreatePlace(ctx context.Context, userID uint64, req CreatePlaceReq) (int64, error) {
+func (r *Repository) CreatePlace(ctx context.Context, workspaceID, userID uint64, req CreatePlaceReq) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	var id int64
-	err := r.db.QueryRowContext(ctx,
-		`INSERT INTO place (user_id, name, link, link_type, description, go_at, go_at_time, status) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
-		userID, req.Name, req.Link, req.LinkType, req.Description, req.GoAt, req.GoAtTime, req.Status,
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO place (workspace_id, user_id, name, link, link_type, description, go_at, go_at_time, status) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		workspaceID, userID, req.Name, req.Link, req.LinkType, req.Description, req.GoAt, req.GoAtTime, req.Status,
 	).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
+
+	if len(req.CategoryIDs) > 0 {
+		if err := replacePlaceCategories(ctx, tx, uint64(id), workspaceID, req.CategoryIDs); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 	return id, nil
 }
 
-func (r *Repository) ListPlaces(ctx context.Context, userID uint64, limit int) ([]Place, error) {
+func (r *Repository) ListPlaces(ctx context.Context, workspaceID uint64, f PlaceFilter) ([]Place, error) {
+	q := `SELECT id, workspace_id, user_id, name, link, link_type, description, go_at, go_at_time, status, created_at, updated_at 
+		FROM place WHERE `
+	args := []any{workspaceID}
+	conds := []string{"workspace_id = $1"}
+	paramIdx := 2
+
+	if f.Status != nil {
+		conds = append(conds, fmt.Sprintf("status = $%d", paramIdx))
+		args = append(args, *f.Status)
+		paramIdx++
+	}
+	if f.LinkType != nil {
+		conds = append(conds, fmt.Sprintf("link_type = $%d", paramIdx))
+		args = append(args, *f.LinkType)
+		paramIdx++
+	}
+	if f.CategoryID != nil {
+		conds = append(conds, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM place_category_list pcl WHERE pcl.place_id = place.id AND pcl.category_id = $%d)", paramIdx))
+		args = append(args, *f.CategoryID)
+		paramIdx++
+	}
+	if f.GoAtFrom != nil {
+		conds = append(conds, fmt.Sprintf("go_at >= $%d", paramIdx))
+		args = append(args, *f.GoAtFrom)
+		paramIdx++
+	}
+	if f.GoAtTo != nil {
+		conds = append(conds, fmt.Sprintf("go_at <= $%d", paramIdx))
+		args = append(args, *f.GoAtTo)
+		paramIdx++
+	}
+	if f.Name != "" {
+		conds = append(conds, fmt.Sprintf("name ILIKE $%d", paramIdx))
+		args = append(args, "%"+escapeLike(f.Name)+"%")
+		paramIdx++
+	}
+
+	col := placeSortColumns[f.Sort]
+	dir, cmp := "DESC", "<"
+	if f.Order == "asc" {
+		dir, cmp = "ASC", ">"
+	}
+
+	if f.After != nil {
+		conds = append(conds, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)", col.expr, cmp, paramIdx, col.cast, paramIdx+1))
+		args = append(args, f.After.Value, f.After.ID)
+		paramIdx += 2
+	}
+
+	q += strings.Join(conds, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT $%d", col.expr, dir, dir, paramIdx)
+	args = append(args, f.Limit)
+
 	var items []Place
-	err := r.db.SelectContext(ctx, &items,
-		`SELECT id, user_id, name, link, link_type, description, go_at, go_at_time, status, created_at, updated_at 
-		FROM place WHERE user_id = $1 ORDER BY id DESC LIMIT $2`,
-		userID, limit,
-	)
+	err := r.db.SelectContext(ctx, &items, q, args...)
 	return items, err
 }
 
-func (r *Repository) GetPlaceByID(ctx context.Context, id, userID uint64) (*Place, error) {
+func (r *Repository) GetPlaceByID(ctx context.Context, id, workspaceID uint64) (*Place, error) {
 	var p Place
 	err := r.db.GetContext(ctx, &p,
-		`SELECT id, user_id, name, link, link_type, description, go_at, go_at_time, status, created_at, updated_at 
-		FROM place WHERE id = $1 AND user_id = $2`,
-		id, userID,
+		`SELECT id, workspace_id, user_id, name, link, link_type, description, go_at, go_at_time, status, created_at, updated_at 
+		FROM place WHERE id = $1 AND workspace_id = $2`,
+		id, workspaceID,
 	)
 	if err != nil {
 		return nil, err
@@ -56,7 +167,7 @@ func (r *Repository) GetPlaceByID(ctx context.Context, id, userID uint64) (*Plac
 	return &p, nil
 }
 
-func (r *Repository) UpdatePlace(ctx context.Context, id, userID uint64, req UpdatePlaceReq) (bool, error) {
+func (r *Repository) UpdatePlace(ctx context.Context, id, workspaceID uint64, req UpdatePlaceReq) (bool, error) {
 	q := "UPDATE place SET "
 	args := []any{}
 	sets := []string{}
@@ -98,18 +209,51 @@ func (r *Repository) UpdatePlace(ctx context.Context, id, userID uint64, req Upd
 		paramIdx++
 	}
 
-	if len(sets) == 0 {
+	if len(sets) == 0 && req.CategoryIDs == nil {
 		return false, nil
 	}
 
-	q += strings.Join(sets, ", ") + fmt.Sprintf(" WHERE id = $%d AND user_id = $%d", paramIdx, paramIdx+1)
-	args = append(args, id, userID)
-
-	res, err := r.db.ExecContext(ctx, q, args...)
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return false, err
 	}
+	defer tx.Rollback()
+
+	updated := false
+	if len(sets) > 0 {
+		q += strings.Join(sets, ", ") + fmt.Sprintf(" WHERE id = $%d AND workspace_id = $%d", paramIdx, paramIdx+1)
+		args = append(args, id, workspaceID)
+
+		res, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return false, err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		updated = rows > 0
+	}
 
+	if req.CategoryIDs != nil {
+		if err := replacePlaceCategories(ctx, tx, id, workspaceID, req.CategoryIDs); err != nil {
+			return false, err
+		}
+		updated = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+func (r *Repository) DeletePlace(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM place WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return false, err
+	}
 	rows, err := res.RowsAffected()
 	if err != nil {
 		return false, err
@@ -117,8 +261,37 @@ func (r *Repository) UpdatePlace(ctx context.Context, id, userID uint64, req Upd
 	return rows > 0, nil
 }
 
-func (r *Repository) DeletePlace(ctx context.Context, id, userID uint64) (bool, error) {
-	res, err := r.db.ExecContext(ctx, `DELETE FROM place WHERE id = $1 AND user_id = $2`, id, userID)
+// Place <-> Category Repository Methods
+
+// SetPlaceCategories replaces all categories assigned to a place.
+// Returns sql.ErrNoRows if any category does not belong to the workspace.
+func (r *Repository) SetPlaceCategories(ctx context.Context, placeID, workspaceID uint64, categoryIDs []uint) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replacePlaceCategories(ctx, tx, placeID, workspaceID, categoryIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) AttachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO place_category_list (workspace_id, place_id, category_id) VALUES ($1, $2, $3)
+		ON CONFLICT (place_id, category_id) DO NOTHING`,
+		workspaceID, placeID, categoryID,
+	)
+	return err
+}
+
+func (r *Repository) DetachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM place_category_list WHERE place_id = $1 AND workspace_id = $2 AND category_id = $3`,
+		placeID, workspaceID, categoryID,
+	)
 	if err != nil {
 		return false, err
 	}
@@ -129,13 +302,86 @@ func (r *Repository) DeletePlace(ctx context.Context, id, userID uint64) (bool,
 	return rows > 0, nil
 }
 
+// ListCategoriesByPlaceIDs returns the categories assigned to each of the given places, keyed by place ID
+func (r *Repository) ListCategoriesByPlaceIDs(ctx context.Context, workspaceID uint64, placeIDs []uint64) (map[uint64][]PlaceCategory, error) {
+	result := make(map[uint64][]PlaceCategory, len(placeIDs))
+	if len(placeIDs) == 0 {
+		return result, nil
+	}
+
+	ids := make(pq.Int64Array, len(placeIDs))
+	for i, id := range placeIDs {
+		ids[i] = int64(id)
+	}
+
+	var rows []placeCategoryRow
+	err := r.db.SelectContext(ctx, &rows,
+		`SELECT pcl.place_id, pc.id, pc.workspace_id, pc.user_id, pc.name
+		FROM place_category_list pcl
+		JOIN place_category pc ON pc.id = pcl.category_id
+		WHERE pcl.workspace_id = $1 AND pcl.place_id = ANY($2)
+		ORDER BY pc.name, pc.id`,
+		workspaceID, ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.PlaceID] = append(result[row.PlaceID], row.PlaceCategory)
+	}
+	return result, nil
+}
+
+// replacePlaceCategories clears and re-inserts the category links of a place inside tx.
+// Only categories of workspaceID are inserted; if any ID is missing, sql.ErrNoRows is returned.
+func replacePlaceCategories(ctx context.Context, tx *sqlx.Tx, placeID, workspaceID uint64, categoryIDs []uint) error {
+	_, err := tx.ExecContext(ctx,
+		`DELETE FROM place_category_list WHERE place_id = $1 AND workspace_id = $2`,
+		placeID, workspaceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	ids := make(pq.Int64Array, len(categoryIDs))
+	for i, id := range categoryIDs {
+		ids[i] = int64(id)
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO place_category_list (workspace_id, place_id, category_id)
+		SELECT $1, p.id, pc.id
+		FROM place p
+		JOIN place_category pc ON pc.workspace_id = p.workspace_id
+		WHERE p.id = $2 AND p.workspace_id = $1 AND pc.id = ANY($3)`,
+		workspaceID, placeID, ids,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != int64(len(categoryIDs)) {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // PlaceCategory Repository Methods
 
-func (r *Repository) CreatePlaceCategory(ctx context.Context, userID uint64, name string) (int64, error) {
+func (r *Repository) CreatePlaceCategory(ctx context.Context, workspaceID, userID uint64, name string) (int64, error) {
 	var id int64
 	err := r.db.QueryRowContext(ctx,
-		`INSERT INTO place_category (user_id, name) VALUES ($1, $2) RETURNING id`,
-		userID, name,
+		`INSERT INTO place_category (workspace_id, user_id, name) VALUES ($1, $2, $3) RETURNING id`,
+		workspaceID, userID, name,
 	).Scan(&id)
 	if err != nil {
 		return 0, err
@@ -143,20 +389,20 @@ func (r *Repository) CreatePlaceCategory(ctx context.Context, userID uint64, nam
 	return id, nil
 }
 
-func (r *Repository) ListPlaceCategories(ctx context.Context, userID uint64, limit int) ([]PlaceCategory, error) {
+func (r *Repository) ListPlaceCategories(ctx context.Context, workspaceID uint64, limit int) ([]PlaceCategory, error) {
 	var items []PlaceCategory
 	err := r.db.SelectContext(ctx, &items,
-		`SELECT id, user_id, name FROM place_category WHERE user_id = $1 ORDER BY id DESC LIMIT $2`,
-		userID, limit,
+		`SELECT id, workspace_id, user_id, name FROM place_category WHERE workspace_id = $1 ORDER BY id DESC LIMIT $2`,
+		workspaceID, limit,
 	)
 	return items, err
 }
 
-func (r *Repository) GetPlaceCategoryByID(ctx context.Context, id uint, userID uint64) (*PlaceCategory, error) {
+func (r *Repository) GetPlaceCategoryByID(ctx context.Context, id uint, workspaceID uint64) (*PlaceCategory, error) {
 	var pc PlaceCategory
 	err := r.db.GetContext(ctx, &pc,
-		`SELECT id, user_id, name FROM place_category WHERE id = $1 AND user_id = $2`,
-		id, userID,
+		`SELECT id, workspace_id, user_id, name FROM place_category WHERE id = $1 AND workspace_id = $2`,
+		id, workspaceID,
 	)
 	if err != nil {
 		return nil, err
@@ -164,14 +410,14 @@ func (r *Repository) GetPlaceCategoryByID(ctx context.Context, id uint, userID u
 	return &pc, nil
 }
 
-func (r *Repository) UpdatePlaceCategory(ctx context.Context, id uint, userID uint64, name *string) (bool, error) {
+func (r *Repository) UpdatePlaceCategory(ctx context.Context, id uint, workspaceID uint64, name *string) (bool, error) {
 	if name == nil {
 		return false, nil
 	}
 
 	res, err := r.db.ExecContext(ctx,
-		`UPDATE place_category SET name = $1 WHERE id = $2 AND user_id = $3`,
-		*name, id, userID,
+		`UPDATE place_category SET name = $1 WHERE id = $2 AND workspace_id = $3`,
+		*name, id, workspaceID,
 	)
 	if err != nil {
 		return false, err
@@ -184,11 +430,223 @@ func (r *Repository) UpdatePlaceCategory(ctx context.Context, id uint, userID ui
 	return rows > 0, nil
 }
 
-func (r *Repository) DeletePlaceCategory(ctx context.Context, id uint, userID uint64) (bool, error) {
+func (r *Repository) DeletePlaceCategory(ctx context.Context, id uint, workspaceID uint64) (bool, error) {
 	res, err := r.db.ExecContext(ctx,
-		`DELETE FROM place_category WHERE id = $1 AND user_id = $2`,
-		id, userID,
+		`DELETE FROM place_category WHERE id = $1 AND workspace_id = $2`,
+		id, workspaceID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// PlaceShare Repository Methods
+
+const placeShareColumns = `id, workspace_id, user_id, name, token_prefix, token_hash, password_hash, category_id,
+	status, link_type, go_at_from, go_at_to, q, sort, sort_order, expires_at, created_at`
+
+// CreatePlaceShare inserts share and sets its ID and CreatedAt
+func (r *Repository) CreatePlaceShare(ctx context.Context, share *PlaceShare) error {
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO place_share (workspace_id, user_id, name, token_prefix, token_hash, password_hash, category_id,
+			status, link_type, go_at_from, go_at_to, q, sort, sort_order, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id, created_at`,
+		share.WorkspaceID, share.UserID, share.Name, share.TokenPrefix, share.TokenHash, share.PasswordHash, share.CategoryID,
+		share.Status, share.LinkType, share.GoAtFrom, share.GoAtTo, share.Q, share.Sort, share.Order, share.ExpiresAt,
+	).Scan(&share.ID, &share.CreatedAt)
+}
+
+// ListPlaceShares returns the workspace's share links, newest first, including expired ones
+func (r *Repository) ListPlaceShares(ctx context.Context, workspaceID uint64) ([]PlaceShare, error) {
+	var shares []PlaceShare
+	err := r.db.SelectContext(ctx, &shares,
+		`SELECT `+placeShareColumns+` FROM place_share WHERE workspace_id = $1 ORDER BY id DESC`, workspaceID)
+	return shares, err
+}
+
+func (r *Repository) GetPlaceShareByHash(ctx context.Context, tokenHash string) (*PlaceShare, error) {
+	var share PlaceShare
+	err := r.db.GetContext(ctx, &share, `SELECT `+placeShareColumns+` FROM place_share WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *Repository) DeletePlaceShare(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM place_share WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// Lookup Repository Methods
+
+func (r *Repository) ListPlaceStatuses(ctx context.Context) ([]PlaceStatus, error) {
+	var items []PlaceStatus
+	err := r.db.SelectContext(ctx, &items,
+		`SELECT id, COALESCE(name, '') AS name, COALESCE(background, '') AS background FROM place_status ORDER BY id`,
+	)
+	return items, err
+}
+
+func (r *Repository) GetPlaceStatusByID(ctx context.Context, id int) (*PlaceStatus, error) {
+	var ps PlaceStatus
+	err := r.db.GetContext(ctx, &ps,
+		`SELECT id, COALESCE(name, '') AS name, COALESCE(background, '') AS background FROM place_status WHERE id = $1`,
+		id,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &ps, nil
+}
+
+func (r *Repository) ListPlaceLinks(ctx context.Context) ([]PlaceLink, error) {
+	var items []PlaceLink
+	err := r.db.SelectContext(ctx, &items,
+		`SELECT id, COALESCE(domain, '') AS domain, COALESCE(icon, '') AS icon FROM place_link ORDER BY id`,
+	)
+	return items, err
+}
+
+func (r *Repository) GetPlaceLinkByID(ctx context.Context, id int) (*PlaceLink, error) {
+	var pl PlaceLink
+	err := r.db.GetContext(ctx, &pl,
+		`SELECT id, COALESCE(domain, '') AS domain, COALESCE(icon, '') AS icon FROM place_link WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pl, nil
+}
+
+// Admin Lookup Repository Methods
+
+func (r *Repository) CreatePlaceStatus(ctx context.Context, name, background string) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO place_status (name, background) VALUES ($1, $2) RETURNING id`,
+		name, background,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *Repository) UpdatePlaceStatus(ctx context.Context, id int, name, background *string) (bool, error) {
+	var sets []string
+	var args []interface{}
+	paramIdx := 1
+
+	if name != nil {
+		sets = append(sets, fmt.Sprintf("name = $%d", paramIdx))
+		args = append(args, *name)
+		paramIdx++
+	}
+	if background != nil {
+		sets = append(sets, fmt.Sprintf("background = $%d", paramIdx))
+		args = append(args, *background)
+		paramIdx++
+	}
+	if len(sets) == 0 {
+		return false, nil
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE place_status SET %s WHERE id = $%d", strings.Join(sets, ", "), paramIdx)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DeletePlaceStatus removes a status; places using it keep a NULL status (ON DELETE SET NULL)
+// and share links filtering on it are deleted
+func (r *Repository) DeletePlaceStatus(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM place_status WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *Repository) CreatePlaceLink(ctx context.Context, domain, icon string) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO place_link (domain, icon) VALUES ($1, $2) RETURNING id`,
+		domain, icon,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *Repository) UpdatePlaceLink(ctx context.Context, id int, domain, icon *string) (bool, error) {
+	var sets []string
+	var args []interface{}
+	paramIdx := 1
+
+	if domain != nil {
+		sets = append(sets, fmt.Sprintf("domain = $%d", paramIdx))
+		args = append(args, *domain)
+		paramIdx++
+	}
+	if icon != nil {
+		sets = append(sets, fmt.Sprintf("icon = $%d", paramIdx))
+		args = append(args, *icon)
+		paramIdx++
+	}
+	if len(sets) == 0 {
+		return false, nil
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE place_link SET %s WHERE id = $%d", strings.Join(sets, ", "), paramIdx)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DeletePlaceLink removes a link type; places using it keep a NULL link_type (ON DELETE SET NULL)
+// and share links filtering on it are deleted
+func (r *Repository) DeletePlaceLink(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM place_link WHERE id = $1`, id)
 	if err != nil {
 		return false, err
 	}
@@ -202,10 +660,11 @@ func (r *Repository) DeletePlaceCategory(ctx context.Context, id uint, userID ui
 // Helper function to convert Place model to response
 func ToPlaceResponse(p *Place) PlaceResponse {
 	resp := PlaceResponse{
-		ID:        p.ID,
-		UserID:    p.UserID,
-		CreatedAt: p.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
+		ID:          p.ID,
+		WorkspaceID: p.WorkspaceID,
+		UserID:      nullableID(p.UserID),
+		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
 	}
 
 	if p.Name.Valid {
@@ -232,14 +691,56 @@ func ToPlaceResponse(p *Place) PlaceResponse {
 		resp.Status = &status
 	}
 
+	if p.StatusInfo != nil {
+		info := ToPlaceStatusResponse(p.StatusInfo)
+		resp.StatusInfo = &info
+	}
+	if p.LinkInfo != nil {
+		info := ToPlaceLinkResponse(p.LinkInfo)
+		resp.LinkInfo = &info
+	}
+
+	resp.Categories = make([]PlaceCategoryResponse, len(p.Categories))
+	for i := range p.Categories {
+		resp.Categories[i] = ToPlaceCategoryResponse(&p.Categories[i])
+	}
+
 	return resp
 }
 
 // Helper function to convert PlaceCategory model to response
 func ToPlaceCategoryResponse(pc *PlaceCategory) PlaceCategoryResponse {
 	return PlaceCategoryResponse{
-		ID:     pc.ID,
-		UserID: pc.UserID,
-		Name:   pc.Name,
+		ID:          pc.ID,
+		WorkspaceID: pc.WorkspaceID,
+		UserID:      nullableID(pc.UserID),
+		Name:        pc.Name,
+	}
+}
+
+// nullableID converts a nullable ID column for JSON, where NULL becomes null
+func nullableID(id sql.NullInt64) *uint64 {
+	if !id.Valid {
+		return nil
+	}
+	v := uint64(id.Int64)
+	return &v
+}
+
+// Helper function to convert PlaceStatus model to response
+func ToPlaceStatusResponse(ps *PlaceStatus) PlaceStatusResponse {
+	return PlaceStatusResponse{
+		ID:         ps.ID,
+		Name:       ps.Name,
+		Background: ps.Background,
+	}
+}
+
+// Helper function to convert PlaceLink model to response
+func ToPlaceLinkResponse(pl *PlaceLink) PlaceLinkResponse {
+	return PlaceLinkResponse{
+		ID:     pl.ID,
+		Domain: pl.Domain,
+		Icon:   pl.Icon,
 	}
 }
@@ -0,0 +1,489 @@
+package place
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"go-saas-api/internal/testutil"
+)
+
+// backends lists every PlaceRepository implementation the suite runs against.
+// new returns the repository and two distinct workspace IDs. Each workspace is
+// the personal workspace of the user with the same ID, so tests pass one ID as
+// both the workspace and the creator.
+var backends = []struct {
+	name string
+	new  func(t *testing.T) (PlaceRepository, uint64, uint64)
+}{
+	{"memory", func(t *testing.T) (PlaceRepository, uint64, uint64) {
+		return NewMemoryRepository(), 1, 2
+	}},
+	{"postgres", func(t *testing.T) (PlaceRepository, uint64, uint64) {
+		db := testutil.PostgresDB(t)
+		var ids []uint64
+		err := db.Select(&ids,
+			`WITH u AS (
+				INSERT INTO users (email, password, name) VALUES ('a@example.com', 'x', 'A'), ('b@example.com', 'x', 'B') RETURNING id
+			)
+			INSERT INTO workspaces (id, name, personal_user_id) SELECT id, 'Personal', id FROM u RETURNING id`)
+		if err != nil {
+			t.Fatalf("insert users and workspaces: %v", err)
+		}
+		return NewRepository(db), ids[0], ids[1]
+	}},
+}
+
+func TestRepositoryPlaceOwnership(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64)
+	}{
+		{"owner can read, update and delete", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			id := mustCreatePlace(t, ctx, repo, owner, "cafe")
+
+			p, err := repo.GetPlaceByID(ctx, id, owner)
+			if err != nil {
+				t.Fatalf("GetPlaceByID: %v", err)
+			}
+			if p.Name.String != "cafe" || p.WorkspaceID != owner || p.UserID.Int64 != int64(owner) {
+				t.Errorf("got %+v", p)
+			}
+
+			updated, err := repo.UpdatePlace(ctx, id, owner, UpdatePlaceReq{Name: strPtr("bakery")})
+			if err != nil || !updated {
+				t.Fatalf("UpdatePlace = %v, %v", updated, err)
+			}
+			p, _ = repo.GetPlaceByID(ctx, id, owner)
+			if p.Name.String != "bakery" {
+				t.Errorf("name = %q, want bakery", p.Name.String)
+			}
+
+			deleted, err := repo.DeletePlace(ctx, id, owner)
+			if err != nil || !deleted {
+				t.Fatalf("DeletePlace = %v, %v", deleted, err)
+			}
+			if _, err := repo.GetPlaceByID(ctx, id, owner); err != sql.ErrNoRows {
+				t.Errorf("after delete err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"other workspace cannot see or change the place", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			id := mustCreatePlace(t, ctx, repo, owner, "cafe")
+
+			if _, err := repo.GetPlaceByID(ctx, id, other); err != sql.ErrNoRows {
+				t.Errorf("GetPlaceByID err = %v, want sql.ErrNoRows", err)
+			}
+			if updated, err := repo.UpdatePlace(ctx, id, other, UpdatePlaceReq{Name: strPtr("x")}); err != nil || updated {
+				t.Errorf("UpdatePlace = %v, %v; want false", updated, err)
+			}
+			if deleted, err := repo.DeletePlace(ctx, id, other); err != nil || deleted {
+				t.Errorf("DeletePlace = %v, %v; want false", deleted, err)
+			}
+			items, err := repo.ListPlaces(ctx, other, PlaceFilter{Sort: "created_at", Order: "desc", Limit: 10})
+			if err != nil || len(items) != 0 {
+				t.Errorf("ListPlaces = %d items, %v; want none", len(items), err)
+			}
+		}},
+		{"update with no fields is a no-op", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			id := mustCreatePlace(t, ctx, repo, owner, "cafe")
+			if updated, err := repo.UpdatePlace(ctx, id, owner, UpdatePlaceReq{}); err != nil || updated {
+				t.Errorf("UpdatePlace = %v, %v; want false", updated, err)
+			}
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				repo, owner, other := b.new(t)
+				tt.run(t, context.Background(), repo, owner, other)
+			})
+		}
+	}
+}
+
+func TestRepositoryPlaceCategories(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64)
+	}{
+		{"create place with own categories", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			food := mustCreateCategory(t, ctx, repo, owner, "food")
+			bar := mustCreateCategory(t, ctx, repo, owner, "bar")
+
+			id, err := repo.CreatePlace(ctx, owner, owner, CreatePlaceReq{Name: strPtr("cafe"), CategoryIDs: []uint{food, bar}})
+			if err != nil {
+				t.Fatalf("CreatePlace: %v", err)
+			}
+			assertCategoryNames(t, ctx, repo, owner, uint64(id), "bar", "food")
+		}},
+		{"cannot link another workspace's category", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			foreign := mustCreateCategory(t, ctx, repo, other, "theirs")
+
+			_, err := repo.CreatePlace(ctx, owner, owner, CreatePlaceReq{Name: strPtr("cafe"), CategoryIDs: []uint{foreign}})
+			if err != sql.ErrNoRows {
+				t.Fatalf("CreatePlace err = %v, want sql.ErrNoRows", err)
+			}
+			items, _ := repo.ListPlaces(ctx, owner, PlaceFilter{Sort: "created_at", Order: "desc", Limit: 10})
+			if len(items) != 0 {
+				t.Errorf("place was created despite the error")
+			}
+
+			id := mustCreatePlace(t, ctx, repo, owner, "cafe")
+			if err := repo.SetPlaceCategories(ctx, id, owner, []uint{foreign}); err != sql.ErrNoRows {
+				t.Errorf("SetPlaceCategories err = %v, want sql.ErrNoRows", err)
+			}
+			assertCategoryNames(t, ctx, repo, owner, id)
+		}},
+		{"set replaces and empty clears", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			food := mustCreateCategory(t, ctx, repo, owner, "food")
+			bar := mustCreateCategory(t, ctx, repo, owner, "bar")
+			id := mustCreatePlace(t, ctx, repo, owner, "cafe")
+
+			if err := repo.SetPlaceCategories(ctx, id, owner, []uint{food}); err != nil {
+				t.Fatalf("SetPlaceCategories: %v", err)
+			}
+			assertCategoryNames(t, ctx, repo, owner, id, "food")
+
+			if err := repo.SetPlaceCategories(ctx, id, owner, []uint{bar}); err != nil {
+				t.Fatalf("SetPlaceCategories: %v", err)
+			}
+			assertCategoryNames(t, ctx, repo, owner, id, "bar")
+
+			if _, err := repo.UpdatePlace(ctx, id, owner, UpdatePlaceReq{CategoryIDs: []uint{}}); err != nil {
+				t.Fatalf("UpdatePlace: %v", err)
+			}
+			assertCategoryNames(t, ctx, repo, owner, id)
+		}},
+		{"attach is idempotent and detach reports missing links", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			food := mustCreateCategory(t, ctx, repo, owner, "food")
+			id := mustCreatePlace(t, ctx, repo, owner, "cafe")
+
+			for i := 0; i < 2; i++ {
+				if err := repo.AttachPlaceCategory(ctx, id, owner, food); err != nil {
+					t.Fatalf("AttachPlaceCategory: %v", err)
+				}
+			}
+			assertCategoryNames(t, ctx, repo, owner, id, "food")
+
+			if detached, err := repo.DetachPlaceCategory(ctx, id, owner, food); err != nil || !detached {
+				t.Errorf("DetachPlaceCategory = %v, %v; want true", detached, err)
+			}
+			if detached, err := repo.DetachPlaceCategory(ctx, id, owner, food); err != nil || detached {
+				t.Errorf("second DetachPlaceCategory = %v, %v; want false", detached, err)
+			}
+		}},
+		{"deleting a category unlinks it", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			food := mustCreateCategory(t, ctx, repo, owner, "food")
+			id := mustCreatePlace(t, ctx, repo, owner, "cafe")
+			if err := repo.SetPlaceCategories(ctx, id, owner, []uint{food}); err != nil {
+				t.Fatalf("SetPlaceCategories: %v", err)
+			}
+
+			if deleted, err := repo.DeletePlaceCategory(ctx, food, other); err != nil || deleted {
+				t.Errorf("DeletePlaceCategory by other = %v, %v; want false", deleted, err)
+			}
+			if deleted, err := repo.DeletePlaceCategory(ctx, food, owner); err != nil || !deleted {
+				t.Fatalf("DeletePlaceCategory = %v, %v", deleted, err)
+			}
+			assertCategoryNames(t, ctx, repo, owner, id)
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				repo, owner, other := b.new(t)
+				tt.run(t, context.Background(), repo, owner, other)
+			})
+		}
+	}
+}
+
+func TestRepositoryListPlaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter PlaceFilter
+		want   []string
+	}{
+		{"name asc", PlaceFilter{Sort: "name", Order: "asc"}, []string{"alpha", "bravo", "charlie", "delta"}},
+		{"name desc", PlaceFilter{Sort: "name", Order: "desc"}, []string{"delta", "charlie", "bravo", "alpha"}},
+		{"status filter", PlaceFilter{Sort: "name", Order: "asc", Status: intPtr(3)}, []string{"bravo", "delta"}},
+		{"name match is case-insensitive", PlaceFilter{Sort: "name", Order: "asc", Name: "HAR"}, []string{"charlie"}},
+		{"like wildcards are literal", PlaceFilter{Sort: "name", Order: "asc", Name: "%"}, nil},
+		{"limit", PlaceFilter{Sort: "name", Order: "asc", Limit: 2}, []string{"alpha", "bravo"}},
+		{"after cursor", PlaceFilter{Sort: "name", Order: "asc", After: &placeCursor{Sort: "name", Order: "asc", Value: "bravo", ID: 1 << 62}}, []string{"charlie", "delta"}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo, owner, other := b.new(t)
+
+				for _, p := range []struct {
+					name   string
+					status int
+				}{{"charlie", 1}, {"alpha", 2}, {"delta", 3}, {"bravo", 3}} {
+					if _, err := repo.CreatePlace(ctx, owner, owner, CreatePlaceReq{Name: strPtr(p.name), Status: intPtr(p.status)}); err != nil {
+						t.Fatalf("CreatePlace: %v", err)
+					}
+				}
+				mustCreatePlace(t, ctx, repo, other, "alpha")
+
+				f := tt.filter
+				if f.Limit == 0 {
+					f.Limit = 100
+				}
+				items, err := repo.ListPlaces(ctx, owner, f)
+				if err != nil {
+					t.Fatalf("ListPlaces: %v", err)
+				}
+
+				var got []string
+				for _, p := range items {
+					got = append(got, p.Name.String)
+				}
+				if !equalStrings(got, tt.want) {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRepositoryLookups(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, _, _ := b.new(t)
+
+			statuses, err := repo.ListPlaceStatuses(ctx)
+			if err != nil || len(statuses) == 0 {
+				t.Fatalf("ListPlaceStatuses = %d, %v", len(statuses), err)
+			}
+			if _, err := repo.GetPlaceStatusByID(ctx, statuses[0].ID); err != nil {
+				t.Errorf("GetPlaceStatusByID: %v", err)
+			}
+			if _, err := repo.GetPlaceStatusByID(ctx, 9999); err != sql.ErrNoRows {
+				t.Errorf("missing status err = %v, want sql.ErrNoRows", err)
+			}
+
+			links, err := repo.ListPlaceLinks(ctx)
+			if err != nil || len(links) == 0 {
+				t.Fatalf("ListPlaceLinks = %d, %v", len(links), err)
+			}
+			if _, err := repo.GetPlaceLinkByID(ctx, 9999); err != sql.ErrNoRows {
+				t.Errorf("missing link err = %v, want sql.ErrNoRows", err)
+			}
+		})
+	}
+}
+
+func TestRepositoryAdminLookups(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, workspaceID, _ := b.new(t)
+
+			statusID, err := repo.CreatePlaceStatus(ctx, "Closed", "#EF4444")
+			if err != nil {
+				t.Fatalf("CreatePlaceStatus: %v", err)
+			}
+			linkID, err := repo.CreatePlaceLink(ctx, "youtube.com", "youtube")
+			if err != nil {
+				t.Fatalf("CreatePlaceLink: %v", err)
+			}
+			// Lookup rows survive the test database reset, so clean up after ourselves
+			t.Cleanup(func() {
+				_, _ = repo.DeletePlaceStatus(ctx, statusID)
+				_, _ = repo.DeletePlaceLink(ctx, linkID)
+			})
+
+			if ok, err := repo.UpdatePlaceStatus(ctx, statusID, strPtr("Closed down"), nil); err != nil || !ok {
+				t.Fatalf("UpdatePlaceStatus = %v, %v", ok, err)
+			}
+			if ok, err := repo.UpdatePlaceLink(ctx, linkID, nil, strPtr("yt")); err != nil || !ok {
+				t.Fatalf("UpdatePlaceLink = %v, %v", ok, err)
+			}
+			status, _ := repo.GetPlaceStatusByID(ctx, statusID)
+			link, _ := repo.GetPlaceLinkByID(ctx, linkID)
+			if status == nil || status.Name != "Closed down" || status.Background != "#EF4444" {
+				t.Errorf("status = %+v", status)
+			}
+			if link == nil || link.Domain != "youtube.com" || link.Icon != "yt" {
+				t.Errorf("link = %+v", link)
+			}
+			if ok, _ := repo.UpdatePlaceStatus(ctx, 9999, strPtr("x"), nil); ok {
+				t.Error("UpdatePlaceStatus of missing status returned true")
+			}
+
+			// Deleting a lookup in use clears it on places (ON DELETE SET NULL)
+			placeID, err := repo.CreatePlace(ctx, workspaceID, workspaceID, CreatePlaceReq{Name: strPtr("cafe"), Status: &statusID, LinkType: &linkID})
+			if err != nil {
+				t.Fatalf("CreatePlace: %v", err)
+			}
+			if ok, err := repo.DeletePlaceStatus(ctx, statusID); err != nil || !ok {
+				t.Fatalf("DeletePlaceStatus = %v, %v", ok, err)
+			}
+			if ok, err := repo.DeletePlaceLink(ctx, linkID); err != nil || !ok {
+				t.Fatalf("DeletePlaceLink = %v, %v", ok, err)
+			}
+			p, err := repo.GetPlaceByID(ctx, uint64(placeID), workspaceID)
+			if err != nil {
+				t.Fatalf("GetPlaceByID: %v", err)
+			}
+			if p.Status.Valid || p.LinkType.Valid {
+				t.Errorf("status = %v, link_type = %v; want both NULL", p.Status, p.LinkType)
+			}
+			if ok, _ := repo.DeletePlaceStatus(ctx, statusID); ok {
+				t.Error("second DeletePlaceStatus returned true")
+			}
+		})
+	}
+}
+
+func TestRepositoryPlaceShares(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64)
+	}{
+		{"create, get by hash and list", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			categoryID := mustCreateCategory(t, ctx, repo, owner, "food")
+			share := &PlaceShare{
+				WorkspaceID:  owner,
+				UserID:       sql.NullInt64{Int64: int64(owner), Valid: true},
+				Name:         "Jakarta eats",
+				TokenPrefix:  "abcdefgh",
+				TokenHash:    "hash-1",
+				PasswordHash: sql.NullString{String: "bcrypt", Valid: true},
+				CategoryID:   sql.NullInt64{Int64: int64(categoryID), Valid: true},
+				Q:            "cafe",
+				Sort:         "name",
+				Order:        "asc",
+			}
+			if err := repo.CreatePlaceShare(ctx, share); err != nil {
+				t.Fatalf("CreatePlaceShare: %v", err)
+			}
+			if share.ID == 0 || share.CreatedAt.IsZero() {
+				t.Errorf("ID/CreatedAt not set: %+v", share)
+			}
+
+			got, err := repo.GetPlaceShareByHash(ctx, "hash-1")
+			if err != nil {
+				t.Fatalf("GetPlaceShareByHash: %v", err)
+			}
+			if got.ID != share.ID || got.WorkspaceID != owner || got.Name != "Jakarta eats" || got.CategoryID.Int64 != int64(categoryID) ||
+				got.PasswordHash.String != "bcrypt" || got.Q != "cafe" || got.Sort != "name" || got.Order != "asc" {
+				t.Errorf("got %+v", got)
+			}
+			if _, err := repo.GetPlaceShareByHash(ctx, "unknown"); err != sql.ErrNoRows {
+				t.Errorf("unknown hash err = %v, want sql.ErrNoRows", err)
+			}
+
+			items, err := repo.ListPlaceShares(ctx, owner)
+			if err != nil || len(items) != 1 || items[0].ID != share.ID {
+				t.Errorf("ListPlaceShares(owner) = %+v, %v", items, err)
+			}
+			if items, _ := repo.ListPlaceShares(ctx, other); len(items) != 0 {
+				t.Errorf("ListPlaceShares(other) = %+v, want none", items)
+			}
+		}},
+		{"delete is scoped to the workspace", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			share := &PlaceShare{WorkspaceID: owner, TokenPrefix: "abcdefgh", TokenHash: "hash-1", Sort: "created_at", Order: "desc"}
+			if err := repo.CreatePlaceShare(ctx, share); err != nil {
+				t.Fatalf("CreatePlaceShare: %v", err)
+			}
+
+			if ok, _ := repo.DeletePlaceShare(ctx, share.ID, other); ok {
+				t.Error("deleted a share link through another workspace")
+			}
+			if ok, err := repo.DeletePlaceShare(ctx, share.ID, owner); err != nil || !ok {
+				t.Fatalf("DeletePlaceShare = %v, %v", ok, err)
+			}
+			if _, err := repo.GetPlaceShareByHash(ctx, "hash-1"); err != sql.ErrNoRows {
+				t.Errorf("GetPlaceShareByHash err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"deleting the category deletes the link", func(t *testing.T, ctx context.Context, repo PlaceRepository, owner, other uint64) {
+			categoryID := mustCreateCategory(t, ctx, repo, owner, "food")
+			share := &PlaceShare{
+				WorkspaceID: owner,
+				TokenPrefix: "abcdefgh",
+				TokenHash:   "hash-1",
+				CategoryID:  sql.NullInt64{Int64: int64(categoryID), Valid: true},
+				Sort:        "created_at",
+				Order:       "desc",
+			}
+			if err := repo.CreatePlaceShare(ctx, share); err != nil {
+				t.Fatalf("CreatePlaceShare: %v", err)
+			}
+
+			if ok, err := repo.DeletePlaceCategory(ctx, categoryID, owner); err != nil || !ok {
+				t.Fatalf("DeletePlaceCategory = %v, %v", ok, err)
+			}
+			// Otherwise the link would widen to every place in the workspace
+			if _, err := repo.GetPlaceShareByHash(ctx, "hash-1"); err != sql.ErrNoRows {
+				t.Errorf("GetPlaceShareByHash err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				repo, owner, other := b.new(t)
+				tt.run(t, context.Background(), repo, owner, other)
+			})
+		}
+	}
+}
+
+func mustCreatePlace(t *testing.T, ctx context.Context, repo PlaceRepository, workspaceID uint64, name string) uint64 {
+	t.Helper()
+	id, err := repo.CreatePlace(ctx, workspaceID, workspaceID, CreatePlaceReq{Name: strPtr(name)})
+	if err != nil {
+		t.Fatalf("CreatePlace: %v", err)
+	}
+	return uint64(id)
+}
+
+func mustCreateCategory(t *testing.T, ctx context.Context, repo PlaceRepository, workspaceID uint64, name string) uint {
+	t.Helper()
+	id, err := repo.CreatePlaceCategory(ctx, workspaceID, workspaceID, name)
+	if err != nil {
+		t.Fatalf("CreatePlaceCategory: %v", err)
+	}
+	return uint(id)
+}
+
+func assertCategoryNames(t *testing.T, ctx context.Context, repo PlaceRepository, workspaceID, placeID uint64, want ...string) {
+	t.Helper()
+	byPlace, err := repo.ListCategoriesByPlaceIDs(ctx, workspaceID, []uint64{placeID})
+	if err != nil {
+		t.Fatalf("ListCategoriesByPlaceIDs: %v", err)
+	}
+	var got []string
+	for _, pc := range byPlace[placeID] {
+		got = append(got, pc.Name)
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("categories = %v, want %v", got, want)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -6,24 +6,80 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, h *Handler, authMW *middleware.AuthMiddleware) {
-	// Place routes - require authentication
-	places := r.Group("/places", authMW.RequireAuth())
+func RegisterRoutes(r *gin.Engine, h *Handler, authMW *middleware.AuthMiddleware, wsMW *middleware.WorkspaceMiddleware, rl *middleware.RateLimiter) {
+	// Routes require authentication; personal API keys need the places:read or places:write scope
+	read := authMW.RequireAuth(middleware.ScopePlacesRead)
+	write := authMW.RequireAuth(middleware.ScopePlacesWrite)
+
+	// Places and categories belong to the active workspace; viewers can only read
+	viewer := wsMW.Require(middleware.WorkspaceRoleViewer)
+	editor := wsMW.Require(middleware.WorkspaceRoleEditor)
+
+	// Place routes
+	placesRead := r.Group("/places", read, rl.Limit("api"), viewer)
+	{
+		placesRead.GET("", h.ListPlaces)
+		placesRead.GET("/:id", h.GetPlace)
+	}
+	placesWrite := r.Group("/places", write, rl.Limit("api"), editor)
+	{
+		placesWrite.POST("", authMW.RequireVerifiedEmail(), h.CreatePlace)
+		placesWrite.PATCH("/:id", h.UpdatePlace)
+		placesWrite.DELETE("/:id", h.DeletePlace)
+		placesWrite.PUT("/:id/categories", h.SetPlaceCategories)
+		placesWrite.POST("/:id/categories/:categoryId", h.AttachPlaceCategory)
+		placesWrite.DELETE("/:id/categories/:categoryId", h.DetachPlaceCategory)
+	}
+
+	// PlaceCategory routes
+	categoriesRead := r.Group("/place-categories", read, rl.Limit("api"), viewer)
+	{
+		categoriesRead.GET("", h.ListPlaceCategories)
+		categoriesRead.GET("/:id", h.GetPlaceCategory)
+	}
+	categoriesWrite := r.Group("/place-categories", write, rl.Limit("api"), editor)
+	{
+		categoriesWrite.POST("", h.CreatePlaceCategory)
+		categoriesWrite.PATCH("/:id", h.UpdatePlaceCategory)
+		categoriesWrite.DELETE("/:id", h.DeletePlaceCategory)
+	}
+
+	// Share link management; viewers can see the links but not create or revoke them
+	sharesRead := r.Group("/place-shares", read, rl.Limit("api"), viewer)
+	{
+		sharesRead.GET("", h.ListPlaceShares)
+	}
+	sharesWrite := r.Group("/place-shares", write, rl.Limit("api"), editor)
+	{
+		sharesWrite.POST("", h.CreatePlaceShare)
+		sharesWrite.DELETE("/:id", h.RevokePlaceShare)
+	}
+
+	// Public share links - no authentication, strict per-IP rate limit since
+	// links can be password protected
+	r.GET("/shared/:token", rl.Limit("shared"), h.ListSharedPlaces)
+
+	// Lookup routes - read-only, require authentication
+	statuses := r.Group("/place-statuses", read, rl.Limit("api"))
+	{
+		statuses.GET("", h.ListPlaceStatuses)
+		statuses.GET("/:id", h.GetPlaceStatus)
+	}
+
+	links := r.Group("/place-links", read, rl.Limit("api"))
 	{
-		places.POST("", h.CreatePlace)
-		places.GET("", h.ListPlaces)
-		places.GET("/:id", h.GetPlace)
-		places.PATCH("/:id", h.UpdatePlace)
-		places.DELETE("/:id", h.DeletePlace)
+		links.GET("", h.ListPlaceLinks)
+		links.GET("/:id", h.GetPlaceLink)
 	}
 
-	// PlaceCategory routes - require authentication
-	categories := r.Group("/place-categories", authMW.RequireAuth())
+	// Admin lookup management - require the admin role
+	admin := r.Group("/admin", authMW.RequireAuth(), authMW.RequireRole(middleware.RoleAdmin), rl.Limit("api"))
 	{
-		categories.POST("", h.CreatePlaceCategory)
-		categories.GET("", h.ListPlaceCategories)
-		categories.GET("/:id", h.GetPlaceCategory)
-		categories.PATCH("/:id", h.UpdatePlaceCategory)
-		categories.DELETE("/:id", h.DeletePlaceCategory)
+		admin.POST("/place-statuses", h.CreatePlaceStatus)
+		admin.PATCH("/place-statuses/:id", h.UpdatePlaceStatus)
+		admin.DELETE("/place-statuses/:id", h.DeletePlaceStatus)
+		admin.POST("/place-links", h.CreatePlaceLink)
+		admin.PATCH("/place-links/:id", h.UpdatePlaceLink)
+		admin.DELETE("/place-links/:id", h.DeletePlaceLink)
 	}
 }
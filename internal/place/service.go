@@ -3,116 +3,510 @@ package place
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"strings"
+	"time"
+
+	"go-saas-api/pkg/customtime"
 )
 
+const defaultListLimit = 50
+
 type Service struct {
-	repo *Repository
+	repo PlaceRepository
 }
 
-func NewService(repo *Repository) *Service {
+func NewService(repo PlaceRepository) *Service {
 	return &Service{repo: repo}
 }
 
 // Place Service Methods
 
-func (s *Service) CreatePlace(ctx context.Context, userID uint64, req CreatePlaceReq) (int64, error) {
+func (s *Service) CreatePlace(ctx context.Context, workspaceID, userID uint64, req CreatePlaceReq) (int64, error) {
 	// validate name
 	if req.Name == nil || *req.Name == "" {
-		return 0, errors.New("name is required")
+		return 0, ErrNameRequired
+	}
+	if req.Link != nil && req.LinkType == nil {
+		linkType, err := s.detectLinkType(ctx, *req.Link)
+		if err != nil {
+			return 0, err
+		}
+		req.LinkType = linkType
+	}
+	if err := s.validateLookups(ctx, req.Status, req.LinkType); err != nil {
+		return 0, err
 	}
-	return s.repo.CreatePlace(ctx, userID, req)
+	req.CategoryIDs = uniqueIDs(req.CategoryIDs)
+
+	id, err := s.repo.CreatePlace(ctx, workspaceID, userID, req)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrCategoryNotFound
+		}
+		return 0, err
+	}
+	return id, nil
 }
 
-func (s *Service) ListPlaces(ctx context.Context, userID uint64, limit int) ([]Place, error) {
-	return s.repo.ListPlaces(ctx, userID, limit)
+// ListPlaces returns one page of places and the cursor for the next page ("" when there is none)
+func (s *Service) ListPlaces(ctx context.Context, workspaceID uint64, query ListPlacesQuery) ([]Place, string, error) {
+	f := PlaceFilter{
+		Status:     query.Status,
+		LinkType:   query.LinkType,
+		CategoryID: query.CategoryID,
+		Name:       strings.TrimSpace(query.Q),
+		Sort:       query.Sort,
+		Order:      query.Order,
+		Limit:      query.Limit,
+	}
+	if f.Sort == "" {
+		f.Sort = "created_at"
+	}
+	if f.Order == "" {
+		f.Order = "desc"
+	}
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	if query.GoAtFrom != "" {
+		t, err := time.Parse(customtime.DateFormat, query.GoAtFrom)
+		if err != nil {
+			return nil, "", ErrInvalidDate
+		}
+		f.GoAtFrom = &t
+	}
+	if query.GoAtTo != "" {
+		t, err := time.Parse(customtime.DateFormat, query.GoAtTo)
+		if err != nil {
+			return nil, "", ErrInvalidDate
+		}
+		f.GoAtTo = &t
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		// A cursor is only meaningful for the ordering it was issued for
+		if cursor.Sort != f.Sort || cursor.Order != f.Order {
+			return nil, "", ErrInvalidCursor
+		}
+		f.After = cursor
+	}
+
+	// Fetch one extra row to know whether another page exists
+	limit := f.Limit
+	f.Limit = limit + 1
+	items, err := s.repo.ListPlaces(ctx, workspaceID, f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(items) > limit {
+		items = items[:limit]
+		nextCursor = encodeCursor(cursorFor(&items[limit-1], f.Sort, f.Order))
+	}
+
+	if err := s.loadRelations(ctx, workspaceID, items); err != nil {
+		return nil, "", err
+	}
+	return items, nextCursor, nil
 }
 
-func (s *Service) GetPlaceByID(ctx context.Context, id, userID uint64) (*Place, error) {
-	place, err := s.repo.GetPlaceByID(ctx, id, userID)
+func (s *Service) GetPlaceByID(ctx context.Context, id, workspaceID uint64) (*Place, error) {
+	place, err := s.repo.GetPlaceByID(ctx, id, workspaceID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("place not found")
+			return nil, ErrPlaceNotFound
 		}
 		return nil, err
 	}
-	return place, nil
+
+	items := []Place{*place}
+	if err := s.loadRelations(ctx, workspaceID, items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
 }
 
-func (s *Service) UpdatePlace(ctx context.Context, id, userID uint64, req UpdatePlaceReq) (bool, error) {
+func (s *Service) UpdatePlace(ctx context.Context, id, workspaceID uint64, req UpdatePlaceReq) (bool, error) {
 	if req.Name == nil && req.Link == nil && req.LinkType == nil &&
-		req.Description == nil && req.GoAt == nil && req.GoAtTime == nil && req.Status == nil {
-		return false, errors.New("no fields to update")
+		req.Description == nil && req.GoAt == nil && req.GoAtTime == nil && req.Status == nil &&
+		req.CategoryIDs == nil {
+		return false, ErrNoFieldsToUpdate
 	}
 
 	// Check if place exists
-	_, err := s.repo.GetPlaceByID(ctx, id, userID)
+	_, err := s.repo.GetPlaceByID(ctx, id, workspaceID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return false, errors.New("place not found")
+			return false, ErrPlaceNotFound
 		}
 		return false, err
 	}
 
-	return s.repo.UpdatePlace(ctx, id, userID, req)
+	// Re-detect the link type whenever the link changes
+	if req.Link != nil && req.LinkType == nil {
+		linkType, err := s.detectLinkType(ctx, *req.Link)
+		if err != nil {
+			return false, err
+		}
+		req.LinkType = linkType
+	}
+	if err := s.validateLookups(ctx, req.Status, req.LinkType); err != nil {
+		return false, err
+	}
+	if req.CategoryIDs != nil {
+		req.CategoryIDs = uniqueIDs(req.CategoryIDs)
+	}
+
+	updated, err := s.repo.UpdatePlace(ctx, id, workspaceID, req)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrCategoryNotFound
+		}
+		return false, err
+	}
+	return updated, nil
 }
 
-func (s *Service) DeletePlace(ctx context.Context, id, userID uint64) (bool, error) {
-	deleted, err := s.repo.DeletePlace(ctx, id, userID)
+func (s *Service) DeletePlace(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	deleted, err := s.repo.DeletePlace(ctx, id, workspaceID)
 	if err != nil {
 		return false, err
 	}
 	if !deleted {
-		return false, errors.New("place not found")
+		return false, ErrPlaceNotFound
 	}
 	return true, nil
 }
 
+// Place <-> Category Service Methods
+
+func (s *Service) SetPlaceCategories(ctx context.Context, placeID, workspaceID uint64, req SetPlaceCategoriesReq) error {
+	// Check if place exists and belongs to the workspace
+	_, err := s.repo.GetPlaceByID(ctx, placeID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPlaceNotFound
+		}
+		return err
+	}
+
+	err = s.repo.SetPlaceCategories(ctx, placeID, workspaceID, uniqueIDs(req.CategoryIDs))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) AttachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) error {
+	_, err := s.repo.GetPlaceByID(ctx, placeID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPlaceNotFound
+		}
+		return err
+	}
+
+	// Only categories of the same workspace can be linked
+	_, err = s.repo.GetPlaceCategoryByID(ctx, categoryID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+
+	return s.repo.AttachPlaceCategory(ctx, placeID, workspaceID, categoryID)
+}
+
+func (s *Service) DetachPlaceCategory(ctx context.Context, placeID, workspaceID uint64, categoryID uint) error {
+	_, err := s.repo.GetPlaceByID(ctx, placeID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPlaceNotFound
+		}
+		return err
+	}
+
+	detached, err := s.repo.DetachPlaceCategory(ctx, placeID, workspaceID, categoryID)
+	if err != nil {
+		return err
+	}
+	if !detached {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// loadRelations fills categories and lookup details of each place
+func (s *Service) loadRelations(ctx context.Context, workspaceID uint64, places []Place) error {
+	if err := s.loadCategories(ctx, workspaceID, places); err != nil {
+		return err
+	}
+	return s.loadLookups(ctx, places)
+}
+
+// loadCategories fills the Categories field of each place in a single query
+func (s *Service) loadCategories(ctx context.Context, workspaceID uint64, places []Place) error {
+	if len(places) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, len(places))
+	for i := range places {
+		ids[i] = places[i].ID
+	}
+
+	categories, err := s.repo.ListCategoriesByPlaceIDs(ctx, workspaceID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range places {
+		places[i].Categories = categories[places[i].ID]
+	}
+	return nil
+}
+
+// loadLookups resolves StatusInfo and LinkInfo; both lookup tables are small so they are read whole
+func (s *Service) loadLookups(ctx context.Context, places []Place) error {
+	if len(places) == 0 {
+		return nil
+	}
+
+	statuses, err := s.repo.ListPlaceStatuses(ctx)
+	if err != nil {
+		return err
+	}
+	links, err := s.repo.ListPlaceLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	statusByID := make(map[int]*PlaceStatus, len(statuses))
+	for i := range statuses {
+		statusByID[statuses[i].ID] = &statuses[i]
+	}
+	linkByID := make(map[int]*PlaceLink, len(links))
+	for i := range links {
+		linkByID[links[i].ID] = &links[i]
+	}
+
+	for i := range places {
+		if places[i].Status.Valid {
+			places[i].StatusInfo = statusByID[int(places[i].Status.Int32)]
+		}
+		if places[i].LinkType.Valid {
+			places[i].LinkInfo = linkByID[int(places[i].LinkType.Int32)]
+		}
+	}
+	return nil
+}
+
+// detectLinkType infers the place_link of a URL from its host
+func (s *Service) detectLinkType(ctx context.Context, link string) (*int, error) {
+	if strings.TrimSpace(link) == "" {
+		return nil, nil
+	}
+	links, err := s.repo.ListPlaceLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resolveLinkType(link, links), nil
+}
+
+// validateLookups checks that the given status / link type exist
+func (s *Service) validateLookups(ctx context.Context, status, linkType *int) error {
+	if status != nil {
+		if _, err := s.repo.GetPlaceStatusByID(ctx, *status); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInvalidStatus
+			}
+			return err
+		}
+	}
+	if linkType != nil {
+		if _, err := s.repo.GetPlaceLinkByID(ctx, *linkType); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInvalidLinkType
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup Service Methods
+
+func (s *Service) ListPlaceStatuses(ctx context.Context) ([]PlaceStatus, error) {
+	return s.repo.ListPlaceStatuses(ctx)
+}
+
+func (s *Service) GetPlaceStatusByID(ctx context.Context, id int) (*PlaceStatus, error) {
+	status, err := s.repo.GetPlaceStatusByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStatusNotFound
+		}
+		return nil, err
+	}
+	return status, nil
+}
+
+func (s *Service) ListPlaceLinks(ctx context.Context) ([]PlaceLink, error) {
+	return s.repo.ListPlaceLinks(ctx)
+}
+
+func (s *Service) GetPlaceLinkByID(ctx context.Context, id int) (*PlaceLink, error) {
+	link, err := s.repo.GetPlaceLinkByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLinkTypeNotFound
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+// Admin Lookup Service Methods
+
+func (s *Service) CreatePlaceStatus(ctx context.Context, req CreatePlaceStatusReq) (int, error) {
+	return s.repo.CreatePlaceStatus(ctx, strings.TrimSpace(req.Name), req.Background)
+}
+
+func (s *Service) UpdatePlaceStatus(ctx context.Context, id int, req UpdatePlaceStatusReq) error {
+	if req.Name == nil && req.Background == nil {
+		return ErrNoFieldsToUpdate
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+
+	updated, err := s.repo.UpdatePlaceStatus(ctx, id, req.Name, req.Background)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrStatusNotFound
+	}
+	return nil
+}
+
+func (s *Service) DeletePlaceStatus(ctx context.Context, id int) error {
+	deleted, err := s.repo.DeletePlaceStatus(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrStatusNotFound
+	}
+	return nil
+}
+
+func (s *Service) CreatePlaceLink(ctx context.Context, req CreatePlaceLinkReq) (int, error) {
+	return s.repo.CreatePlaceLink(ctx, normalizeHost(req.Domain), req.Icon)
+}
+
+func (s *Service) UpdatePlaceLink(ctx context.Context, id int, req UpdatePlaceLinkReq) error {
+	if req.Domain == nil && req.Icon == nil {
+		return ErrNoFieldsToUpdate
+	}
+	if req.Domain != nil {
+		domain := normalizeHost(*req.Domain)
+		req.Domain = &domain
+	}
+
+	updated, err := s.repo.UpdatePlaceLink(ctx, id, req.Domain, req.Icon)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrLinkTypeNotFound
+	}
+	return nil
+}
+
+func (s *Service) DeletePlaceLink(ctx context.Context, id int) error {
+	deleted, err := s.repo.DeletePlaceLink(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrLinkTypeNotFound
+	}
+	return nil
+}
+
+// uniqueIDs removes duplicate IDs while keeping their order
+func uniqueIDs(ids []uint) []uint {
+	if ids == nil {
+		return nil
+	}
+	seen := make(map[uint]struct{}, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
+}
+
 // PlaceCategory Service Methods
 
-func (s *Service) CreatePlaceCategory(ctx context.Context, userID uint64, req CreatePlaceCategoryReq) (int64, error) {
-	return s.repo.CreatePlaceCategory(ctx, userID, req.Name)
+func (s *Service) CreatePlaceCategory(ctx context.Context, workspaceID, userID uint64, req CreatePlaceCategoryReq) (int64, error) {
+	return s.repo.CreatePlaceCategory(ctx, workspaceID, userID, req.Name)
 }
 
-func (s *Service) ListPlaceCategories(ctx context.Context, userID uint64, limit int) ([]PlaceCategory, error) {
-	return s.repo.ListPlaceCategories(ctx, userID, limit)
+func (s *Service) ListPlaceCategories(ctx context.Context, workspaceID uint64, limit int) ([]PlaceCategory, error) {
+	return s.repo.ListPlaceCategories(ctx, workspaceID, limit)
 }
 
-func (s *Service) GetPlaceCategoryByID(ctx context.Context, id uint, userID uint64) (*PlaceCategory, error) {
-	category, err := s.repo.GetPlaceCategoryByID(ctx, id, userID)
+func (s *Service) GetPlaceCategoryByID(ctx context.Context, id uint, workspaceID uint64) (*PlaceCategory, error) {
+	category, err := s.repo.GetPlaceCategoryByID(ctx, id, workspaceID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("category not found")
+			return nil, ErrCategoryNotFound
 		}
 		return nil, err
 	}
 	return category, nil
 }
 
-func (s *Service) UpdatePlaceCategory(ctx context.Context, id uint, userID uint64, req UpdatePlaceCategoryReq) (bool, error) {
+func (s *Service) UpdatePlaceCategory(ctx context.Context, id uint, workspaceID uint64, req UpdatePlaceCategoryReq) (bool, error) {
 	if req.Name == nil {
-		return false, errors.New("no fields to update")
+		return false, ErrNoFieldsToUpdate
 	}
 
-	// Check if category exists and belongs to user
-	_, err := s.repo.GetPlaceCategoryByID(ctx, id, userID)
+	// Check if category exists and belongs to the workspace
+	_, err := s.repo.GetPlaceCategoryByID(ctx, id, workspaceID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return false, errors.New("category not found")
+			return false, ErrCategoryNotFound
 		}
 		return false, err
 	}
 
-	return s.repo.UpdatePlaceCategory(ctx, id, userID, req.Name)
+	return s.repo.UpdatePlaceCategory(ctx, id, workspaceID, req.Name)
 }
 
-func (s *Service) DeletePlaceCategory(ctx context.Context, id uint, userID uint64) (bool, error) {
-	deleted, err := s.repo.DeletePlaceCategory(ctx, id, userID)
+func (s *Service) DeletePlaceCategory(ctx context.Context, id uint, workspaceID uint64) (bool, error) {
+	deleted, err := s.repo.DeletePlaceCategory(ctx, id, workspaceID)
 	if err != nil {
 		return false, err
 	}
 	if !deleted {
-		return false, errors.New("category not found")
+		return false, ErrCategoryNotFound
 	}
 	return true, nil
 }
@@ -0,0 +1,284 @@
+package place
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"go-saas-api/pkg/token"
+)
+
+func TestResolveLinkType(t *testing.T) {
+	links := NewMemoryRepository().linkTypes // maps=1, instagram=2, tiktok=3, other=4
+
+	tests := []struct {
+		url  string
+		want int // 0 = nil
+	}{
+		{"https://maps.google.com/?q=monas", 1},
+		{"https://www.google.com/maps/place/Monas", 1},
+		{"https://maps.app.goo.gl/abc123", 1},
+		{"goo.gl/maps/abc123", 1},
+		{"https://www.instagram.com/p/xyz/", 2},
+		{"http://instagr.am/p/xyz", 2},
+		{"https://m.instagram.com/p/xyz", 2},
+		{"https://vm.tiktok.com/ZSabc/", 3},
+		{"HTTPS://WWW.TIKTOK.COM/@someone", 3},
+		{"https://example.com/page", 4},
+		{"https://goo.gl/other", 4},
+		{"https://notinstagram.com/", 4},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got := resolveLinkType(tt.url, links)
+			switch {
+			case tt.want == 0 && got != nil:
+				t.Errorf("got %d, want nil", *got)
+			case tt.want != 0 && (got == nil || *got != tt.want):
+				t.Errorf("got %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceCreatePlace(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewMemoryRepository())
+	const workspaceID, userID = 1, 1
+
+	foreign, _ := svc.CreatePlaceCategory(ctx, 2, 2, CreatePlaceCategoryReq{Name: "theirs"})
+
+	tests := []struct {
+		name    string
+		req     CreatePlaceReq
+		wantErr error
+	}{
+		{"detects link type", CreatePlaceReq{Name: strPtr("cafe"), Link: strPtr("https://instagram.com/p/1")}, nil},
+		{"missing name", CreatePlaceReq{Name: strPtr("")}, ErrNameRequired},
+		{"unknown status", CreatePlaceReq{Name: strPtr("cafe"), Status: intPtr(99)}, ErrInvalidStatus},
+		{"unknown link type", CreatePlaceReq{Name: strPtr("cafe"), LinkType: intPtr(99)}, ErrInvalidLinkType},
+		{"foreign category", CreatePlaceReq{Name: strPtr("cafe"), CategoryIDs: []uint{uint(foreign)}}, ErrCategoryNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := svc.CreatePlace(ctx, workspaceID, userID, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreatePlace err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			p, err := svc.GetPlaceByID(ctx, uint64(id), workspaceID)
+			if err != nil {
+				t.Fatalf("GetPlaceByID: %v", err)
+			}
+			if p.LinkInfo == nil || p.LinkInfo.Domain != "instagram.com" {
+				t.Errorf("LinkInfo = %+v, want instagram.com", p.LinkInfo)
+			}
+		})
+	}
+}
+
+func TestServiceListPlacesPagination(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewMemoryRepository())
+	const workspaceID, userID = 1, 1
+
+	for _, name := range []string{"e", "c", "a", "d", "b"} {
+		if _, err := svc.CreatePlace(ctx, workspaceID, userID, CreatePlaceReq{Name: strPtr(name)}); err != nil {
+			t.Fatalf("CreatePlace: %v", err)
+		}
+	}
+
+	var got []string
+	query := ListPlacesQuery{Sort: "name", Order: "asc", Limit: 2}
+	for page := 0; ; page++ {
+		if page > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		items, next, err := svc.ListPlaces(ctx, workspaceID, query)
+		if err != nil {
+			t.Fatalf("ListPlaces: %v", err)
+		}
+		for _, p := range items {
+			got = append(got, p.Name.String)
+		}
+		if next == "" {
+			break
+		}
+		query.Cursor = next
+	}
+
+	if want := []string{"a", "b", "c", "d", "e"}; !equalStrings(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// A cursor cannot be reused with a different ordering
+	_, next, _ := svc.ListPlaces(ctx, workspaceID, ListPlacesQuery{Sort: "name", Order: "asc", Limit: 1})
+	_, _, err := svc.ListPlaces(ctx, workspaceID, ListPlacesQuery{Sort: "name", Order: "desc", Cursor: next})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("mismatched cursor err = %v, want ErrInvalidCursor", err)
+	}
+
+	// Nor can a well-formed cursor carry a value its sort column cannot hold
+	tampered := []placeCursor{
+		{Sort: "go_at", Order: "desc", Value: "x", ID: 1},
+		{Sort: "created_at", Order: "desc", Value: "2026-05-01", ID: 1},
+	}
+	for _, c := range tampered {
+		_, _, err := svc.ListPlaces(ctx, workspaceID, ListPlacesQuery{Sort: c.Sort, Order: c.Order, Cursor: encodeCursor(c)})
+		if !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("cursor %+v err = %v, want ErrInvalidCursor", c, err)
+		}
+	}
+}
+
+func TestServiceAttachPlaceCategoryOwnership(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewMemoryRepository())
+
+	placeID, _ := svc.CreatePlace(ctx, 1, 1, CreatePlaceReq{Name: strPtr("cafe")})
+	own, _ := svc.CreatePlaceCategory(ctx, 1, 1, CreatePlaceCategoryReq{Name: "mine"})
+	foreign, _ := svc.CreatePlaceCategory(ctx, 2, 2, CreatePlaceCategoryReq{Name: "theirs"})
+
+	tests := []struct {
+		name        string
+		workspaceID uint64
+		categoryID  uint
+		wantErr     error
+	}{
+		{"own category", 1, uint(own), nil},
+		{"another workspace's category", 1, uint(foreign), ErrCategoryNotFound},
+		{"another workspace's place", 2, uint(foreign), ErrPlaceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.AttachPlaceCategory(ctx, uint64(placeID), tt.workspaceID, tt.categoryID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServicePlaceShares(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	svc := NewService(repo)
+	const workspaceID, userID = 1, 1
+
+	food, _ := svc.CreatePlaceCategory(ctx, workspaceID, userID, CreatePlaceCategoryReq{Name: "food"})
+	drinks, _ := svc.CreatePlaceCategory(ctx, workspaceID, userID, CreatePlaceCategoryReq{Name: "drinks"})
+	foreign, _ := svc.CreatePlaceCategory(ctx, 2, 2, CreatePlaceCategoryReq{Name: "theirs"})
+	for _, name := range []string{"b-cafe", "a-cafe", "museum"} {
+		req := CreatePlaceReq{Name: strPtr(name)}
+		switch name {
+		case "b-cafe":
+			req.CategoryIDs = []uint{uint(food), uint(drinks)}
+		case "a-cafe":
+			req.CategoryIDs = []uint{uint(food)}
+		}
+		if _, err := svc.CreatePlace(ctx, workspaceID, userID, req); err != nil {
+			t.Fatalf("CreatePlace: %v", err)
+		}
+	}
+
+	categoryID := uint(food)
+	open, err := svc.CreatePlaceShare(ctx, workspaceID, userID, CreatePlaceShareReq{Name: "Cafes", CategoryID: &categoryID, Sort: "name", Order: "asc"})
+	if err != nil {
+		t.Fatalf("CreatePlaceShare: %v", err)
+	}
+	if open.Token == "" || open.TokenPrefix != open.Token[:shareTokenPrefixLen] || open.HasPassword || open.ExpiresAt != nil {
+		t.Errorf("created share = %+v", open)
+	}
+
+	t.Run("lists the filtered places", func(t *testing.T) {
+		share, items, next, err := svc.ListSharedPlaces(ctx, open.Token, "", SharedPlacesQuery{Limit: 1})
+		if err != nil {
+			t.Fatalf("ListSharedPlaces: %v", err)
+		}
+		if share.Name != "Cafes" || len(items) != 1 || items[0].Name.String != "a-cafe" || next == "" {
+			t.Fatalf("first page = %q, %+v, next %q", share.Name, items, next)
+		}
+		_, items, next, _ = svc.ListSharedPlaces(ctx, open.Token, "", SharedPlacesQuery{Limit: 1, Cursor: next})
+		if len(items) != 1 || items[0].Name.String != "b-cafe" {
+			t.Errorf("second page = %+v", items)
+		}
+
+		// b-cafe is also in drinks, which the share does not reveal
+		resp := toSharedPlaceResponse(share, &items[0])
+		if len(resp.Categories) != 1 || resp.Categories[0].Name != "food" {
+			t.Errorf("categories = %+v, want only the shared one", resp.Categories)
+		}
+	})
+
+	t.Run("validates the filter", func(t *testing.T) {
+		foreignID := uint(foreign)
+		tests := []struct {
+			name    string
+			req     CreatePlaceShareReq
+			wantErr error
+		}{
+			{"another workspace's category", CreatePlaceShareReq{CategoryID: &foreignID}, ErrCategoryNotFound},
+			{"unknown status", CreatePlaceShareReq{Status: intPtr(99)}, ErrInvalidStatus},
+			{"unknown link type", CreatePlaceShareReq{LinkType: intPtr(99)}, ErrInvalidLinkType},
+			{"go_at range ends before it starts", CreatePlaceShareReq{GoAtFrom: "2026-05-02", GoAtTo: "2026-05-01"}, ErrInvalidDate},
+		}
+		for _, tt := range tests {
+			if _, err := svc.CreatePlaceShare(ctx, workspaceID, userID, tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		}
+	})
+
+	t.Run("password protected", func(t *testing.T) {
+		protected, err := svc.CreatePlaceShare(ctx, workspaceID, userID, CreatePlaceShareReq{Password: strPtr("s3cret")})
+		if err != nil {
+			t.Fatalf("CreatePlaceShare: %v", err)
+		}
+		if !protected.HasPassword {
+			t.Error("has_password = false")
+		}
+
+		if _, _, _, err := svc.ListSharedPlaces(ctx, protected.Token, "", SharedPlacesQuery{}); !errors.Is(err, ErrPasswordRequired) {
+			t.Errorf("no password err = %v, want ErrPasswordRequired", err)
+		}
+		if _, _, _, err := svc.ListSharedPlaces(ctx, protected.Token, "wrong", SharedPlacesQuery{}); !errors.Is(err, ErrInvalidPassword) {
+			t.Errorf("wrong password err = %v, want ErrInvalidPassword", err)
+		}
+		if _, items, _, err := svc.ListSharedPlaces(ctx, protected.Token, "s3cret", SharedPlacesQuery{}); err != nil || len(items) != 3 {
+			t.Errorf("right password = %d items, %v; want 3", len(items), err)
+		}
+	})
+
+	t.Run("expired, unknown and revoked links are not found", func(t *testing.T) {
+		expired := &PlaceShare{WorkspaceID: workspaceID, TokenPrefix: "expired-", TokenHash: token.Hash("expired-token"),
+			Sort: "created_at", Order: "desc", ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}}
+		if err := repo.CreatePlaceShare(ctx, expired); err != nil {
+			t.Fatalf("CreatePlaceShare: %v", err)
+		}
+		if _, _, _, err := svc.ListSharedPlaces(ctx, "expired-token", "", SharedPlacesQuery{}); !errors.Is(err, ErrShareNotFound) {
+			t.Errorf("expired err = %v, want ErrShareNotFound", err)
+		}
+		if _, _, _, err := svc.ListSharedPlaces(ctx, "unknown", "", SharedPlacesQuery{}); !errors.Is(err, ErrShareNotFound) {
+			t.Errorf("unknown err = %v, want ErrShareNotFound", err)
+		}
+
+		if err := svc.RevokePlaceShare(ctx, open.ID, 2); !errors.Is(err, ErrShareNotFound) {
+			t.Errorf("revoke from another workspace err = %v, want ErrShareNotFound", err)
+		}
+		if err := svc.RevokePlaceShare(ctx, open.ID, workspaceID); err != nil {
+			t.Fatalf("RevokePlaceShare: %v", err)
+		}
+		if _, _, _, err := svc.ListSharedPlaces(ctx, open.Token, "", SharedPlacesQuery{}); !errors.Is(err, ErrShareNotFound) {
+			t.Errorf("revoked err = %v, want ErrShareNotFound", err)
+		}
+	})
+}
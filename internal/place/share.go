@@ -0,0 +1,244 @@
+package place
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go-saas-api/pkg/customtime"
+	"go-saas-api/pkg/token"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	shareTokenBytes     = 24
+	shareTokenPrefixLen = 8 // shown in listings to tell links apart
+)
+
+// SharePasswordHeader carries the password of a protected share link. A header
+// keeps the password out of URLs and access logs.
+const SharePasswordHeader = "X-Share-Password"
+
+// CreatePlaceShare creates a public link to the workspace's places matching the
+// request's filter. The token is returned only here; just its hash is stored.
+func (s *Service) CreatePlaceShare(ctx context.Context, workspaceID, userID uint64, req CreatePlaceShareReq) (*CreatedPlaceShareResponse, error) {
+	share := &PlaceShare{
+		WorkspaceID: workspaceID,
+		UserID:      creatorID(userID),
+		Name:        strings.TrimSpace(req.Name),
+		Q:           strings.TrimSpace(req.Q),
+		Sort:        req.Sort,
+		Order:       req.Order,
+	}
+	if share.Sort == "" {
+		share.Sort = "created_at"
+	}
+	if share.Order == "" {
+		share.Order = "desc"
+	}
+
+	if req.CategoryID != nil {
+		if _, err := s.GetPlaceCategoryByID(ctx, *req.CategoryID, workspaceID); err != nil {
+			return nil, err
+		}
+		share.CategoryID = sql.NullInt64{Int64: int64(*req.CategoryID), Valid: true}
+	}
+	if err := s.validateLookups(ctx, req.Status, req.LinkType); err != nil {
+		return nil, err
+	}
+	if req.Status != nil {
+		share.Status = sql.NullInt32{Int32: int32(*req.Status), Valid: true}
+	}
+	if req.LinkType != nil {
+		share.LinkType = sql.NullInt32{Int32: int32(*req.LinkType), Valid: true}
+	}
+
+	for _, d := range []struct {
+		value string
+		dst   *sql.NullTime
+	}{{req.GoAtFrom, &share.GoAtFrom}, {req.GoAtTo, &share.GoAtTo}} {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse(customtime.DateFormat, d.value)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		*d.dst = sql.NullTime{Time: t, Valid: true}
+	}
+	if share.GoAtFrom.Valid && share.GoAtTo.Valid && share.GoAtFrom.Time.After(share.GoAtTo.Time) {
+		return nil, ErrInvalidDate
+	}
+
+	if req.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		share.PasswordHash = sql.NullString{String: string(hash), Valid: true}
+	}
+	if req.ExpiresInDays != nil {
+		share.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Duration(*req.ExpiresInDays) * 24 * time.Hour), Valid: true}
+	}
+
+	raw, err := token.Random(shareTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	share.TokenPrefix = raw[:shareTokenPrefixLen]
+	share.TokenHash = token.Hash(raw)
+
+	if err := s.repo.CreatePlaceShare(ctx, share); err != nil {
+		return nil, err
+	}
+	return &CreatedPlaceShareResponse{PlaceShareResponse: toPlaceShareResponse(share), Token: raw}, nil
+}
+
+// ListPlaceShares returns the workspace's share links, including expired ones
+func (s *Service) ListPlaceShares(ctx context.Context, workspaceID uint64) ([]PlaceShareResponse, error) {
+	shares, err := s.repo.ListPlaceShares(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]PlaceShareResponse, len(shares))
+	for i := range shares {
+		items[i] = toPlaceShareResponse(&shares[i])
+	}
+	return items, nil
+}
+
+// RevokePlaceShare deletes a share link so its token stops working
+func (s *Service) RevokePlaceShare(ctx context.Context, id, workspaceID uint64) error {
+	ok, err := s.repo.DeletePlaceShare(ctx, id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+// ListSharedPlaces returns one page of the places behind a share link. Unknown,
+// revoked and expired links are all reported as ErrShareNotFound.
+func (s *Service) ListSharedPlaces(ctx context.Context, raw, password string, query SharedPlacesQuery) (*PlaceShare, []Place, string, error) {
+	share, err := s.repo.GetPlaceShareByHash(ctx, token.Hash(raw))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, "", ErrShareNotFound
+		}
+		return nil, nil, "", err
+	}
+	if share.ExpiresAt.Valid && !share.ExpiresAt.Time.After(time.Now()) {
+		return nil, nil, "", ErrShareNotFound
+	}
+
+	if share.PasswordHash.Valid {
+		if password == "" {
+			return nil, nil, "", ErrPasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(share.PasswordHash.String), []byte(password)) != nil {
+			return nil, nil, "", ErrInvalidPassword
+		}
+	}
+
+	q := ListPlacesQuery{
+		Q:      share.Q,
+		Sort:   share.Sort,
+		Order:  share.Order,
+		Limit:  query.Limit,
+		Cursor: query.Cursor,
+	}
+	if share.CategoryID.Valid {
+		categoryID := uint(share.CategoryID.Int64)
+		q.CategoryID = &categoryID
+	}
+	if share.Status.Valid {
+		status := int(share.Status.Int32)
+		q.Status = &status
+	}
+	if share.LinkType.Valid {
+		linkType := int(share.LinkType.Int32)
+		q.LinkType = &linkType
+	}
+	if share.GoAtFrom.Valid {
+		q.GoAtFrom = share.GoAtFrom.Time.Format(customtime.DateFormat)
+	}
+	if share.GoAtTo.Valid {
+		q.GoAtTo = share.GoAtTo.Time.Format(customtime.DateFormat)
+	}
+
+	items, nextCursor, err := s.ListPlaces(ctx, share.WorkspaceID, q)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return share, items, nextCursor, nil
+}
+
+func toPlaceShareResponse(ps *PlaceShare) PlaceShareResponse {
+	resp := PlaceShareResponse{
+		ID:          ps.ID,
+		Name:        ps.Name,
+		TokenPrefix: ps.TokenPrefix,
+		CategoryID:  nullableID(ps.CategoryID),
+		Q:           ps.Q,
+		Sort:        ps.Sort,
+		Order:       ps.Order,
+		HasPassword: ps.PasswordHash.Valid,
+		UserID:      nullableID(ps.UserID),
+		CreatedAt:   ps.CreatedAt.Format(time.RFC3339),
+	}
+	if ps.Status.Valid {
+		status := int(ps.Status.Int32)
+		resp.Status = &status
+	}
+	if ps.LinkType.Valid {
+		linkType := int(ps.LinkType.Int32)
+		resp.LinkType = &linkType
+	}
+	if ps.GoAtFrom.Valid {
+		d := ps.GoAtFrom.Time.Format(customtime.DateFormat)
+		resp.GoAtFrom = &d
+	}
+	if ps.GoAtTo.Valid {
+		d := ps.GoAtTo.Time.Format(customtime.DateFormat)
+		resp.GoAtTo = &d
+	}
+	if ps.ExpiresAt.Valid {
+		t := ps.ExpiresAt.Time.Format(time.RFC3339)
+		resp.ExpiresAt = &t
+	}
+	return resp
+}
+
+// toSharedPlaceResponse converts a place for a public share link, leaving out
+// the workspace and creator. A share filtered by category shows only that one.
+func toSharedPlaceResponse(share *PlaceShare, p *Place) SharedPlaceResponse {
+	full := ToPlaceResponse(p)
+	resp := SharedPlaceResponse{
+		ID:          full.ID,
+		Name:        full.Name,
+		Link:        full.Link,
+		LinkType:    full.LinkType,
+		Description: full.Description,
+		GoAt:        full.GoAt,
+		GoAtTime:    full.GoAtTime,
+		Status:      full.Status,
+		StatusInfo:  full.StatusInfo,
+		LinkInfo:    full.LinkInfo,
+		CreatedAt:   full.CreatedAt,
+		UpdatedAt:   full.UpdatedAt,
+	}
+
+	resp.Categories = make([]SharedCategoryResponse, 0, len(p.Categories))
+	for _, c := range p.Categories {
+		if share.CategoryID.Valid && int64(c.ID) != share.CategoryID.Int64 {
+			continue
+		}
+		resp.Categories = append(resp.Categories, SharedCategoryResponse{ID: c.ID, Name: c.Name})
+	}
+	return resp
+}
@@ -0,0 +1,58 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Hook is a named cleanup function run on shutdown
+type Hook struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Registry collects shutdown hooks from modules.
+// Hooks run in reverse registration order (like defer), so resources
+// registered early (e.g. the database) are closed after their users.
+type Registry struct {
+	mu    sync.Mutex
+	hooks []Hook
+	done  bool
+}
+
+func New() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(name string, fn func(ctx context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, Hook{Name: name, Fn: fn})
+}
+
+// Shutdown runs every hook once, even if earlier ones fail or ctx expires,
+// and returns all errors joined together.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if r.done {
+		r.mu.Unlock()
+		return nil
+	}
+	r.done = true
+	hooks := r.hooks
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := h.Fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.Name, err))
+			continue
+		}
+		log.Printf("🛑 %s stopped", h.Name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package testutil
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-saas-api/db/migrations"
+	"go-saas-api/internal/database"
+	"go-saas-api/internal/migrate"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresDB connects to the database in TEST_DB_DSN, applies all migrations and
+// empties every table except the seeded lookups. The test is skipped when
+// TEST_DB_DSN is not set. Never point it at a database holding real data.
+func PostgresDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN not set, skipping Postgres backend")
+	}
+
+	db, err := database.NewConnection(dsn)
+	if err != nil {
+		t.Fatalf("connect test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	var tables []string
+	err = db.SelectContext(ctx, &tables,
+		`SELECT tablename FROM pg_tables
+		WHERE schemaname = current_schema()
+		AND tablename NOT IN ('schema_migrations', 'place_status', 'place_link')`,
+	)
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	if len(tables) > 0 {
+		if _, err := db.ExecContext(ctx, `TRUNCATE `+strings.Join(tables, ", ")+` RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatalf("truncate tables: %v", err)
+		}
+	}
+
+	return db
+}
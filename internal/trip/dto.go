@@ -0,0 +1,81 @@
+package trip
+
+import (
+	"go-saas-api/pkg/customtime"
+)
+
+// Trip DTOs
+
+type CreateTripReq struct {
+	Name        string           `json:"name" validate:"required,min=1,max=120"`
+	Description *string          `json:"description" validate:"omitempty"`
+	StartDate   *customtime.Date `json:"start_date" validate:"required"`
+	EndDate     *customtime.Date `json:"end_date" validate:"required"` // inclusive
+}
+
+type UpdateTripReq struct {
+	Name        *string          `json:"name" validate:"omitempty,min=1,max=120"`
+	Description *string          `json:"description" validate:"omitempty"`
+	StartDate   *customtime.Date `json:"start_date" validate:"omitempty"`
+	EndDate     *customtime.Date `json:"end_date" validate:"omitempty"`
+}
+
+type TripResponse struct {
+	ID          uint64          `json:"id"`
+	WorkspaceID uint64          `json:"workspace_id"`
+	UserID      *uint64         `json:"user_id"` // creator
+	Name        string          `json:"name"`
+	Description *string         `json:"description"`
+	StartDate   customtime.Date `json:"start_date"`
+	EndDate     customtime.Date `json:"end_date"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+// TripDetailResponse is a trip with its itinerary, one entry per day of the trip
+type TripDetailResponse struct {
+	TripResponse
+	Days []DayResponse `json:"days"`
+}
+
+type DayResponse struct {
+	Date  customtime.Date `json:"date"`
+	Items []ItemResponse  `json:"items"`
+}
+
+// Item DTOs
+
+type CreateItemReq struct {
+	PlaceID uint64               `json:"place_id" validate:"required,min=1"`
+	Day     *customtime.Date     `json:"day" validate:"omitempty"`      // defaults to the place's go_at
+	StartAt *customtime.DateTime `json:"start_at" validate:"omitempty"` // defaults to the place's go_at_time when it falls on day
+	EndAt   *customtime.DateTime `json:"end_at" validate:"omitempty"`
+	Note    *string              `json:"note" validate:"omitempty,max=1000"`
+}
+
+type UpdateItemReq struct {
+	StartAt *customtime.DateTime `json:"start_at" validate:"omitempty"`
+	EndAt   *customtime.DateTime `json:"end_at" validate:"omitempty"`
+	Note    *string              `json:"note" validate:"omitempty,max=1000"`
+}
+
+// MoveItemReq moves an item to another day, keeping its clock times
+type MoveItemReq struct {
+	Day      *customtime.Date `json:"day" validate:"required"`
+	Position *int             `json:"position" validate:"omitempty,min=0"` // defaults to the end of the day
+}
+
+// ReorderDayReq lists every item of a day in its new order
+type ReorderDayReq struct {
+	ItemIDs []uint64 `json:"item_ids" validate:"required,dive,min=1"`
+}
+
+type ItemResponse struct {
+	ID       uint64               `json:"id"`
+	PlaceID  uint64               `json:"place_id"`
+	Day      customtime.Date      `json:"day"`
+	Position int                  `json:"position"`
+	StartAt  *customtime.DateTime `json:"start_at"`
+	EndAt    *customtime.DateTime `json:"end_at"`
+	Note     *string              `json:"note"`
+}
@@ -0,0 +1,18 @@
+package trip
+
+import "go-saas-api/pkg/apperror"
+
+var (
+	ErrTripNotFound      = apperror.New(apperror.KindNotFound, "trip_not_found", "trip not found")
+	ErrItemNotFound      = apperror.New(apperror.KindNotFound, "trip_item_not_found", "trip item not found")
+	ErrNoFieldsToUpdate  = apperror.New(apperror.KindInvalid, "no_fields_to_update", "no fields to update")
+	ErrInvalidDateRange  = apperror.New(apperror.KindInvalid, "invalid_date_range", "end_date must not be before start_date")
+	ErrTripTooLong       = apperror.New(apperror.KindInvalid, "trip_too_long", "a trip can last at most 90 days")
+	ErrDayRequired       = apperror.New(apperror.KindInvalid, "day_required", "day is required when the place has no go_at")
+	ErrDayOutsideTrip    = apperror.New(apperror.KindInvalid, "day_outside_trip", "day is outside the trip's dates")
+	ErrInvalidDay        = apperror.New(apperror.KindInvalid, "invalid_day", "invalid day, expected YYYY-MM-DD")
+	ErrInvalidTimes      = apperror.New(apperror.KindInvalid, "invalid_times", "start_at must be on the item's day and end_at after start_at")
+	ErrInvalidOrder      = apperror.New(apperror.KindInvalid, "invalid_order", "item_ids must list every item of the day exactly once")
+	ErrItemsOutsideRange = apperror.New(apperror.KindConflict, "items_outside_range", "some items are planned outside the new dates")
+	ErrTimeConflict      = apperror.New(apperror.KindConflict, "time_conflict", "the item overlaps other items of the trip")
+)
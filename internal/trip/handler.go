@@ -0,0 +1,362 @@
+package trip
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-saas-api/pkg/apperror"
+	"go-saas-api/pkg/customtime"
+	"go-saas-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	service *Service
+	v       *validator.Validate
+}
+
+func NewHandler(service *Service, v *validator.Validate) *Handler {
+	return &Handler{
+		service: service,
+		v:       v,
+	}
+}
+
+// Trip Handlers
+
+// POST /trips
+func (h *Handler) CreateTrip(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req CreateTripReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	resp, err := h.service.CreateTrip(ctx, workspaceID.(uint64), userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, resp)
+}
+
+// GET /trips
+func (h *Handler) ListTrips(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListTrips(ctx, workspaceID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
+
+// GET /trips/:id
+func (h *Handler) GetTrip(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	resp, err := h.service.GetTrip(ctx, id, workspaceID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// PATCH /trips/:id
+func (h *Handler) UpdateTrip(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req UpdateTripReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	resp, err := h.service.UpdateTrip(ctx, id, workspaceID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// DELETE /trips/:id
+func (h *Handler) DeleteTrip(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteTrip(ctx, id, workspaceID.(uint64)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "trip deleted successfully"})
+}
+
+// Itinerary Handlers
+
+// POST /trips/:id/items
+func (h *Handler) AddItem(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	tripID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req CreateItemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	resp, err := h.service.AddItem(ctx, tripID, workspaceID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, resp)
+}
+
+// PATCH /trips/:id/items/:itemId
+func (h *Handler) UpdateItem(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	tripID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req UpdateItemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	resp, err := h.service.UpdateItem(ctx, tripID, itemID, workspaceID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// DELETE /trips/:id/items/:itemId
+func (h *Handler) DeleteItem(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	tripID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteItem(ctx, tripID, itemID, workspaceID.(uint64)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "trip item deleted successfully"})
+}
+
+// POST /trips/:id/items/:itemId/move
+func (h *Handler) MoveItem(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	tripID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req MoveItemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	resp, err := h.service.MoveItem(ctx, tripID, itemID, workspaceID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, resp)
+}
+
+// PUT /trips/:id/days/:day/order
+func (h *Handler) ReorderDay(c *gin.Context) {
+	workspaceID, exists := c.Get("workspaceID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	tripID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	day, err := time.Parse(customtime.DateFormat, c.Param("day"))
+	if err != nil {
+		c.Error(ErrInvalidDay)
+		return
+	}
+
+	var req ReorderDayReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ReorderDay(ctx, tripID, workspaceID.(uint64), day, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
@@ -0,0 +1,293 @@
+package trip
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-saas-api/internal/place"
+)
+
+// AddItem plans a place on a day of the trip, after the items already there.
+// Without a day the place's go_at is used, and without times its go_at_time
+// when that falls on the chosen day.
+func (s *Service) AddItem(ctx context.Context, tripID, workspaceID uint64, req CreateItemReq) (*ItemResponse, error) {
+	t, err := s.trip(ctx, tripID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.places.GetPlaceByID(ctx, req.PlaceID, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, place.ErrPlaceNotFound
+		}
+		return nil, err
+	}
+
+	item := &Item{TripID: t.ID, PlaceID: p.ID, Note: nullString(req.Note)}
+	switch {
+	case req.Day != nil:
+		item.Day = dateOf(req.Day.Time)
+	case p.GoAt.Valid:
+		item.Day = dateOf(p.GoAt.Time)
+	default:
+		return nil, ErrDayRequired
+	}
+	if req.StartAt != nil {
+		item.StartAt = sql.NullTime{Time: wallClock(req.StartAt.Time), Valid: true}
+	} else if req.EndAt == nil && p.GoAtTime.Valid && dateOf(p.GoAtTime.Time).Equal(item.Day) {
+		item.StartAt = sql.NullTime{Time: wallClock(p.GoAtTime.Time), Valid: true}
+	}
+	if req.EndAt != nil {
+		item.EndAt = sql.NullTime{Time: wallClock(req.EndAt.Time), Valid: true}
+	}
+
+	err = s.editItinerary(ctx, t.ID, func(t *Trip, items []Item) (*ItineraryEdit, error) {
+		if !t.contains(item.Day) {
+			return nil, ErrDayOutsideTrip
+		}
+		if err := checkSchedule(item, items); err != nil {
+			return nil, err
+		}
+		day := itemsOn(items, item.Day)
+		if len(day) > 0 {
+			item.Position = day[len(day)-1].Position + 1
+		}
+		return &ItineraryEdit{Create: item}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := toItemResponse(item)
+	return &resp, nil
+}
+
+// UpdateItem changes an item's times or note. Changing only start_at keeps the
+// item's duration.
+func (s *Service) UpdateItem(ctx context.Context, tripID, itemID, workspaceID uint64, req UpdateItemReq) (*ItemResponse, error) {
+	if req.StartAt == nil && req.EndAt == nil && req.Note == nil {
+		return nil, ErrNoFieldsToUpdate
+	}
+	if _, err := s.trip(ctx, tripID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	var item Item
+	err := s.editItinerary(ctx, tripID, func(_ *Trip, items []Item) (*ItineraryEdit, error) {
+		idx := indexOf(items, itemID)
+		if idx < 0 {
+			return nil, ErrItemNotFound
+		}
+		item = items[idx]
+
+		if req.StartAt != nil {
+			start := wallClock(req.StartAt.Time)
+			if req.EndAt == nil && item.StartAt.Valid && item.EndAt.Valid {
+				item.EndAt.Time = start.Add(item.EndAt.Time.Sub(item.StartAt.Time))
+			}
+			item.StartAt = sql.NullTime{Time: start, Valid: true}
+		}
+		if req.EndAt != nil {
+			item.EndAt = sql.NullTime{Time: wallClock(req.EndAt.Time), Valid: true}
+		}
+		if req.Note != nil {
+			item.Note = nullString(req.Note)
+		}
+		if err := checkSchedule(&item, items); err != nil {
+			return nil, err
+		}
+		return &ItineraryEdit{Update: &item}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := toItemResponse(&item)
+	return &resp, nil
+}
+
+func (s *Service) DeleteItem(ctx context.Context, tripID, itemID, workspaceID uint64) error {
+	if _, err := s.trip(ctx, tripID, workspaceID); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.DeleteItem(ctx, itemID, tripID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// MoveItem moves an item to a position on another day (or the same one). Its
+// times move with it, keeping their time of day.
+func (s *Service) MoveItem(ctx context.Context, tripID, itemID, workspaceID uint64, req MoveItemReq) (*ItemResponse, error) {
+	t, err := s.trip(ctx, tripID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	day := dateOf(req.Day.Time)
+
+	var moved Item
+	err = s.editItinerary(ctx, t.ID, func(t *Trip, items []Item) (*ItineraryEdit, error) {
+		if !t.contains(day) {
+			return nil, ErrDayOutsideTrip
+		}
+		idx := indexOf(items, itemID)
+		if idx < 0 {
+			return nil, ErrItemNotFound
+		}
+		moved = items[idx]
+		from := moved.Day
+		shift := day.Sub(dateOf(from))
+		moved.Day = day
+		if moved.StartAt.Valid {
+			moved.StartAt.Time = moved.StartAt.Time.Add(shift)
+		}
+		if moved.EndAt.Valid {
+			moved.EndAt.Time = moved.EndAt.Time.Add(shift)
+		}
+		if err := checkSchedule(&moved, items); err != nil {
+			return nil, err
+		}
+
+		// Renumber the day the item left and the day it joined
+		var changed []Item
+		if !dateOf(from).Equal(day) {
+			changed = append(changed, renumber(without(itemsOn(items, from), moved.ID))...)
+		}
+		target := without(itemsOn(items, day), moved.ID)
+		pos := len(target)
+		if req.Position != nil && *req.Position < pos {
+			pos = *req.Position
+		}
+		target = append(target[:pos], append([]Item{moved}, target[pos:]...)...)
+		target = renumber(target)
+		moved = target[pos]
+		return &ItineraryEdit{Schedule: append(changed, target...)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := toItemResponse(&moved)
+	return &resp, nil
+}
+
+// ReorderDay sets the order of a day's items. itemIDs must list each of them once.
+func (s *Service) ReorderDay(ctx context.Context, tripID, workspaceID uint64, day time.Time, req ReorderDayReq) ([]ItemResponse, error) {
+	t, err := s.trip(ctx, tripID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	day = dateOf(day)
+
+	var ordered []Item
+	err = s.editItinerary(ctx, t.ID, func(t *Trip, items []Item) (*ItineraryEdit, error) {
+		if !t.contains(day) {
+			return nil, ErrDayOutsideTrip
+		}
+		current := itemsOn(items, day)
+		if len(req.ItemIDs) != len(current) {
+			return nil, ErrInvalidOrder
+		}
+		byID := make(map[uint64]Item, len(current))
+		for _, item := range current {
+			byID[item.ID] = item
+		}
+		ordered = make([]Item, 0, len(current))
+		for _, id := range req.ItemIDs {
+			item, ok := byID[id]
+			if !ok {
+				return nil, ErrInvalidOrder
+			}
+			delete(byID, id) // a repeated ID fails the lookup above
+			ordered = append(ordered, item)
+		}
+		return &ItineraryEdit{Schedule: renumber(ordered)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]ItemResponse, len(ordered))
+	for i := range ordered {
+		resp[i] = toItemResponse(&ordered[i])
+	}
+	return resp, nil
+}
+
+// Helpers
+
+// editItinerary applies fn's edit with the trip and its items locked; see
+// TripRepository.EditItinerary. fn must check the trip it is given, not one
+// loaded earlier, and must not call the repository.
+func (s *Service) editItinerary(ctx context.Context, tripID uint64, fn func(t *Trip, items []Item) (*ItineraryEdit, error)) error {
+	err := s.repo.EditItinerary(ctx, tripID, fn)
+	if err == sql.ErrNoRows {
+		// Deleted since it was loaded
+		return ErrTripNotFound
+	}
+	return err
+}
+
+// indexOf returns the index of the item with id, or -1
+func indexOf(items []Item, id uint64) int {
+	for i := range items {
+		if items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkSchedule validates item's times and rejects overlaps with the trip's
+// other items, listing the ones it conflicts with
+func checkSchedule(item *Item, items []Item) error {
+	if item.EndAt.Valid && (!item.StartAt.Valid || !item.EndAt.Time.After(item.StartAt.Time)) {
+		return ErrInvalidTimes
+	}
+	if item.StartAt.Valid && !dateOf(item.StartAt.Time).Equal(dateOf(item.Day)) {
+		return ErrInvalidTimes
+	}
+
+	var conflicts []uint64
+	for i := range items {
+		if items[i].ID != item.ID && item.overlaps(&items[i]) {
+			conflicts = append(conflicts, items[i].ID)
+		}
+	}
+	if len(conflicts) > 0 {
+		return ErrTimeConflict.WithDetails(map[string]any{"conflicting_item_ids": conflicts})
+	}
+	return nil
+}
+
+// itemsOn returns the items planned on day, in itinerary order
+func itemsOn(items []Item, day time.Time) []Item {
+	var out []Item
+	for _, item := range items {
+		if dateOf(item.Day).Equal(dateOf(day)) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func without(items []Item, id uint64) []Item {
+	out := items[:0:0]
+	for _, item := range items {
+		if item.ID != id {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// renumber sets positions to 0..n-1 in slice order
+func renumber(items []Item) []Item {
+	for i := range items {
+		items[i].Position = i
+	}
+	return items
+}
@@ -0,0 +1,203 @@
+package trip
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository is an in-process TripRepository for tests and local experiments.
+// It mirrors the Postgres repository, including deleting a trip's items with it.
+// It knows nothing of places, so items of a deleted place are not removed.
+type MemoryRepository struct {
+	mu         sync.Mutex
+	trips      map[uint64]Trip
+	items      map[uint64]Item
+	nextTripID uint64
+	nextItemID uint64
+}
+
+var _ TripRepository = (*MemoryRepository)(nil)
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		trips: map[uint64]Trip{},
+		items: map[uint64]Item{},
+	}
+}
+
+// Trip Repository Methods
+
+func (r *MemoryRepository) CreateTrip(ctx context.Context, t *Trip) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextTripID++
+	now := time.Now()
+	t.ID = r.nextTripID
+	t.CreatedAt, t.UpdatedAt = now, now
+	r.trips[t.ID] = *t
+	return nil
+}
+
+func (r *MemoryRepository) ListTrips(ctx context.Context, workspaceID uint64) ([]Trip, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var trips []Trip
+	for _, t := range r.trips {
+		if t.WorkspaceID == workspaceID {
+			trips = append(trips, t)
+		}
+	}
+	sort.Slice(trips, func(i, j int) bool {
+		if !trips[i].StartDate.Equal(trips[j].StartDate) {
+			return trips[i].StartDate.Before(trips[j].StartDate)
+		}
+		return trips[i].ID < trips[j].ID
+	})
+	return trips, nil
+}
+
+func (r *MemoryRepository) GetTrip(ctx context.Context, id, workspaceID uint64) (*Trip, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.trips[id]
+	if !ok || t.WorkspaceID != workspaceID {
+		return nil, sql.ErrNoRows
+	}
+	return &t, nil
+}
+
+// UpdateTrip holds the repository lock while fn runs, so fn must not call back
+// into the repository
+func (r *MemoryRepository) UpdateTrip(ctx context.Context, id, workspaceID uint64, fn func(t *Trip, items []Item) error) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.trips[id]
+	if !ok || stored.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	t := stored
+	if err := fn(&t, r.listItems(id)); err != nil {
+		return false, err
+	}
+	stored.Name = t.Name
+	stored.Description = t.Description
+	stored.StartDate = t.StartDate
+	stored.EndDate = t.EndDate
+	stored.UpdatedAt = time.Now()
+	r.trips[id] = stored
+	return true, nil
+}
+
+func (r *MemoryRepository) DeleteTrip(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.trips[id]
+	if !ok || t.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	delete(r.trips, id)
+	for itemID, item := range r.items {
+		if item.TripID == id {
+			delete(r.items, itemID)
+		}
+	}
+	return true, nil
+}
+
+// Item Repository Methods
+
+func (r *MemoryRepository) ListItems(ctx context.Context, tripID uint64) ([]Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.listItems(tripID), nil
+}
+
+// EditItinerary holds the repository lock while fn runs, so fn must not call
+// back into the repository
+func (r *MemoryRepository) EditItinerary(ctx context.Context, tripID uint64, fn func(t *Trip, items []Item) (*ItineraryEdit, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.trips[tripID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	edit, err := fn(&t, r.listItems(tripID))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if item := edit.Create; item != nil {
+		r.nextItemID++
+		item.ID = r.nextItemID
+		item.TripID = tripID
+		item.CreatedAt, item.UpdatedAt = now, now
+		r.items[item.ID] = *item
+	}
+	if item := edit.Update; item != nil {
+		if stored, ok := r.items[item.ID]; ok && stored.TripID == tripID {
+			stored.StartAt = item.StartAt
+			stored.EndAt = item.EndAt
+			stored.Note = item.Note
+			stored.UpdatedAt = now
+			r.items[item.ID] = stored
+		}
+	}
+	for _, item := range edit.Schedule {
+		stored, ok := r.items[item.ID]
+		if !ok || stored.TripID != tripID {
+			continue
+		}
+		stored.Day = item.Day
+		stored.Position = item.Position
+		stored.StartAt = item.StartAt
+		stored.EndAt = item.EndAt
+		stored.UpdatedAt = now
+		r.items[item.ID] = stored
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteItem(ctx context.Context, id, tripID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[id]
+	if !ok || item.TripID != tripID {
+		return false, nil
+	}
+	delete(r.items, id)
+	return true, nil
+}
+
+// Helpers - callers hold mu
+
+// listItems returns the trip's items in itinerary order
+func (r *MemoryRepository) listItems(tripID uint64) []Item {
+	var items []Item
+	for _, item := range r.items {
+		if item.TripID == tripID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ID < b.ID
+	})
+	return items
+}
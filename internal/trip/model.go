@@ -0,0 +1,63 @@
+package trip
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Trip groups a workspace's places into an itinerary over a date range
+type Trip struct {
+	ID          uint64         `db:"id"`
+	WorkspaceID uint64         `db:"workspace_id"`
+	UserID      sql.NullInt64  `db:"user_id"` // creator; NULL once their account is deleted
+	Name        string         `db:"name"`
+	Description sql.NullString `db:"description"`
+	StartDate   time.Time      `db:"start_date"`
+	EndDate     time.Time      `db:"end_date"` // inclusive
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+// Item is a place planned on one day of a trip. StartAt and EndAt are wall-clock
+// times without a zone, like place.go_at_time.
+type Item struct {
+	ID        uint64         `db:"id"`
+	TripID    uint64         `db:"trip_id"`
+	PlaceID   uint64         `db:"place_id"`
+	Day       time.Time      `db:"day"`
+	Position  int            `db:"position"`
+	StartAt   sql.NullTime   `db:"start_at"`
+	EndAt     sql.NullTime   `db:"end_at"`
+	Note      sql.NullString `db:"note"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+}
+
+// ItineraryEdit is a change to a trip's items, worked out from their current
+// state inside TripRepository.EditItinerary
+type ItineraryEdit struct {
+	Create   *Item  // inserted; its ID and timestamps are set
+	Update   *Item  // its times and note are written
+	Schedule []Item // their day, position and times are written
+}
+
+// overlaps reports whether two timed items are planned at the same time. An item
+// without an end occupies only its start instant.
+func (it *Item) overlaps(other *Item) bool {
+	if !it.StartAt.Valid || !other.StartAt.Valid {
+		return false
+	}
+	aStart, aEnd := it.interval()
+	bStart, bEnd := other.interval()
+	if aStart.Equal(bStart) {
+		return true
+	}
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+func (it *Item) interval() (time.Time, time.Time) {
+	if it.EndAt.Valid {
+		return it.StartAt.Time, it.EndAt.Time
+	}
+	return it.StartAt.Time, it.StartAt.Time
+}
@@ -0,0 +1,202 @@
+package trip
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TripRepository is the storage used by Service.
+// Repository (Postgres) and MemoryRepository implement it. Trips are scoped to
+// workspaceID; items are reached through a trip the caller has already loaded.
+// Lookups that find nothing return sql.ErrNoRows and updates/deletes of missing
+// rows return false.
+type TripRepository interface {
+	CreateTrip(ctx context.Context, t *Trip) error
+	ListTrips(ctx context.Context, workspaceID uint64) ([]Trip, error)
+	GetTrip(ctx context.Context, id, workspaceID uint64) (*Trip, error)
+	UpdateTrip(ctx context.Context, id, workspaceID uint64, fn func(t *Trip, items []Item) error) (bool, error)
+	DeleteTrip(ctx context.Context, id, workspaceID uint64) (bool, error)
+
+	ListItems(ctx context.Context, tripID uint64) ([]Item, error)
+	EditItinerary(ctx context.Context, tripID uint64, fn func(t *Trip, items []Item) (*ItineraryEdit, error)) error
+	DeleteItem(ctx context.Context, id, tripID uint64) (bool, error)
+}
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+var _ TripRepository = (*Repository)(nil)
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Trip Repository Methods
+
+const tripColumns = `id, workspace_id, user_id, name, description, start_date, end_date, created_at, updated_at`
+
+// CreateTrip inserts t and sets its ID and timestamps
+func (r *Repository) CreateTrip(ctx context.Context, t *Trip) error {
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO trip (workspace_id, user_id, name, description, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`,
+		t.WorkspaceID, t.UserID, t.Name, t.Description, t.StartDate, t.EndDate,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// ListTrips returns the workspace's trips, earliest first
+func (r *Repository) ListTrips(ctx context.Context, workspaceID uint64) ([]Trip, error) {
+	var trips []Trip
+	err := r.db.SelectContext(ctx, &trips,
+		`SELECT `+tripColumns+` FROM trip WHERE workspace_id = $1 ORDER BY start_date, id`, workspaceID)
+	return trips, err
+}
+
+func (r *Repository) GetTrip(ctx context.Context, id, workspaceID uint64) (*Trip, error) {
+	var t Trip
+	err := r.db.GetContext(ctx, &t, `SELECT `+tripColumns+` FROM trip WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// UpdateTrip locks the trip, passes it and its items to fn and writes the
+// name, description and dates fn leaves in t, all in one transaction. Itinerary
+// edits wait for it, so fn can check the items against the new dates. An error
+// from fn is returned as is.
+func (r *Repository) UpdateTrip(ctx context.Context, id, workspaceID uint64, fn func(t *Trip, items []Item) error) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	t, items, err := lockTrip(ctx, tx, id)
+	if err == sql.ErrNoRows || (err == nil && t.WorkspaceID != workspaceID) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := fn(t, items); err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE trip SET name = $1, description = $2, start_date = $3, end_date = $4 WHERE id = $5`,
+		t.Name, t.Description, t.StartDate, t.EndDate, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// DeleteTrip removes a trip together with its items (ON DELETE CASCADE)
+func (r *Repository) DeleteTrip(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM trip WHERE id = $1 AND workspace_id = $2`, id, workspaceID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// Item Repository Methods
+
+const itemColumns = `id, trip_id, place_id, day, position, start_at, end_at, note, created_at, updated_at`
+
+// ListItems returns the trip's items in itinerary order
+func (r *Repository) ListItems(ctx context.Context, tripID uint64) ([]Item, error) {
+	var items []Item
+	err := r.db.SelectContext(ctx, &items,
+		`SELECT `+itemColumns+` FROM trip_item WHERE trip_id = $1 ORDER BY day, position, id`, tripID)
+	return items, err
+}
+
+// EditItinerary locks the trip, passes it and its items in itinerary order to
+// fn and applies the edit fn returns, all in one transaction. Concurrent edits
+// and trip updates therefore see each other's changes. An error from fn is
+// returned as is, and a missing trip gives sql.ErrNoRows.
+func (r *Repository) EditItinerary(ctx context.Context, tripID uint64, fn func(t *Trip, items []Item) (*ItineraryEdit, error)) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t, items, err := lockTrip(ctx, tx, tripID)
+	if err != nil {
+		return err
+	}
+
+	edit, err := fn(t, items)
+	if err != nil {
+		return err
+	}
+
+	if item := edit.Create; item != nil {
+		item.TripID = tripID
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO trip_item (trip_id, place_id, day, position, start_at, end_at, note)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`,
+			tripID, item.PlaceID, item.Day, item.Position, item.StartAt, item.EndAt, item.Note,
+		).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	if item := edit.Update; item != nil {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE trip_item SET start_at = $1, end_at = $2, note = $3 WHERE id = $4 AND trip_id = $5`,
+			item.StartAt, item.EndAt, item.Note, item.ID, tripID)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range edit.Schedule {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE trip_item SET day = $1, position = $2, start_at = $3, end_at = $4 WHERE id = $5 AND trip_id = $6`,
+			edit.Schedule[i].Day, edit.Schedule[i].Position, edit.Schedule[i].StartAt, edit.Schedule[i].EndAt, edit.Schedule[i].ID, tripID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) DeleteItem(ctx context.Context, id, tripID uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM trip_item WHERE id = $1 AND trip_id = $2`, id, tripID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// Helpers
+
+// lockTrip locks the trip row until tx ends and returns the trip with its items
+// in itinerary order
+func lockTrip(ctx context.Context, tx *sqlx.Tx, id uint64) (*Trip, []Item, error) {
+	var t Trip
+	if err := tx.GetContext(ctx, &t, `SELECT `+tripColumns+` FROM trip WHERE id = $1 FOR UPDATE`, id); err != nil {
+		return nil, nil, err
+	}
+	var items []Item
+	err := tx.SelectContext(ctx, &items,
+		`SELECT `+itemColumns+` FROM trip_item WHERE trip_id = $1 ORDER BY day, position, id`, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &t, items, nil
+}
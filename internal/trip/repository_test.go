@@ -0,0 +1,322 @@
+package trip
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-saas-api/internal/testutil"
+)
+
+// backends lists every TripRepository implementation the suite runs against.
+// new returns the repository, a workspace ID and two place IDs in that workspace.
+var backends = []struct {
+	name string
+	new  func(t *testing.T) (TripRepository, uint64, [2]uint64)
+}{
+	{"memory", func(t *testing.T) (TripRepository, uint64, [2]uint64) {
+		return NewMemoryRepository(), 1, [2]uint64{1, 2}
+	}},
+	{"postgres", func(t *testing.T) (TripRepository, uint64, [2]uint64) {
+		db := testutil.PostgresDB(t)
+		var workspaceID uint64
+		err := db.Get(&workspaceID,
+			`WITH u AS (
+				INSERT INTO users (email, password, name) VALUES ('a@example.com', 'x', 'A') RETURNING id
+			)
+			INSERT INTO workspaces (id, name, personal_user_id) SELECT id, 'Personal', id FROM u RETURNING id`)
+		if err != nil {
+			t.Fatalf("insert user and workspace: %v", err)
+		}
+		var places []uint64
+		err = db.Select(&places,
+			`INSERT INTO place (workspace_id, user_id, name) VALUES ($1, $1, 'cafe'), ($1, $1, 'museum') RETURNING id`,
+			workspaceID)
+		if err != nil {
+			t.Fatalf("insert places: %v", err)
+		}
+		return NewRepository(db), workspaceID, [2]uint64{places[0], places[1]}
+	}},
+}
+
+func TestRepositoryTrips(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, workspaceID, _ := b.new(t)
+
+			later := mustCreateTrip(t, ctx, repo, workspaceID, "autumn", day(10, 1), day(10, 5))
+			earlier := mustCreateTrip(t, ctx, repo, workspaceID, "summer", day(7, 1), day(7, 3))
+
+			trips, err := repo.ListTrips(ctx, workspaceID)
+			if err != nil {
+				t.Fatalf("ListTrips: %v", err)
+			}
+			if len(trips) != 2 || trips[0].ID != earlier.ID || trips[1].ID != later.ID {
+				t.Fatalf("ListTrips = %+v, want earliest first", trips)
+			}
+
+			ok, err := repo.UpdateTrip(ctx, earlier.ID, workspaceID, func(tr *Trip, items []Item) error {
+				tr.Name = "early summer"
+				tr.Description = sql.NullString{String: "beach", Valid: true}
+				tr.EndDate = day(7, 4)
+				return nil
+			})
+			if err != nil || !ok {
+				t.Fatalf("UpdateTrip = %v, %v", ok, err)
+			}
+			got, err := repo.GetTrip(ctx, earlier.ID, workspaceID)
+			if err != nil {
+				t.Fatalf("GetTrip: %v", err)
+			}
+			if got.Name != "early summer" || got.Description.String != "beach" || !dateOf(got.EndDate).Equal(day(7, 4)) {
+				t.Errorf("GetTrip = %+v", got)
+			}
+
+			// An error from fn leaves the trip untouched
+			errStop := errors.New("stop")
+			_, err = repo.UpdateTrip(ctx, earlier.ID, workspaceID, func(tr *Trip, items []Item) error {
+				tr.Name = "never saved"
+				return errStop
+			})
+			if err != errStop {
+				t.Errorf("UpdateTrip err = %v, want fn's error", err)
+			}
+			if got, _ := repo.GetTrip(ctx, earlier.ID, workspaceID); got.Name != "early summer" {
+				t.Errorf("after failed update name = %q", got.Name)
+			}
+
+			if _, err := repo.GetTrip(ctx, earlier.ID, workspaceID+1000); err != sql.ErrNoRows {
+				t.Errorf("GetTrip other workspace err = %v, want sql.ErrNoRows", err)
+			}
+			noop := func(*Trip, []Item) error { return nil }
+			if ok, err := repo.UpdateTrip(ctx, earlier.ID, workspaceID+1000, noop); err != nil || ok {
+				t.Errorf("UpdateTrip other workspace = %v, %v; want false", ok, err)
+			}
+			if ok, err := repo.DeleteTrip(ctx, earlier.ID, workspaceID+1000); err != nil || ok {
+				t.Errorf("DeleteTrip other workspace = %v, %v; want false", ok, err)
+			}
+			if ok, err := repo.DeleteTrip(ctx, earlier.ID, workspaceID); err != nil || !ok {
+				t.Fatalf("DeleteTrip = %v, %v", ok, err)
+			}
+			if _, err := repo.GetTrip(ctx, earlier.ID, workspaceID); err != sql.ErrNoRows {
+				t.Errorf("after delete err = %v, want sql.ErrNoRows", err)
+			}
+		})
+	}
+}
+
+func TestRepositoryItems(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, workspaceID, places := b.new(t)
+			tr := mustCreateTrip(t, ctx, repo, workspaceID, "summer", day(7, 1), day(7, 3))
+
+			second := mustCreateItem(t, ctx, repo, &Item{TripID: tr.ID, PlaceID: places[0], Day: day(7, 2), Position: 0})
+			first := mustCreateItem(t, ctx, repo, &Item{TripID: tr.ID, PlaceID: places[1], Day: day(7, 1), Position: 1})
+			third := mustCreateItem(t, ctx, repo, &Item{TripID: tr.ID, PlaceID: places[1], Day: day(7, 2), Position: 1})
+			assertItemOrder(t, ctx, repo, tr.ID, first.ID, second.ID, third.ID)
+
+			start := time.Date(2026, 7, 2, 9, 0, 0, 0, time.UTC)
+			third.StartAt = sql.NullTime{Time: start, Valid: true}
+			third.EndAt = sql.NullTime{Time: start.Add(time.Hour), Valid: true}
+			third.Note = sql.NullString{String: "book ahead", Valid: true}
+			mustEdit(t, ctx, repo, tr.ID, &ItineraryEdit{Update: third})
+
+			// Swap the day-2 items and move the first one onto day 2
+			second.Position, third.Position = 1, 0
+			first.Day, first.Position = day(7, 2), 2
+			mustEdit(t, ctx, repo, tr.ID, &ItineraryEdit{Schedule: []Item{*second, *third, *first}})
+			items := assertItemOrder(t, ctx, repo, tr.ID, third.ID, second.ID, first.ID)
+			if !dateOf(items[2].Day).Equal(day(7, 2)) {
+				t.Errorf("moved item day = %v, want 2026-07-02", items[2].Day)
+			}
+			if !items[0].StartAt.Time.Equal(start) || items[0].Note.String != "book ahead" {
+				t.Errorf("updated item = %+v", items[0])
+			}
+
+			if ok, err := repo.DeleteItem(ctx, second.ID, tr.ID+1000); err != nil || ok {
+				t.Errorf("DeleteItem other trip = %v, %v; want false", ok, err)
+			}
+			if ok, err := repo.DeleteItem(ctx, second.ID, tr.ID); err != nil || !ok {
+				t.Fatalf("DeleteItem = %v, %v", ok, err)
+			}
+			assertItemOrder(t, ctx, repo, tr.ID, third.ID, first.ID)
+
+			// An error from fn leaves the items untouched
+			errStop := errors.New("stop")
+			err := repo.EditItinerary(ctx, tr.ID, func(_ *Trip, items []Item) (*ItineraryEdit, error) {
+				return nil, errStop
+			})
+			if err != errStop {
+				t.Errorf("EditItinerary err = %v, want fn's error", err)
+			}
+			assertItemOrder(t, ctx, repo, tr.ID, third.ID, first.ID)
+
+			// Deleting the trip removes its itinerary
+			if _, err := repo.DeleteTrip(ctx, tr.ID, workspaceID); err != nil {
+				t.Fatalf("DeleteTrip: %v", err)
+			}
+			assertItemOrder(t, ctx, repo, tr.ID)
+			err = repo.EditItinerary(ctx, tr.ID, func(_ *Trip, items []Item) (*ItineraryEdit, error) {
+				return &ItineraryEdit{}, nil
+			})
+			if err != sql.ErrNoRows {
+				t.Errorf("EditItinerary deleted trip err = %v, want sql.ErrNoRows", err)
+			}
+		})
+	}
+}
+
+func TestRepositoryConcurrentEdits(t *testing.T) {
+	const editors = 8
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, workspaceID, places := b.new(t)
+			tr := mustCreateTrip(t, ctx, repo, workspaceID, "summer", day(7, 1), day(7, 3))
+
+			// Each editor appends after the items it sees; the trip lock makes
+			// them see each other's items, so no position is used twice
+			var wg sync.WaitGroup
+			errs := make(chan error, editors)
+			for i := 0; i < editors; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- repo.EditItinerary(ctx, tr.ID, func(_ *Trip, items []Item) (*ItineraryEdit, error) {
+						return &ItineraryEdit{Create: &Item{PlaceID: places[0], Day: day(7, 1), Position: len(items)}}, nil
+					})
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("EditItinerary: %v", err)
+				}
+			}
+
+			items, err := repo.ListItems(ctx, tr.ID)
+			if err != nil {
+				t.Fatalf("ListItems: %v", err)
+			}
+			if len(items) != editors {
+				t.Fatalf("items = %d, want %d", len(items), editors)
+			}
+			for i := range items {
+				if items[i].Position != i {
+					t.Errorf("positions = %+v, want 0..%d", items, editors-1)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryConcurrentShrink(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo, workspaceID, places := b.new(t)
+
+			// An edit adding an item on the last day races an update dropping
+			// that day; whichever runs second must see the other's change
+			for i := 0; i < 10; i++ {
+				tr := mustCreateTrip(t, ctx, repo, workspaceID, "summer", day(7, 1), day(7, 3))
+				var wg sync.WaitGroup
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					_, _ = repo.UpdateTrip(ctx, tr.ID, workspaceID, func(tr *Trip, items []Item) error {
+						if len(items) > 0 {
+							return errors.New("items on the dropped day")
+						}
+						tr.EndDate = day(7, 2)
+						return nil
+					})
+				}()
+				go func() {
+					defer wg.Done()
+					_ = repo.EditItinerary(ctx, tr.ID, func(tr *Trip, items []Item) (*ItineraryEdit, error) {
+						if !tr.contains(day(7, 3)) {
+							return nil, errors.New("day outside trip")
+						}
+						return &ItineraryEdit{Create: &Item{PlaceID: places[0], Day: day(7, 3)}}, nil
+					})
+				}()
+				wg.Wait()
+
+				got, err := repo.GetTrip(ctx, tr.ID, workspaceID)
+				if err != nil {
+					t.Fatalf("GetTrip: %v", err)
+				}
+				items, err := repo.ListItems(ctx, tr.ID)
+				if err != nil {
+					t.Fatalf("ListItems: %v", err)
+				}
+				for _, item := range items {
+					if !got.contains(item.Day) {
+						t.Fatalf("item on %v outside trip ending %v", item.Day, got.EndDate)
+					}
+				}
+			}
+		})
+	}
+}
+
+func mustCreateTrip(t *testing.T, ctx context.Context, repo TripRepository, workspaceID uint64, name string, start, end time.Time) *Trip {
+	t.Helper()
+	tr := &Trip{WorkspaceID: workspaceID, Name: name, StartDate: start, EndDate: end}
+	if err := repo.CreateTrip(ctx, tr); err != nil {
+		t.Fatalf("CreateTrip: %v", err)
+	}
+	return tr
+}
+
+func mustCreateItem(t *testing.T, ctx context.Context, repo TripRepository, item *Item) *Item {
+	t.Helper()
+	mustEdit(t, ctx, repo, item.TripID, &ItineraryEdit{Create: item})
+	return item
+}
+
+func mustEdit(t *testing.T, ctx context.Context, repo TripRepository, tripID uint64, edit *ItineraryEdit) {
+	t.Helper()
+	err := repo.EditItinerary(ctx, tripID, func(_ *Trip, items []Item) (*ItineraryEdit, error) {
+		return edit, nil
+	})
+	if err != nil {
+		t.Fatalf("EditItinerary: %v", err)
+	}
+}
+
+func assertItemOrder(t *testing.T, ctx context.Context, repo TripRepository, tripID uint64, want ...uint64) []Item {
+	t.Helper()
+	items, err := repo.ListItems(ctx, tripID)
+	if err != nil {
+		t.Fatalf("ListItems: %v", err)
+	}
+	got := make([]uint64, len(items))
+	for i := range items {
+		got[i] = items[i].ID
+	}
+	if len(got) != len(want) {
+		t.Fatalf("items = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("items = %v, want %v", got, want)
+		}
+	}
+	return items
+}
+
+// day returns a date in 2026
+func day(month time.Month, d int) time.Time {
+	return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,35 @@
+package trip
+
+import (
+	"go-saas-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, h *Handler, authMW *middleware.AuthMiddleware, wsMW *middleware.WorkspaceMiddleware, rl *middleware.RateLimiter) {
+	// Trips plan the workspace's places, so they share the places API key scopes
+	read := authMW.RequireAuth(middleware.ScopePlacesRead)
+	write := authMW.RequireAuth(middleware.ScopePlacesWrite)
+
+	viewer := wsMW.Require(middleware.WorkspaceRoleViewer)
+	editor := wsMW.Require(middleware.WorkspaceRoleEditor)
+
+	tripsRead := r.Group("/trips", read, rl.Limit("api"), viewer)
+	{
+		tripsRead.GET("", h.ListTrips)
+		tripsRead.GET("/:id", h.GetTrip)
+	}
+	tripsWrite := r.Group("/trips", write, rl.Limit("api"), editor)
+	{
+		tripsWrite.POST("", h.CreateTrip)
+		tripsWrite.PATCH("/:id", h.UpdateTrip)
+		tripsWrite.DELETE("/:id", h.DeleteTrip)
+
+		// Itinerary
+		tripsWrite.POST("/:id/items", h.AddItem)
+		tripsWrite.PATCH("/:id/items/:itemId", h.UpdateItem)
+		tripsWrite.DELETE("/:id/items/:itemId", h.DeleteItem)
+		tripsWrite.POST("/:id/items/:itemId/move", h.MoveItem)
+		tripsWrite.PUT("/:id/days/:day/order", h.ReorderDay)
+	}
+}
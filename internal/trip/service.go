@@ -0,0 +1,247 @@
+package trip
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go-saas-api/internal/place"
+	"go-saas-api/pkg/customtime"
+)
+
+const maxTripDays = 90
+
+// PlaceLookup finds the places itinerary items refer to. Missing places, and
+// places of other workspaces, are reported as sql.ErrNoRows.
+type PlaceLookup interface {
+	GetPlaceByID(ctx context.Context, id, workspaceID uint64) (*place.Place, error)
+}
+
+type Service struct {
+	repo   TripRepository
+	places PlaceLookup
+}
+
+func NewService(repo TripRepository, places PlaceLookup) *Service {
+	return &Service{
+		repo:   repo,
+		places: places,
+	}
+}
+
+// Trip Service Methods
+
+func (s *Service) CreateTrip(ctx context.Context, workspaceID, userID uint64, req CreateTripReq) (*TripResponse, error) {
+	t := &Trip{
+		WorkspaceID: workspaceID,
+		UserID:      sql.NullInt64{Int64: int64(userID), Valid: true},
+		Name:        strings.TrimSpace(req.Name),
+		Description: nullString(req.Description),
+		StartDate:   dateOf(req.StartDate.Time),
+		EndDate:     dateOf(req.EndDate.Time),
+	}
+	if err := checkDateRange(t.StartDate, t.EndDate); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateTrip(ctx, t); err != nil {
+		return nil, err
+	}
+	resp := toTripResponse(t)
+	return &resp, nil
+}
+
+// ListTrips returns the workspace's trips, earliest first
+func (s *Service) ListTrips(ctx context.Context, workspaceID uint64) ([]TripResponse, error) {
+	trips, err := s.repo.ListTrips(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]TripResponse, len(trips))
+	for i := range trips {
+		items[i] = toTripResponse(&trips[i])
+	}
+	return items, nil
+}
+
+// GetTrip returns a trip with its itinerary, including days without items
+func (s *Service) GetTrip(ctx context.Context, id, workspaceID uint64) (*TripDetailResponse, error) {
+	t, err := s.trip(ctx, id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListItems(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TripDetailResponse{TripResponse: toTripResponse(t)}
+	byDay := map[string]int{}
+	for d := dateOf(t.StartDate); !d.After(dateOf(t.EndDate)); d = d.AddDate(0, 0, 1) {
+		byDay[d.Format(customtime.DateFormat)] = len(resp.Days)
+		resp.Days = append(resp.Days, DayResponse{Date: customtime.Date{Time: d}, Items: []ItemResponse{}})
+	}
+	for i := range items {
+		if idx, ok := byDay[items[i].Day.Format(customtime.DateFormat)]; ok {
+			resp.Days[idx].Items = append(resp.Days[idx].Items, toItemResponse(&items[i]))
+		}
+	}
+	return resp, nil
+}
+
+// UpdateTrip changes a trip's details. Dates can only shrink to the days that
+// still have items; move or remove those items first.
+func (s *Service) UpdateTrip(ctx context.Context, id, workspaceID uint64, req UpdateTripReq) (*TripResponse, error) {
+	if req.Name == nil && req.Description == nil && req.StartDate == nil && req.EndDate == nil {
+		return nil, ErrNoFieldsToUpdate
+	}
+
+	// The dates are checked against the items with the trip locked, so an item
+	// cannot be planned on a day the new dates drop
+	ok, err := s.repo.UpdateTrip(ctx, id, workspaceID, func(t *Trip, items []Item) error {
+		if req.Name != nil {
+			t.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Description != nil {
+			t.Description = nullString(req.Description)
+		}
+		if req.StartDate != nil {
+			t.StartDate = dateOf(req.StartDate.Time)
+		}
+		if req.EndDate != nil {
+			t.EndDate = dateOf(req.EndDate.Time)
+		}
+		if req.StartDate == nil && req.EndDate == nil {
+			return nil
+		}
+
+		if err := checkDateRange(t.StartDate, t.EndDate); err != nil {
+			return err
+		}
+		var outside []uint64
+		for i := range items {
+			if !t.contains(items[i].Day) {
+				outside = append(outside, items[i].ID)
+			}
+		}
+		if len(outside) > 0 {
+			return ErrItemsOutsideRange.WithDetails(map[string]any{"item_ids": outside})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTripNotFound
+	}
+
+	updated, err := s.trip(ctx, id, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	resp := toTripResponse(updated)
+	return &resp, nil
+}
+
+func (s *Service) DeleteTrip(ctx context.Context, id, workspaceID uint64) error {
+	ok, err := s.repo.DeleteTrip(ctx, id, workspaceID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTripNotFound
+	}
+	return nil
+}
+
+// Helpers
+
+func (s *Service) trip(ctx context.Context, id, workspaceID uint64) (*Trip, error) {
+	t, err := s.repo.GetTrip(ctx, id, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTripNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func checkDateRange(start, end time.Time) error {
+	if end.Before(start) {
+		return ErrInvalidDateRange
+	}
+	if end.Sub(start) >= maxTripDays*24*time.Hour {
+		return ErrTripTooLong
+	}
+	return nil
+}
+
+// contains reports whether day falls within the trip's dates
+func (t *Trip) contains(day time.Time) bool {
+	day = dateOf(day)
+	return !day.Before(dateOf(t.StartDate)) && !day.After(dateOf(t.EndDate))
+}
+
+// dateOf drops the time of day, so dates read from the database and from JSON compare equal
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// wallClock drops t's offset but keeps its clock reading, as the zoneless
+// start_at and end_at columns do. Comparing times then matches what is stored.
+func wallClock(t time.Time) time.Time {
+	y, m, d := t.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(y, m, d, hour, min, sec, 0, time.UTC)
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func toTripResponse(t *Trip) TripResponse {
+	resp := TripResponse{
+		ID:          t.ID,
+		WorkspaceID: t.WorkspaceID,
+		Name:        t.Name,
+		StartDate:   customtime.Date{Time: t.StartDate},
+		EndDate:     customtime.Date{Time: t.EndDate},
+		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   t.UpdatedAt.Format(time.RFC3339),
+	}
+	if t.UserID.Valid {
+		userID := uint64(t.UserID.Int64)
+		resp.UserID = &userID
+	}
+	if t.Description.Valid {
+		resp.Description = &t.Description.String
+	}
+	return resp
+}
+
+func toItemResponse(it *Item) ItemResponse {
+	resp := ItemResponse{
+		ID:       it.ID,
+		PlaceID:  it.PlaceID,
+		Day:      customtime.Date{Time: it.Day},
+		Position: it.Position,
+	}
+	if it.StartAt.Valid {
+		resp.StartAt = customtime.NewDateTime(it.StartAt.Time)
+	}
+	if it.EndAt.Valid {
+		resp.EndAt = customtime.NewDateTime(it.EndAt.Time)
+	}
+	if it.Note.Valid {
+		resp.Note = &it.Note.String
+	}
+	return resp
+}
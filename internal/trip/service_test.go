@@ -0,0 +1,374 @@
+package trip
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-saas-api/internal/place"
+	"go-saas-api/pkg/apperror"
+	"go-saas-api/pkg/customtime"
+)
+
+const workspaceID, userID = 1, 1
+
+// newTestService returns a service whose workspace has a five day trip (1-5
+// July 2026) and three places: one with go_at and go_at_time on 2 July, one
+// with only go_at on 9 July, and one with neither
+func newTestService(t *testing.T) (*Service, *TripResponse, [3]uint64) {
+	t.Helper()
+	ctx := context.Background()
+	places := place.NewMemoryRepository()
+	var ids [3]uint64
+	for i, req := range []place.CreatePlaceReq{
+		{Name: strPtr("cafe"), GoAt: customtime.NewDate(day(7, 2)), GoAtTime: customtime.NewDateTime(at(7, 2, 10))},
+		{Name: strPtr("museum"), GoAt: customtime.NewDate(day(7, 9))},
+		{Name: strPtr("park")},
+	} {
+		id, err := places.CreatePlace(ctx, workspaceID, userID, req)
+		if err != nil {
+			t.Fatalf("CreatePlace: %v", err)
+		}
+		ids[i] = uint64(id)
+	}
+
+	svc := NewService(NewMemoryRepository(), places)
+	tr, err := svc.CreateTrip(ctx, workspaceID, userID, CreateTripReq{
+		Name:      "summer",
+		StartDate: customtime.NewDate(day(7, 1)),
+		EndDate:   customtime.NewDate(day(7, 5)),
+	})
+	if err != nil {
+		t.Fatalf("CreateTrip: %v", err)
+	}
+	return svc, tr, ids
+}
+
+func TestServiceCreateTrip(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewMemoryRepository(), place.NewMemoryRepository())
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		wantErr    error
+	}{
+		{"single day", day(7, 1), day(7, 1), nil},
+		{"90 days", day(1, 1), day(1, 1).AddDate(0, 0, 89), nil},
+		{"end before start", day(7, 2), day(7, 1), ErrInvalidDateRange},
+		{"91 days", day(1, 1), day(1, 1).AddDate(0, 0, 90), ErrTripTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateTrip(ctx, workspaceID, userID, CreateTripReq{
+				Name:      "trip",
+				StartDate: customtime.NewDate(tt.start),
+				EndDate:   customtime.NewDate(tt.end),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CreateTrip err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceAddItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     func(places [3]uint64) CreateItemReq
+		wantErr error
+		want    func(t *testing.T, item *ItemResponse)
+	}{
+		{"defaults day and start to the place's plan", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: p[0]}
+		}, nil, func(t *testing.T, item *ItemResponse) {
+			if !item.Day.Time.Equal(day(7, 2)) || item.StartAt == nil || !item.StartAt.Time.Equal(at(7, 2, 10)) {
+				t.Errorf("item = %+v, want 2 July at 10:00", item)
+			}
+		}},
+		{"go_at_time on another day is not used", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: p[0], Day: customtime.NewDate(day(7, 3))}
+		}, nil, func(t *testing.T, item *ItemResponse) {
+			if item.StartAt != nil {
+				t.Errorf("StartAt = %v, want none", item.StartAt)
+			}
+		}},
+		{"offset is dropped, keeping the clock time", func(p [3]uint64) CreateItemReq {
+			start := time.Date(2026, 7, 3, 9, 0, 0, 0, time.FixedZone("", -5*60*60))
+			return CreateItemReq{PlaceID: p[2], Day: customtime.NewDate(day(7, 3)), StartAt: customtime.NewDateTime(start)}
+		}, nil, func(t *testing.T, item *ItemResponse) {
+			if item.StartAt == nil || !item.StartAt.Time.Equal(at(7, 3, 9)) {
+				t.Errorf("StartAt = %v, want 3 July 09:00", item.StartAt)
+			}
+		}},
+		{"day required without go_at", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: p[2]}
+		}, ErrDayRequired, nil},
+		{"go_at outside the trip", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: p[1]}
+		}, ErrDayOutsideTrip, nil},
+		{"start on another day", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: p[2], Day: customtime.NewDate(day(7, 3)), StartAt: customtime.NewDateTime(at(7, 4, 9))}
+		}, ErrInvalidTimes, nil},
+		{"end without start", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: p[2], Day: customtime.NewDate(day(7, 3)), EndAt: customtime.NewDateTime(at(7, 3, 9))}
+		}, ErrInvalidTimes, nil},
+		{"end before start", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: p[2], Day: customtime.NewDate(day(7, 3)), StartAt: customtime.NewDateTime(at(7, 3, 9)), EndAt: customtime.NewDateTime(at(7, 3, 8))}
+		}, ErrInvalidTimes, nil},
+		{"unknown place", func(p [3]uint64) CreateItemReq {
+			return CreateItemReq{PlaceID: 999, Day: customtime.NewDate(day(7, 3))}
+		}, place.ErrPlaceNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, tr, places := newTestService(t)
+
+			item, err := svc.AddItem(ctx, tr.ID, workspaceID, tt.req(places))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddItem err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want != nil {
+				tt.want(t, item)
+			}
+		})
+	}
+}
+
+func TestServiceAddItemAppendsToDay(t *testing.T) {
+	ctx := context.Background()
+	svc, tr, places := newTestService(t)
+
+	for i := range places {
+		mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[i], Day: customtime.NewDate(day(7, 3))})
+	}
+
+	detail, err := svc.GetTrip(ctx, tr.ID, workspaceID)
+	if err != nil {
+		t.Fatalf("GetTrip: %v", err)
+	}
+	if len(detail.Days) != 5 {
+		t.Fatalf("days = %d, want 5", len(detail.Days))
+	}
+	items := detail.Days[2].Items
+	if len(items) != 3 {
+		t.Fatalf("3 July items = %+v, want 3", items)
+	}
+	for i, item := range items {
+		if item.Position != i || item.PlaceID != places[i] {
+			t.Errorf("item %d = %+v", i, item)
+		}
+	}
+}
+
+func TestServiceTimeConflicts(t *testing.T) {
+	ctx := context.Background()
+	svc, tr, places := newTestService(t)
+
+	// 10:00-12:00 on 2 July, and an untimed item that never conflicts
+	morning := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[0], StartAt: customtime.NewDateTime(at(7, 2, 10)), EndAt: customtime.NewDateTime(at(7, 2, 12))})
+	mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[2], Day: customtime.NewDate(day(7, 2))})
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		conflict   bool
+	}{
+		{"overlapping", at(7, 2, 11), at(7, 2, 13), true},
+		{"same start without end", at(7, 2, 10), time.Time{}, true},
+		{"point inside", at(7, 2, 11), time.Time{}, true},
+		{"starting as the other ends", at(7, 2, 12), at(7, 2, 13), false},
+		{"ending as the other starts", at(7, 2, 9), at(7, 2, 10), false},
+		// 10:30+02:00 is stored as 10:30, so it conflicts even though it is 08:30 UTC
+		{"offset keeps the clock time", time.Date(2026, 7, 2, 10, 30, 0, 0, time.FixedZone("", 2*60*60)), time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CreateItemReq{PlaceID: places[2], Day: customtime.NewDate(day(7, 2)), StartAt: customtime.NewDateTime(tt.start)}
+			if !tt.end.IsZero() {
+				req.EndAt = customtime.NewDateTime(tt.end)
+			}
+			item, err := svc.AddItem(ctx, tr.ID, workspaceID, req)
+			if !tt.conflict {
+				if err != nil {
+					t.Fatalf("AddItem: %v", err)
+				}
+				svc.DeleteItem(ctx, tr.ID, item.ID, workspaceID)
+				return
+			}
+
+			if !errors.Is(err, ErrTimeConflict) {
+				t.Fatalf("AddItem err = %v, want ErrTimeConflict", err)
+			}
+			var appErr *apperror.Error
+			if !errors.As(err, &appErr) {
+				t.Fatalf("err %T is not an *apperror.Error", err)
+			}
+			want := map[string]any{"conflicting_item_ids": []uint64{morning.ID}}
+			if !reflect.DeepEqual(appErr.Details, want) {
+				t.Errorf("details = %v, want %v", appErr.Details, want)
+			}
+		})
+	}
+}
+
+func TestServiceUpdateItemKeepsDuration(t *testing.T) {
+	ctx := context.Background()
+	svc, tr, places := newTestService(t)
+	item := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[0], StartAt: customtime.NewDateTime(at(7, 2, 10)), EndAt: customtime.NewDateTime(at(7, 2, 12))})
+
+	got, err := svc.UpdateItem(ctx, tr.ID, item.ID, workspaceID, UpdateItemReq{StartAt: customtime.NewDateTime(at(7, 2, 14))})
+	if err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	if !got.EndAt.Time.Equal(at(7, 2, 16)) {
+		t.Errorf("EndAt = %v, want 16:00", got.EndAt.Time)
+	}
+
+	if _, err := svc.UpdateItem(ctx, tr.ID, item.ID, workspaceID, UpdateItemReq{}); !errors.Is(err, ErrNoFieldsToUpdate) {
+		t.Errorf("empty update err = %v, want ErrNoFieldsToUpdate", err)
+	}
+	if _, err := svc.UpdateItem(ctx, tr.ID, 999, workspaceID, UpdateItemReq{Note: strPtr("x")}); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("unknown item err = %v, want ErrItemNotFound", err)
+	}
+}
+
+func TestServiceMoveItem(t *testing.T) {
+	ctx := context.Background()
+	svc, tr, places := newTestService(t)
+
+	timed := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[0], StartAt: customtime.NewDateTime(at(7, 2, 10)), EndAt: customtime.NewDateTime(at(7, 2, 12))})
+	left := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[2], Day: customtime.NewDate(day(7, 2))})
+	a := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[1], Day: customtime.NewDate(day(7, 4))})
+	b := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[2], Day: customtime.NewDate(day(7, 4))})
+
+	moved, err := svc.MoveItem(ctx, tr.ID, timed.ID, workspaceID, MoveItemReq{Day: customtime.NewDate(day(7, 4)), Position: intPtr(1)})
+	if err != nil {
+		t.Fatalf("MoveItem: %v", err)
+	}
+	if !moved.StartAt.Time.Equal(at(7, 4, 10)) || !moved.EndAt.Time.Equal(at(7, 4, 12)) {
+		t.Errorf("moved times = %v-%v, want 4 July 10:00-12:00", moved.StartAt.Time, moved.EndAt.Time)
+	}
+
+	detail, _ := svc.GetTrip(ctx, tr.ID, workspaceID)
+	assertDay(t, detail.Days[1], left.ID)
+	assertDay(t, detail.Days[3], a.ID, timed.ID, b.ID)
+
+	// Moving without a position appends; a timed item on the target day conflicts
+	blocker := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[2], Day: customtime.NewDate(day(7, 5)), StartAt: customtime.NewDateTime(at(7, 5, 11))})
+	if _, err := svc.MoveItem(ctx, tr.ID, timed.ID, workspaceID, MoveItemReq{Day: customtime.NewDate(day(7, 5))}); !errors.Is(err, ErrTimeConflict) {
+		t.Errorf("MoveItem onto conflict err = %v, want ErrTimeConflict", err)
+	}
+	if _, err := svc.MoveItem(ctx, tr.ID, left.ID, workspaceID, MoveItemReq{Day: customtime.NewDate(day(7, 5))}); err != nil {
+		t.Fatalf("MoveItem: %v", err)
+	}
+	detail, _ = svc.GetTrip(ctx, tr.ID, workspaceID)
+	assertDay(t, detail.Days[4], blocker.ID, left.ID)
+
+	if _, err := svc.MoveItem(ctx, tr.ID, a.ID, workspaceID, MoveItemReq{Day: customtime.NewDate(day(7, 6))}); !errors.Is(err, ErrDayOutsideTrip) {
+		t.Errorf("MoveItem outside trip err = %v, want ErrDayOutsideTrip", err)
+	}
+}
+
+func TestServiceReorderDay(t *testing.T) {
+	ctx := context.Background()
+	svc, tr, places := newTestService(t)
+
+	var ids []uint64
+	for i := range places {
+		ids = append(ids, mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[i], Day: customtime.NewDate(day(7, 3))}).ID)
+	}
+
+	tests := []struct {
+		name    string
+		order   []uint64
+		wantErr error
+	}{
+		{"missing item", []uint64{ids[2], ids[0]}, ErrInvalidOrder},
+		{"repeated item", []uint64{ids[2], ids[0], ids[0]}, ErrInvalidOrder},
+		{"item from another day", []uint64{ids[2], ids[0], 999}, ErrInvalidOrder},
+		{"full permutation", []uint64{ids[2], ids[0], ids[1]}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ReorderDay(ctx, tr.ID, workspaceID, day(7, 3), ReorderDayReq{ItemIDs: tt.order})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReorderDay err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			detail, _ := svc.GetTrip(ctx, tr.ID, workspaceID)
+			assertDay(t, detail.Days[2], tt.order...)
+		})
+	}
+}
+
+func TestServiceUpdateTripDates(t *testing.T) {
+	ctx := context.Background()
+	svc, tr, places := newTestService(t)
+	item := mustAddItem(t, svc, tr.ID, CreateItemReq{PlaceID: places[2], Day: customtime.NewDate(day(7, 5))})
+
+	_, err := svc.UpdateTrip(ctx, tr.ID, workspaceID, UpdateTripReq{EndDate: customtime.NewDate(day(7, 4))})
+	if !errors.Is(err, ErrItemsOutsideRange) {
+		t.Fatalf("UpdateTrip err = %v, want ErrItemsOutsideRange", err)
+	}
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		want := map[string]any{"item_ids": []uint64{item.ID}}
+		if !reflect.DeepEqual(appErr.Details, want) {
+			t.Errorf("details = %v, want %v", appErr.Details, want)
+		}
+	}
+
+	got, err := svc.UpdateTrip(ctx, tr.ID, workspaceID, UpdateTripReq{StartDate: customtime.NewDate(day(7, 3)), EndDate: customtime.NewDate(day(7, 8))})
+	if err != nil {
+		t.Fatalf("UpdateTrip: %v", err)
+	}
+	if !got.StartDate.Time.Equal(day(7, 3)) || !got.EndDate.Time.Equal(day(7, 8)) {
+		t.Errorf("dates = %v-%v, want 3-8 July", got.StartDate.Time, got.EndDate.Time)
+	}
+
+	if _, err := svc.GetTrip(ctx, tr.ID, workspaceID+1); !errors.Is(err, ErrTripNotFound) {
+		t.Errorf("GetTrip other workspace err = %v, want ErrTripNotFound", err)
+	}
+}
+
+func mustAddItem(t *testing.T, svc *Service, tripID uint64, req CreateItemReq) *ItemResponse {
+	t.Helper()
+	item, err := svc.AddItem(context.Background(), tripID, workspaceID, req)
+	if err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	return item
+}
+
+func assertDay(t *testing.T, d DayResponse, want ...uint64) {
+	t.Helper()
+	got := make([]uint64, len(d.Items))
+	for i, item := range d.Items {
+		got[i] = item.ID
+		if item.Position != i {
+			t.Errorf("%s item %d position = %d", d.Date.Format(customtime.DateFormat), item.ID, item.Position)
+		}
+	}
+	if !reflect.DeepEqual(got, want) && !(len(got) == 0 && len(want) == 0) {
+		t.Errorf("%s items = %v, want %v", d.Date.Format(customtime.DateFormat), got, want)
+	}
+}
+
+// at returns an hour of a day in 2026
+func at(month time.Month, d, hour int) time.Time {
+	return time.Date(2026, month, d, hour, 0, 0, 0, time.UTC)
+}
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
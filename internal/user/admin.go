@@ -0,0 +1,112 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go-saas-api/internal/middleware"
+)
+
+const defaultAdminListLimit = 50
+
+// ListUsers returns a page of users for the admin API and the total number of matches
+func (s *Service) ListUsers(ctx context.Context, q ListUsersQuery) ([]AdminUserResponse, int, error) {
+	filter := UserFilter{
+		Query:    strings.TrimSpace(q.Q),
+		Role:     q.Role,
+		Disabled: q.Disabled,
+		Limit:    q.Limit,
+		Offset:   q.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAdminListLimit
+	}
+
+	users, total, err := s.repo.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]AdminUserResponse, len(users))
+	for i := range users {
+		items[i] = toAdminUserResponse(&users[i])
+	}
+	return items, total, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, id uint64) (*AdminUserResponse, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	resp := toAdminUserResponse(user)
+	return &resp, nil
+}
+
+// UpdateUser changes a user's role or disabled state. The user's sessions are
+// revoked so the change applies immediately rather than at the next refresh.
+func (s *Service) UpdateUser(ctx context.Context, adminID, id uint64, req AdminUpdateUserReq) (*AdminUserResponse, error) {
+	if req.Role == nil && req.Disabled == nil {
+		return nil, ErrNoFieldsToUpdate
+	}
+
+	// Keep at least the acting admin able to administer
+	if id == adminID && ((req.Role != nil && *req.Role != middleware.RoleAdmin) || (req.Disabled != nil && *req.Disabled)) {
+		return nil, ErrCannotModifySelf
+	}
+
+	updated, err := s.repo.UpdateUserAdmin(ctx, id, req.Role, req.Disabled)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrUserNotFound
+	}
+
+	if err := s.repo.RevokeAllUserTokens(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, id)
+}
+
+// SetRoleByEmail is used by the CLI to bootstrap the first admin
+func (s *Service) SetRoleByEmail(ctx context.Context, email, role string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if _, err := s.repo.UpdateUserAdmin(ctx, user.ID, &role, nil); err != nil {
+		return err
+	}
+	return s.repo.RevokeAllUserTokens(ctx, user.ID)
+}
+
+func toAdminUserResponse(u *User) AdminUserResponse {
+	resp := AdminUserResponse{
+		ID:            u.ID,
+		Email:         u.Email,
+		Name:          u.Name,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerified,
+		Disabled:      u.DisabledAt.Valid,
+		CreatedAt:     u.CreatedAt.Format(time.RFC3339),
+	}
+	if u.DisabledAt.Valid {
+		t := u.DisabledAt.Time.Format(time.RFC3339)
+		resp.DisabledAt = &t
+	}
+	if u.DeletedAt.Valid {
+		t := u.DeletedAt.Time.Format(time.RFC3339)
+		resp.DeletedAt = &t
+	}
+	return resp
+}
@@ -0,0 +1,154 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"go-saas-api/internal/middleware"
+	"go-saas-api/pkg/token"
+)
+
+const (
+	apiKeyBytes       = 32
+	apiKeyPrefixLen   = len(middleware.APIKeyPrefix) + 8 // shown in listings to tell keys apart
+	maxAPIKeysPerUser = 25
+)
+
+// CreateAPIKey issues a personal API key. The key is returned only here;
+// just its hash is stored.
+func (s *Service) CreateAPIKey(ctx context.Context, userID uint64, req CreateAPIKeyReq) (*CreatedAPIKeyResponse, error) {
+	if _, err := s.activeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	secret, err := token.Random(apiKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	raw := middleware.APIKeyPrefix + secret
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	key := &APIKey{
+		UserID:  userID,
+		Name:    strings.TrimSpace(req.Name),
+		Prefix:  raw[:apiKeyPrefixLen],
+		KeyHash: token.Hash(raw),
+		Scopes:  slices.Compact(scopes),
+	}
+	if req.ExpiresInDays != nil {
+		key.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Duration(*req.ExpiresInDays) * 24 * time.Hour), Valid: true}
+	}
+
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(key), Key: raw}, nil
+}
+
+// ListAPIKeys returns the user's keys that have not been revoked, including expired ones
+func (s *Service) ListAPIKeys(ctx context.Context, userID uint64) ([]APIKeyResponse, error) {
+	keys, err := s.repo.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]APIKeyResponse, len(keys))
+	for i := range keys {
+		items[i] = toAPIKeyResponse(&keys[i])
+	}
+	return items, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, userID, id uint64) error {
+	revoked, err := s.repo.RevokeAPIKey(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// APIKeyAuthenticator lets middleware.AuthMiddleware accept personal API keys
+type APIKeyAuthenticator struct {
+	repo UserRepository
+}
+
+var _ middleware.APIKeyAuthenticator = (*APIKeyAuthenticator)(nil)
+
+func NewAPIKeyAuthenticator(repo UserRepository) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{repo: repo}
+}
+
+// AuthenticateAPIKey returns the key's owner and scopes, or nil if the key is
+// unknown, revoked or expired, or its owner is disabled or pending deletion
+func (a *APIKeyAuthenticator) AuthenticateAPIKey(ctx context.Context, raw string) (*middleware.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(raw, middleware.APIKeyPrefix) {
+		return nil, nil
+	}
+
+	key, err := a.repo.GetAPIKeyByHash(ctx, token.Hash(raw))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.RevokedAt.Valid || (key.ExpiresAt.Valid && !key.ExpiresAt.Time.After(now)) {
+		return nil, nil
+	}
+
+	user, err := a.repo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if user.DeletedAt.Valid || user.DisabledAt.Valid {
+		return nil, nil
+	}
+
+	if err := a.repo.TouchAPIKey(ctx, key.ID, now); err != nil {
+		return nil, err
+	}
+	return &middleware.APIKeyPrincipal{
+		KeyID:  key.ID,
+		UserID: key.UserID,
+		Role:   user.Role,
+		Scopes: key.Scopes,
+	}, nil
+}
+
+func toAPIKeyResponse(k *APIKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.ExpiresAt.Valid {
+		t := k.ExpiresAt.Time.Format(time.RFC3339)
+		resp.ExpiresAt = &t
+	}
+	if k.LastUsedAt.Valid {
+		t := k.LastUsedAt.Time.Format(time.RFC3339)
+		resp.LastUsedAt = &t
+	}
+	return resp
+}
@@ -17,14 +17,159 @@ type ChangePasswordReq struct {
 	NewPassword string `json:"new_password" validate:"required,min=6,max=72"`
 }
 
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type VerifyEmailReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6,max=72"`
+}
+
+type UpdateMeReq struct {
+	Name  *string `json:"name" validate:"omitempty,min=2,max=120"`
+	Email *string `json:"email" validate:"omitempty,email,max=255"`
+}
+
+type DeleteMeReq struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type CreateAPIKeyReq struct {
+	Name          string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=places:read places:write"`
+	ExpiresInDays *int     `json:"expires_in_days" validate:"omitempty,min=1,max=365"` // omit for a key that never expires
+}
+
+type VerifyMFAReq struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"` // authenticator code or recovery code
+}
+
+type ConfirmTwoFactorReq struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// TwoFactorPasswordReq re-confirms the password before 2FA is disabled or codes are regenerated
+type TwoFactorPasswordReq struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type OIDCCallbackReq struct {
+	Code  string `json:"code" validate:"required,max=2048"`
+	State string `json:"state" validate:"required,max=256"`
+}
+
 // Response DTOs
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int64        `json:"expires_in"` // access token lifetime in seconds
+	User         UserResponse `json:"user"`
+}
+
+// LoginResponse holds either tokens or, when 2FA is on, an MFA challenge
+type LoginResponse struct {
+	*AuthResponse
+	*MFAChallenge
+}
+
+type MFAChallenge struct {
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token"`      // pass to /auth/2fa/verify with a code
+	MFAExpiresIn int64  `json:"mfa_expires_in"` // seconds
 }
 
 type UserResponse struct {
-	ID    uint64 `json:"id"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	ID            uint64 `json:"id"`
+	Email         string `json:"email"`
+	Name          string `json:"name"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	TwoFactor     bool   `json:"two_factor_enabled"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse carries the new secret; it is not active until confirmed
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse is the only time recovery codes are shown
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+type SessionResponse struct {
+	ID         uint64 `json:"id"`
+	Device     string `json:"device"` // label derived from the user agent
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	Current    bool   `json:"current"` // the session making this request
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+}
+
+type APIKeyResponse struct {
+	ID         uint64   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse is the only response that includes the key itself
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// Admin DTOs
+type ListUsersQuery struct {
+	Q        string  `form:"q" validate:"omitempty,max=255"`
+	Role     *string `form:"role" validate:"omitempty,oneof=user admin"`
+	Disabled *bool   `form:"disabled"`
+	Limit    int     `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset   int     `form:"offset" validate:"omitempty,min=0"`
+}
+
+type AdminUpdateUserReq struct {
+	Role     *string `json:"role" validate:"omitempty,oneof=user admin"`
+	Disabled *bool   `json:"disabled"`
+}
+
+type AdminUserResponse struct {
+	ID            uint64  `json:"id"`
+	Email         string  `json:"email"`
+	Name          string  `json:"name"`
+	Role          string  `json:"role"`
+	EmailVerified bool    `json:"email_verified"`
+	Disabled      bool    `json:"disabled"`
+	DisabledAt    *string `json:"disabled_at"`
+	DeletedAt     *string `json:"deleted_at"` // pending deletion
+	CreatedAt     string  `json:"created_at"`
 }
@@ -0,0 +1,40 @@
+package user
+
+import "go-saas-api/pkg/apperror"
+
+var (
+	ErrEmailTaken           = apperror.New(apperror.KindConflict, "email_already_registered", "email already registered")
+	ErrInvalidCredentials   = apperror.New(apperror.KindUnauthorized, "invalid_credentials", "invalid credentials")
+	ErrOldPasswordIncorrect = apperror.New(apperror.KindUnauthorized, "old_password_incorrect", "old password is incorrect")
+	ErrInvalidRefreshToken  = apperror.New(apperror.KindUnauthorized, "invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused   = apperror.New(apperror.KindUnauthorized, "refresh_token_reused", "refresh token reused")
+	ErrTooManyLoginAttempts = apperror.New(apperror.KindTooManyRequests, "too_many_login_attempts", "too many failed login attempts, try again later")
+
+	ErrInvalidVerificationToken = apperror.New(apperror.KindInvalid, "invalid_verification_token", "invalid or expired verification token")
+	ErrEmailAlreadyVerified     = apperror.New(apperror.KindConflict, "email_already_verified", "email already verified")
+	ErrInvalidResetToken        = apperror.New(apperror.KindInvalid, "invalid_reset_token", "invalid or expired password reset token")
+
+	ErrUserNotFound      = apperror.New(apperror.KindNotFound, "user_not_found", "user not found")
+	ErrNoFieldsToUpdate  = apperror.New(apperror.KindInvalid, "no_fields_to_update", "no fields to update")
+	ErrPasswordIncorrect = apperror.New(apperror.KindUnauthorized, "password_incorrect", "password is incorrect")
+
+	ErrAccountDisabled  = apperror.New(apperror.KindForbidden, "account_disabled", "account has been disabled")
+	ErrCannotModifySelf = apperror.New(apperror.KindForbidden, "cannot_modify_self", "admins cannot demote or disable themselves")
+
+	ErrTwoFactorAlreadyEnabled = apperror.New(apperror.KindConflict, "two_factor_already_enabled", "two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = apperror.New(apperror.KindConflict, "two_factor_not_enabled", "two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp       = apperror.New(apperror.KindConflict, "two_factor_not_set_up", "start two-factor setup first")
+	ErrInvalidMFAToken         = apperror.New(apperror.KindUnauthorized, "invalid_mfa_token", "invalid or expired login challenge, log in again")
+	ErrInvalidMFACode          = apperror.New(apperror.KindUnauthorized, "invalid_mfa_code", "invalid authentication code")
+
+	ErrSessionNotFound = apperror.New(apperror.KindNotFound, "session_not_found", "session not found")
+
+	ErrAPIKeyNotFound = apperror.New(apperror.KindNotFound, "api_key_not_found", "api key not found")
+	ErrTooManyAPIKeys = apperror.New(apperror.KindConflict, "too_many_api_keys", "api key limit reached, revoke an unused key first")
+
+	ErrOIDCProviderNotFound = apperror.New(apperror.KindNotFound, "oidc_provider_not_found", "unknown identity provider")
+	ErrInvalidOIDCState     = apperror.New(apperror.KindInvalid, "invalid_oidc_state", "invalid or expired login state, start again")
+	ErrOIDCLoginFailed      = apperror.New(apperror.KindUnauthorized, "oidc_login_failed", "could not verify the identity provider's response")
+	ErrOIDCEmailNotVerified = apperror.New(apperror.KindForbidden, "oidc_email_not_verified", "the identity provider did not confirm an email address")
+	ErrOIDCAccountExists    = apperror.New(apperror.KindConflict, "oidc_account_exists", "an account with this email exists; log in with your password and verify your email to link it")
+)
@@ -3,8 +3,10 @@ package user
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
+	"go-saas-api/pkg/apperror"
 	"go-saas-api/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -27,24 +29,20 @@ func NewHandler(service *Service, v *validator.Validate) *Handler {
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid json")
+		c.Error(apperror.ErrInvalidJSON)
 		return
 	}
 	if err := h.v.Struct(req); err != nil {
-		response.Error(c, http.StatusBadRequest, "validation failed")
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	result, err := h.service.Register(ctx, req)
+	result, err := h.service.Register(ctx, req, clientInfo(c))
 	if err != nil {
-		if err.Error() == "email already registered" {
-			response.Error(c, http.StatusConflict, err.Error())
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
@@ -55,24 +53,44 @@ func (h *Handler) Register(c *gin.Context) {
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid json")
+		c.Error(apperror.ErrInvalidJSON)
 		return
 	}
 	if err := h.v.Struct(req); err != nil {
-		response.Error(c, http.StatusBadRequest, "validation failed")
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
 	defer cancel()
 
-	result, err := h.service.Login(ctx, req)
+	result, err := h.service.Login(ctx, req, clientInfo(c))
 	if err != nil {
-		if err.Error() == "invalid credentials" {
-			response.Error(c, http.StatusUnauthorized, err.Error())
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// POST /auth/2fa/verify
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var req VerifyMFAReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.VerifyMFA(ctx, req, clientInfo(c))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -83,17 +101,17 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) ChangePassword(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		response.Error(c, http.StatusUnauthorized, "unauthorized")
+		c.Error(apperror.ErrUnauthorized)
 		return
 	}
 
 	var req ChangePasswordReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid json")
+		c.Error(apperror.ErrInvalidJSON)
 		return
 	}
 	if err := h.v.Struct(req); err != nil {
-		response.Error(c, http.StatusBadRequest, "validation failed")
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
 		return
 	}
 
@@ -102,13 +120,604 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 
 	err := h.service.ChangePassword(ctx, userID.(uint64), req)
 	if err != nil {
-		if err.Error() == "old password is incorrect" {
-			response.Error(c, http.StatusUnauthorized, err.Error())
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "internal server error")
+		c.Error(err)
 		return
 	}
 
 	response.SuccessMessage(c, http.StatusOK, "password changed successfully")
 }
+
+// POST /auth/refresh
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.Refresh(ctx, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// POST /auth/logout (protected route)
+func (h *Handler) Logout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req LogoutReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	err := h.service.Logout(ctx, userID.(uint64), c.GetString("tokenJTI"), c.GetTime("tokenExpiresAt"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "logged out successfully")
+}
+
+// GET /auth/sessions (protected route)
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListSessions(ctx, userID.(uint64), c.GetUint64("sessionID"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
+
+// DELETE /auth/sessions/:id (protected route)
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.RevokeSession(ctx, userID.(uint64), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "session logged out")
+}
+
+// POST /auth/verify-email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.VerifyEmail(ctx, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "email verified successfully")
+}
+
+// POST /auth/resend-verification (protected route)
+func (h *Handler) ResendVerification(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // SMTP can be slow
+	defer cancel()
+
+	if err := h.service.ResendVerification(ctx, userID.(uint64)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "verification email sent")
+}
+
+// POST /auth/forgot-password
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	// Same answer, in the same time, whether or not the email is registered
+	h.service.ForgotPassword(req)
+
+	response.SuccessMessage(c, http.StatusOK, "if the email is registered, a reset link has been sent")
+}
+
+// POST /auth/reset-password
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.ResetPassword(ctx, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "password reset successfully")
+}
+
+// GET /auth/oidc/providers
+func (h *Handler) ListOIDCProviders(c *gin.Context) {
+	response.Success(c, http.StatusOK, gin.H{"providers": h.service.OIDCProviders()})
+}
+
+// POST /auth/oidc/:provider/start
+func (h *Handler) StartOIDCLogin(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // may fetch the discovery document
+	defer cancel()
+
+	result, err := h.service.StartOIDCLogin(ctx, c.Param("provider"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// POST /auth/oidc/:provider/callback
+func (h *Handler) CompleteOIDCLogin(c *gin.Context) {
+	var req OIDCCallbackReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // calls the provider's token endpoint
+	defer cancel()
+
+	result, err := h.service.CompleteOIDCLogin(ctx, c.Param("provider"), req, clientInfo(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GET /me (protected route)
+func (h *Handler) GetMe(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.GetMe(ctx, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// PATCH /me (protected route)
+func (h *Handler) UpdateMe(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req UpdateMeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // may send a verification email
+	defer cancel()
+
+	result, err := h.service.UpdateMe(ctx, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// DELETE /me (protected route)
+func (h *Handler) DeleteMe(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req DeleteMeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteMe(ctx, userID.(uint64), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "account scheduled for deletion; log in again before the grace period ends to cancel")
+}
+
+// GET /me/2fa (protected route)
+func (h *Handler) TwoFactorStatus(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.TwoFactorStatus(ctx, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// POST /me/2fa/setup (protected route)
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.SetupTwoFactor(ctx, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// POST /me/2fa/confirm (protected route)
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req ConfirmTwoFactorReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.ConfirmTwoFactor(ctx, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// POST /me/2fa/disable (protected route)
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req TwoFactorPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DisableTwoFactor(ctx, userID.(uint64), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "two-factor authentication disabled")
+}
+
+// POST /me/2fa/recovery-codes (protected route)
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req TwoFactorPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.RegenerateRecoveryCodes(ctx, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GET /me/api-keys (protected route)
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListAPIKeys(ctx, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
+
+// POST /me/api-keys (protected route)
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req CreateAPIKeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.CreateAPIKey(ctx, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, result)
+}
+
+// DELETE /me/api-keys/:id (protected route)
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.RevokeAPIKey(ctx, userID.(uint64), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "api key revoked")
+}
+
+// Admin Handlers
+
+// GET /admin/users (admin only)
+func (h *Handler) ListUsers(c *gin.Context) {
+	var query ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperror.ErrInvalidQuery)
+		return
+	}
+	if err := h.v.Struct(query); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, total, err := h.service.ListUsers(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items, "total": total})
+}
+
+// GET /admin/users/:id (admin only)
+func (h *Handler) GetUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.GetUser(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// PATCH /admin/users/:id (admin only)
+func (h *Handler) UpdateUser(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req AdminUpdateUserReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.UpdateUser(ctx, adminID.(uint64), id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// clientInfo describes the client of a login request
+func clientInfo(c *gin.Context) ClientInfo {
+	return ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
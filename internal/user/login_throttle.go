@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Brute-force protection: failed logins are counted per account and per client IP.
+// Once a key passes its free attempts it is locked with exponential backoff.
+const (
+	loginFailureWindow  = time.Hour // failures older than this are forgotten
+	accountFreeAttempts = 5
+	ipFreeAttempts      = 20 // higher, since many users can share an IP
+	loginBackoffBase    = 30 * time.Second
+	loginBackoffMax     = 15 * time.Minute
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so that
+// unknown and known emails take the same time to reject
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+type loginKey struct {
+	key  string
+	free int
+}
+
+func loginKeys(email, clientIP string) []loginKey {
+	keys := []loginKey{{"email:" + strings.ToLower(email), accountFreeAttempts}}
+	if clientIP != "" {
+		keys = append(keys, loginKey{"ip:" + clientIP, ipFreeAttempts})
+	}
+	return keys
+}
+
+// checkLoginLock returns ErrTooManyLoginAttempts if any key is locked
+func (s *Service) checkLoginLock(ctx context.Context, keys []loginKey) error {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.key
+	}
+
+	until, err := s.repo.LoginLockedUntil(ctx, names)
+	if err != nil {
+		return err
+	}
+	if wait := time.Until(until); wait > 0 {
+		return ErrTooManyLoginAttempts.WithRetryAfter(wait)
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt and locks keys that used up their free attempts
+func (s *Service) recordLoginFailure(ctx context.Context, keys []loginKey) error {
+	now := time.Now()
+	for _, k := range keys {
+		failures, err := s.repo.RecordLoginFailure(ctx, k.key, now.Add(-loginFailureWindow))
+		if err != nil {
+			return err
+		}
+		if d := loginBackoff(failures, k.free); d > 0 {
+			if err := s.repo.LockLogin(ctx, k.key, now.Add(d)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loginBackoff returns how long to lock after the given number of failures
+func loginBackoff(failures, free int) time.Duration {
+	if failures < free {
+		return 0
+	}
+	extra := failures - free
+	if extra >= 10 {
+		return loginBackoffMax
+	}
+	return min(loginBackoffBase<<extra, loginBackoffMax)
+}
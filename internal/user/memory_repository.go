@@ -0,0 +1,955 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errUniqueViolation stands in for the Postgres unique constraint error
+var errUniqueViolation = errors.New("duplicate key value violates unique constraint")
+
+// errForeignKeyViolation stands in for the Postgres foreign key error
+var errForeignKeyViolation = errors.New("insert or update violates foreign key constraint")
+
+// MemoryRepository is an in-process UserRepository for tests and local experiments.
+// It mirrors the Postgres repository's semantics, including sql.ErrNoRows for missing rows.
+type MemoryRepository struct {
+	mu            sync.Mutex
+	users         map[uint64]User
+	refreshTokens map[uint64]RefreshToken
+	revoked       map[string]time.Time // jti -> expires at
+	verifications map[string]emailVerification
+	resets        map[string]passwordReset // token hash -> reset
+	loginAttempts map[string]loginAttempt
+	apiKeys       map[uint64]APIKey
+	identities    map[uint64]Identity
+	oidcStates    map[string]OIDCState       // state hash -> state
+	recoveryCodes map[uint64]map[string]bool // user ID -> code hash -> used
+	sessions      map[uint64]Session
+	nextUserID    uint64
+	nextTokenID   uint64
+	nextAPIKeyID  uint64
+	nextIdentity  uint64
+	nextSession   uint64
+}
+
+var _ UserRepository = (*MemoryRepository)(nil)
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users:         map[uint64]User{},
+		refreshTokens: map[uint64]RefreshToken{},
+		revoked:       map[string]time.Time{},
+		verifications: map[string]emailVerification{},
+		resets:        map[string]passwordReset{},
+		loginAttempts: map[string]loginAttempt{},
+		apiKeys:       map[uint64]APIKey{},
+		identities:    map[uint64]Identity{},
+		oidcStates:    map[string]OIDCState{},
+		recoveryCodes: map[uint64]map[string]bool{},
+		sessions:      map[uint64]Session{},
+	}
+}
+
+// loginAttempt is a login_attempts row
+type loginAttempt struct {
+	failures    int
+	lockedUntil time.Time
+	updatedAt   time.Time
+}
+
+// passwordReset is a password_reset_tokens row
+type passwordReset struct {
+	userID    uint64
+	expiresAt time.Time
+	used      bool
+}
+
+// emailVerification is an email_verification_tokens row
+type emailVerification struct {
+	userID    uint64
+	email     string
+	expiresAt time.Time
+	used      bool
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, email, hashedPassword, name string) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			return 0, errUniqueViolation
+		}
+	}
+
+	r.nextUserID++
+	now := time.Now()
+	r.users[r.nextUserID] = User{
+		ID:        r.nextUserID,
+		Email:     email,
+		Password:  hashedPassword,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Role:      "user",
+	}
+	return r.nextUserID, nil
+}
+
+func (r *MemoryRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) GetByID(ctx context.Context, id uint64) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &u, nil
+}
+
+func (r *MemoryRepository) UpdatePassword(ctx context.Context, id uint64, hashedPassword string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil // UPDATE of a missing row is not an error
+	}
+	u.Password = hashedPassword
+	u.UpdatedAt = time.Now()
+	r.users[id] = u
+	r.revokeRefreshTokens(func(rt RefreshToken) bool { return rt.UserID == id })
+	return nil
+}
+
+func (r *MemoryRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *MemoryRepository) UpdateProfile(ctx context.Context, id uint64, name, email *string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return false, nil
+	}
+	if email != nil {
+		for _, other := range r.users {
+			if other.ID != id && other.Email == *email {
+				return false, errUniqueViolation
+			}
+		}
+		u.Email = *email
+		u.EmailVerified = false
+		u.EmailVerifiedAt = sql.NullTime{}
+	}
+	if name != nil {
+		u.Name = *name
+	}
+	u.UpdatedAt = time.Now()
+	r.users[id] = u
+	return true, nil
+}
+
+func (r *MemoryRepository) SoftDelete(ctx context.Context, id uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return false, nil
+	}
+	u.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	r.users[id] = u
+	return true, nil
+}
+
+func (r *MemoryRepository) Restore(ctx context.Context, id uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[id]; ok {
+		u.DeletedAt = sql.NullTime{}
+		r.users[id] = u
+	}
+	return nil
+}
+
+// PurgeDeleted removes the users and their tokens. Workspaces live in the
+// workspace module, so unlike Postgres it does not hand over shared workspaces.
+func (r *MemoryRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, u := range r.users {
+		if !u.DeletedAt.Valid || !u.DeletedAt.Time.Before(before) {
+			continue
+		}
+		// Cascade like the foreign keys do
+		delete(r.users, id)
+		for tid, rt := range r.refreshTokens {
+			if rt.UserID == id {
+				delete(r.refreshTokens, tid)
+			}
+		}
+		for k, v := range r.verifications {
+			if v.userID == id {
+				delete(r.verifications, k)
+			}
+		}
+		for k, v := range r.resets {
+			if v.userID == id {
+				delete(r.resets, k)
+			}
+		}
+		for k, v := range r.apiKeys {
+			if v.UserID == id {
+				delete(r.apiKeys, k)
+			}
+		}
+		for k, v := range r.identities {
+			if v.UserID == id {
+				delete(r.identities, k)
+			}
+		}
+		delete(r.recoveryCodes, id)
+		for k, v := range r.sessions {
+			if v.UserID == id {
+				delete(r.sessions, k)
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (r *MemoryRepository) ListUsers(ctx context.Context, filter UserFilter) ([]User, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	query := strings.ToLower(filter.Query)
+	var matches []User
+	for _, u := range r.users {
+		if query != "" && !strings.Contains(strings.ToLower(u.Email), query) && !strings.Contains(strings.ToLower(u.Name), query) {
+			continue
+		}
+		if filter.Role != nil && u.Role != *filter.Role {
+			continue
+		}
+		if filter.Disabled != nil && u.DisabledAt.Valid != *filter.Disabled {
+			continue
+		}
+		matches = append(matches, u)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
+	total := len(matches)
+	start := min(filter.Offset, total)
+	end := min(start+filter.Limit, total)
+	return matches[start:end], total, nil
+}
+
+func (r *MemoryRepository) UpdateUserAdmin(ctx context.Context, id uint64, role *string, disabled *bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return false, nil
+	}
+	if role != nil {
+		u.Role = *role
+	}
+	if disabled != nil {
+		switch {
+		case !*disabled:
+			u.DisabledAt = sql.NullTime{}
+		case !u.DisabledAt.Valid:
+			u.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+	}
+	r.users[id] = u
+	return true, nil
+}
+
+// Refresh token & revocation methods
+
+func (r *MemoryRepository) CreateRefreshToken(ctx context.Context, rt *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insertRefreshToken(rt)
+}
+
+func (r *MemoryRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rt := range r.refreshTokens {
+		if rt.TokenHash == tokenHash {
+			return &rt, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) RotateRefreshToken(ctx context.Context, oldID uint64, next *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.refreshTokens[oldID]
+	if !ok || old.RevokedAt.Valid {
+		return sql.ErrNoRows
+	}
+	if err := r.insertRefreshToken(next); err != nil {
+		return err
+	}
+
+	old.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	old.ReplacedBy = sql.NullInt64{Int64: int64(next.ID), Valid: true}
+	r.refreshTokens[oldID] = old
+	return nil
+}
+
+func (r *MemoryRepository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeRefreshTokens(func(rt RefreshToken) bool { return rt.FamilyID == familyID })
+	return nil
+}
+
+func (r *MemoryRepository) RevokeAllUserTokens(ctx context.Context, userID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeRefreshTokens(func(rt RefreshToken) bool { return rt.UserID == userID })
+	return nil
+}
+
+func (r *MemoryRepository) RevokeAccessToken(ctx context.Context, jti string, userID uint64, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.revoked[jti]; !ok {
+		r.revoked[jti] = expiresAt
+	}
+	now := time.Now()
+	for k, exp := range r.revoked {
+		if exp.Before(now) {
+			delete(r.revoked, k)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.revoked[jti]
+	return ok, nil
+}
+
+// insertRefreshToken enforces the token_hash unique constraint; callers hold mu
+func (r *MemoryRepository) insertRefreshToken(rt *RefreshToken) error {
+	for _, existing := range r.refreshTokens {
+		if existing.TokenHash == rt.TokenHash {
+			return errUniqueViolation
+		}
+	}
+
+	r.nextTokenID++
+	rt.ID = r.nextTokenID
+	rt.CreatedAt = time.Now()
+	r.refreshTokens[rt.ID] = *rt
+	return nil
+}
+
+// revokeRefreshTokens revokes matching refresh tokens, their live access tokens
+// and their sessions; callers hold mu
+func (r *MemoryRepository) revokeRefreshTokens(match func(RefreshToken) bool) {
+	now := time.Now()
+	families := map[string]bool{}
+	for id, rt := range r.refreshTokens {
+		if !match(rt) {
+			continue
+		}
+		families[rt.FamilyID] = true
+		if rt.AccessExpiresAt.After(now) {
+			if _, ok := r.revoked[rt.AccessJTI]; !ok {
+				r.revoked[rt.AccessJTI] = rt.AccessExpiresAt
+			}
+		}
+		if !rt.RevokedAt.Valid {
+			rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			r.refreshTokens[id] = rt
+		}
+	}
+
+	for id, sess := range r.sessions {
+		if families[sess.FamilyID] && !sess.RevokedAt.Valid {
+			sess.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			r.sessions[id] = sess
+		}
+	}
+}
+
+// Email verification methods
+
+func (r *MemoryRepository) CreateEmailVerification(ctx context.Context, jti string, userID uint64, email string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, v := range r.verifications {
+		if v.userID == userID && !v.used {
+			delete(r.verifications, k)
+		}
+	}
+	if _, ok := r.verifications[jti]; ok {
+		return errUniqueViolation
+	}
+	r.verifications[jti] = emailVerification{userID: userID, email: email, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *MemoryRepository) ConsumeEmailVerification(ctx context.Context, jti string, userID uint64, email string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.verifications[jti]
+	if !ok || v.used || v.userID != userID || v.email != email || !v.expiresAt.After(time.Now()) {
+		return false, nil
+	}
+	u, ok := r.users[userID]
+	if !ok || u.Email != email {
+		return false, nil
+	}
+
+	v.used = true
+	r.verifications[jti] = v
+	u.EmailVerified = true
+	u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	r.users[userID] = u
+	return true, nil
+}
+
+func (r *MemoryRepository) IsEmailVerified(ctx context.Context, userID uint64) (bool, error) {
+	u, err := r.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return u.EmailVerified, nil
+}
+
+// Password reset methods
+
+func (r *MemoryRepository) CreatePasswordReset(ctx context.Context, userID uint64, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, v := range r.resets {
+		if v.userID == userID && !v.used {
+			delete(r.resets, k)
+		}
+	}
+	if _, ok := r.resets[tokenHash]; ok {
+		return errUniqueViolation
+	}
+	r.resets[tokenHash] = passwordReset{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *MemoryRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v, ok := r.resets[tokenHash]
+	if !ok || v.used || !v.expiresAt.After(time.Now()) {
+		return 0, sql.ErrNoRows
+	}
+	u, ok := r.users[v.userID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+
+	v.used = true
+	r.resets[tokenHash] = v
+	u.Password = passwordHash
+	u.UpdatedAt = time.Now()
+	r.users[v.userID] = u
+	return v.userID, nil
+}
+
+// Login attempt methods
+
+func (r *MemoryRepository) LoginLockedUntil(ctx context.Context, keys []string) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var until time.Time
+	for _, key := range keys {
+		if a, ok := r.loginAttempts[key]; ok && a.lockedUntil.After(until) {
+			until = a.lockedUntil
+		}
+	}
+	return until, nil
+}
+
+func (r *MemoryRepository) RecordLoginFailure(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.loginAttempts[key]
+	if !ok || a.updatedAt.Before(windowStart) {
+		a.failures = 0
+	}
+	a.failures++
+	a.updatedAt = time.Now()
+	r.loginAttempts[key] = a
+	return a.failures, nil
+}
+
+func (r *MemoryRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if a, ok := r.loginAttempts[key]; ok {
+		a.lockedUntil = until
+		r.loginAttempts[key] = a
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ClearLoginFailures(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.loginAttempts, key)
+	return nil
+}
+
+func (r *MemoryRepository) PurgeLoginAttempts(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	now := time.Now()
+	for key, a := range r.loginAttempts {
+		if a.updatedAt.Before(before) && a.lockedUntil.Before(now) {
+			delete(r.loginAttempts, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// API key methods
+
+func (r *MemoryRepository) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[key.UserID]; !ok {
+		return errForeignKeyViolation
+	}
+	for _, existing := range r.apiKeys {
+		if existing.KeyHash == key.KeyHash {
+			return errUniqueViolation
+		}
+	}
+
+	r.nextAPIKeyID++
+	key.ID = r.nextAPIKeyID
+	key.CreatedAt = time.Now()
+	stored := *key
+	stored.Scopes = slices.Clone(key.Scopes)
+	r.apiKeys[key.ID] = stored
+	return nil
+}
+
+func (r *MemoryRepository) ListAPIKeys(ctx context.Context, userID uint64) ([]APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := []APIKey{}
+	for _, k := range r.apiKeys {
+		if k.UserID == userID && !k.RevokedAt.Valid {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID > keys[j].ID })
+	return keys, nil
+}
+
+func (r *MemoryRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.apiKeys {
+		if k.KeyHash == keyHash {
+			return &k, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) RevokeAPIKey(ctx context.Context, id, userID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.apiKeys[id]
+	if !ok || k.UserID != userID || k.RevokedAt.Valid {
+		return false, nil
+	}
+	k.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	r.apiKeys[id] = k
+	return true, nil
+}
+
+func (r *MemoryRepository) TouchAPIKey(ctx context.Context, id uint64, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.apiKeys[id]
+	if !ok || (k.LastUsedAt.Valid && !k.LastUsedAt.Time.Before(usedAt.Add(-time.Minute))) {
+		return nil
+	}
+	k.LastUsedAt = sql.NullTime{Time: usedAt, Valid: true}
+	r.apiKeys[id] = k
+	return nil
+}
+
+// OpenID Connect methods
+
+func (r *MemoryRepository) CreateOIDCState(ctx context.Context, stateHash string, state *OIDCState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.oidcStates[stateHash]; ok {
+		return errUniqueViolation
+	}
+	r.oidcStates[stateHash] = *state
+	return nil
+}
+
+func (r *MemoryRepository) ConsumeOIDCState(ctx context.Context, stateHash, provider string) (*OIDCState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.oidcStates[stateHash]
+	if !ok || state.Provider != provider || !state.ExpiresAt.After(time.Now()) {
+		return nil, sql.ErrNoRows
+	}
+	delete(r.oidcStates, stateHash)
+	return &state, nil
+}
+
+func (r *MemoryRepository) PurgeOIDCStates(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for k, v := range r.oidcStates {
+		if v.ExpiresAt.Before(before) {
+			delete(r.oidcStates, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemoryRepository) GetIdentity(ctx context.Context, provider, subject string) (*Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) CreateIdentity(ctx context.Context, identity *Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insertIdentity(identity)
+}
+
+func (r *MemoryRepository) CreateUserWithIdentity(ctx context.Context, email, hashedPassword, name string, emailVerified bool, identity *Identity) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			return 0, errUniqueViolation
+		}
+	}
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return 0, errUniqueViolation
+		}
+	}
+
+	r.nextUserID++
+	now := time.Now()
+	u := User{
+		ID:            r.nextUserID,
+		Email:         email,
+		Password:      hashedPassword,
+		Name:          name,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Role:          "user",
+		EmailVerified: emailVerified,
+	}
+	if emailVerified {
+		u.EmailVerifiedAt = sql.NullTime{Time: now, Valid: true}
+	}
+	r.users[u.ID] = u
+
+	identity.UserID = u.ID
+	return u.ID, r.insertIdentity(identity)
+}
+
+// insertIdentity enforces the foreign key and the (provider, subject) unique constraint; callers hold mu
+func (r *MemoryRepository) insertIdentity(identity *Identity) error {
+	if _, ok := r.users[identity.UserID]; !ok {
+		return errForeignKeyViolation
+	}
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return errUniqueViolation
+		}
+	}
+
+	r.nextIdentity++
+	identity.ID = r.nextIdentity
+	identity.CreatedAt = time.Now()
+	r.identities[identity.ID] = *identity
+	return nil
+}
+
+// Two-factor methods
+
+func (r *MemoryRepository) SetTOTPSecret(ctx context.Context, userID uint64, secret string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || u.TOTPEnabledAt.Valid {
+		return false, nil
+	}
+	u.TOTPSecret = sql.NullString{String: secret, Valid: true}
+	u.TOTPLastStep = sql.NullInt64{}
+	r.users[userID] = u
+	return true, nil
+}
+
+func (r *MemoryRepository) EnableTOTP(ctx context.Context, userID uint64, step int64, codeHashes []string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || u.TOTPEnabledAt.Valid || !u.TOTPSecret.Valid {
+		return false, nil
+	}
+	u.TOTPEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	u.TOTPLastStep = sql.NullInt64{Int64: step, Valid: true}
+	r.users[userID] = u
+	r.replaceRecoveryCodes(userID, codeHashes)
+	return true, nil
+}
+
+func (r *MemoryRepository) DisableTOTP(ctx context.Context, userID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[userID]; ok {
+		u.TOTPSecret = sql.NullString{}
+		u.TOTPEnabledAt = sql.NullTime{}
+		u.TOTPLastStep = sql.NullInt64{}
+		r.users[userID] = u
+	}
+	delete(r.recoveryCodes, userID)
+	return nil
+}
+
+func (r *MemoryRepository) UseTOTPStep(ctx context.Context, userID uint64, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || (u.TOTPLastStep.Valid && u.TOTPLastStep.Int64 >= step) {
+		return false, nil
+	}
+	u.TOTPLastStep = sql.NullInt64{Int64: step, Valid: true}
+	r.users[userID] = u
+	return true, nil
+}
+
+func (r *MemoryRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userID]; !ok {
+		return errForeignKeyViolation
+	}
+	r.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+func (r *MemoryRepository) ConsumeRecoveryCode(ctx context.Context, userID uint64, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used, ok := r.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	r.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (r *MemoryRepository) CountRecoveryCodes(ctx context.Context, userID uint64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.recoveryCodes[userID] {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// replaceRecoveryCodes swaps in a fresh set of unused codes; callers hold mu
+func (r *MemoryRepository) replaceRecoveryCodes(userID uint64, codeHashes []string) {
+	codes := make(map[string]bool, len(codeHashes))
+	for _, h := range codeHashes {
+		codes[h] = false
+	}
+	r.recoveryCodes[userID] = codes
+}
+
+// Session methods
+
+func (r *MemoryRepository) CreateSession(ctx context.Context, sess *Session, rt *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[sess.UserID]; !ok {
+		return errForeignKeyViolation
+	}
+	for _, existing := range r.sessions {
+		if existing.FamilyID == sess.FamilyID {
+			return errUniqueViolation
+		}
+	}
+	if err := r.insertRefreshToken(rt); err != nil {
+		return err
+	}
+
+	r.nextSession++
+	sess.ID = r.nextSession
+	sess.CreatedAt = time.Now()
+	sess.LastSeenAt = sess.CreatedAt
+	r.sessions[sess.ID] = *sess
+	return nil
+}
+
+func (r *MemoryRepository) GetSessionByFamily(ctx context.Context, familyID string) (*Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, sess := range r.sessions {
+		if sess.FamilyID == familyID {
+			return &sess, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) ListSessions(ctx context.Context, userID uint64, activeSince time.Time) ([]Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := []Session{}
+	for _, sess := range r.sessions {
+		if sess.UserID == userID && !sess.RevokedAt.Valid && sess.LastSeenAt.After(activeSince) {
+			sessions = append(sessions, sess)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, nil
+}
+
+func (r *MemoryRepository) TouchSession(ctx context.Context, id, userID uint64, seenAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sess, ok := r.sessions[id]
+	if !ok || sess.UserID != userID || sess.RevokedAt.Valid {
+		return false, nil
+	}
+	if sess.LastSeenAt.Before(seenAt.Add(-time.Minute)) {
+		sess.LastSeenAt = seenAt
+		r.sessions[id] = sess
+	}
+	return true, nil
+}
+
+func (r *MemoryRepository) RevokeSession(ctx context.Context, id, userID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sess, ok := r.sessions[id]
+	if !ok || sess.UserID != userID || sess.RevokedAt.Valid {
+		return false, nil
+	}
+	r.revokeRefreshTokens(func(rt RefreshToken) bool { return rt.FamilyID == sess.FamilyID })
+	return true, nil
+}
+
+func (r *MemoryRepository) PurgeSessions(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, sess := range r.sessions {
+		if (sess.RevokedAt.Valid && sess.RevokedAt.Time.Before(before)) || sess.LastSeenAt.Before(before) {
+			delete(r.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -1,6 +1,11 @@
 package user
 
-import "time"
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
 
 // User represents the user domain model
 type User struct {
@@ -10,4 +15,90 @@ type User struct {
 	Name      string    `db:"name" json:"name"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+
+	EmailVerified   bool         `db:"email_verified" json:"email_verified"`
+	EmailVerifiedAt sql.NullTime `db:"email_verified_at" json:"-"`
+	DeletedAt       sql.NullTime `db:"deleted_at" json:"-"` // set while the account awaits deletion
+
+	Role       string       `db:"role" json:"role"`
+	DisabledAt sql.NullTime `db:"disabled_at" json:"-"` // set by an admin
+
+	TOTPSecret    sql.NullString `db:"totp_secret" json:"-"`
+	TOTPEnabledAt sql.NullTime   `db:"totp_enabled_at" json:"-"` // 2FA is on once enrolment is confirmed
+	TOTPLastStep  sql.NullInt64  `db:"totp_last_step" json:"-"`
+}
+
+// UserFilter narrows the admin user listing
+type UserFilter struct {
+	Query    string // matches email or name
+	Role     *string
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+// RefreshToken represents a stored (hashed) refresh token.
+// Tokens rotated from the same login share a FamilyID.
+type RefreshToken struct {
+	ID              uint64        `db:"id"`
+	UserID          uint64        `db:"user_id"`
+	TokenHash       string        `db:"token_hash"`
+	FamilyID        string        `db:"family_id"`
+	AccessJTI       string        `db:"access_jti"` // jti of the access token issued alongside
+	AccessExpiresAt time.Time     `db:"access_expires_at"`
+	ExpiresAt       time.Time     `db:"expires_at"`
+	RevokedAt       sql.NullTime  `db:"revoked_at"`
+	ReplacedBy      sql.NullInt64 `db:"replaced_by"`
+	CreatedAt       time.Time     `db:"created_at"`
+}
+
+// Session is one login on one device. It lives as long as the refresh token
+// family the login started.
+type Session struct {
+	ID         uint64       `db:"id"`
+	UserID     uint64       `db:"user_id"`
+	FamilyID   string       `db:"family_id"`
+	UserAgent  string       `db:"user_agent"`
+	IP         string       `db:"ip"`
+	CreatedAt  time.Time    `db:"created_at"`
+	LastSeenAt time.Time    `db:"last_seen_at"`
+	RevokedAt  sql.NullTime `db:"revoked_at"`
+}
+
+// ClientInfo describes the client a login comes from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// APIKey is a personal API key. Only the hash of the key is stored.
+type APIKey struct {
+	ID         uint64         `db:"id"`
+	UserID     uint64         `db:"user_id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	ExpiresAt  sql.NullTime   `db:"expires_at"`
+	LastUsedAt sql.NullTime   `db:"last_used_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// Identity links a user to an account at an OpenID provider
+type Identity struct {
+	ID        uint64         `db:"id"`
+	UserID    uint64         `db:"user_id"`
+	Provider  string         `db:"provider"`
+	Subject   string         `db:"subject"`
+	Email     sql.NullString `db:"email"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// OIDCState is a pending OpenID authorization request
+type OIDCState struct {
+	Provider     string    `db:"provider"`
+	CodeVerifier string    `db:"code_verifier"`
+	Nonce        string    `db:"nonce"`
+	ExpiresAt    time.Time `db:"expires_at"`
 }
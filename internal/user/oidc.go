@@ -0,0 +1,173 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go-saas-api/internal/oidc"
+	"go-saas-api/pkg/token"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+// RegisterOIDCProvider enables login with p under /auth/oidc/<name>
+func (s *Service) RegisterOIDCProvider(p *oidc.Provider) {
+	if s.oidcProviders == nil {
+		s.oidcProviders = map[string]*oidc.Provider{}
+	}
+	s.oidcProviders[p.Name()] = p
+}
+
+// OIDCProviders returns the names of the configured providers
+func (s *Service) OIDCProviders() []string {
+	names := make([]string, 0, len(s.oidcProviders))
+	for name := range s.oidcProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartOIDCLogin creates a single-use state with a PKCE verifier and nonce and
+// returns the provider URL to send the browser to. The client should keep the
+// returned state and only complete a callback that carries the same one.
+func (s *Service) StartOIDCLogin(ctx context.Context, provider string) (*OIDCStartResponse, error) {
+	p, ok := s.oidcProviders[provider]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	state, err := token.Random(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := token.Random(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := token.Random(48) // 64 characters, within PKCE's 43-128
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateOIDCState(ctx, token.Hash(state), &OIDCState{
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCStartResponse{AuthorizationURL: authURL, State: state}, nil
+}
+
+// CompleteOIDCLogin redeems the code the provider redirected back with, then
+// logs in the linked user, linking or creating one on first login
+func (s *Service) CompleteOIDCLogin(ctx context.Context, provider string, req OIDCCallbackReq, client ClientInfo) (*LoginResponse, error) {
+	p, ok := s.oidcProviders[provider]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	state, err := s.repo.ConsumeOIDCState(ctx, token.Hash(req.State), provider)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, err
+	}
+
+	idToken, err := p.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		log.Printf("oidc %s: exchange code: %v", provider, err)
+		return nil, ErrOIDCLoginFailed
+	}
+	claims, err := p.VerifyIDToken(ctx, idToken, state.Nonce)
+	if err != nil {
+		log.Printf("oidc %s: %v", provider, err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.oidcUser(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.finishLogin(ctx, user, client)
+}
+
+// oidcUser finds the user linked to the identity. On first login it links the
+// account with the same verified email, or creates a new one.
+func (s *Service) oidcUser(ctx context.Context, provider string, claims *oidc.Claims) (*User, error) {
+	identity, err := s.repo.GetIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		return s.repo.GetByID(ctx, identity.UserID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Only a provider-verified email may claim or create an account
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	identity = &Identity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    sql.NullString{String: claims.Email, Valid: true},
+	}
+
+	existing, err := s.repo.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Whoever registered an unverified address may not own it; linking would
+		// let them keep access with their password
+		if !existing.EmailVerified {
+			return nil, ErrOIDCAccountExists
+		}
+		identity.UserID = existing.ID
+		if err := s.repo.CreateIdentity(ctx, identity); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	case err != sql.ErrNoRows:
+		return nil, err
+	}
+
+	// New account; the random password can be replaced via forgot-password
+	secret, err := token.Random(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := hashPassword(secret)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.repo.CreateUserWithIdentity(ctx, claims.Email, hashedPassword, oidcDisplayName(claims), true, identity)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// oidcDisplayName falls back to the email's local part when the provider sends no name
+func oidcDisplayName(claims *oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if len(name) < 2 {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if len(name) > 120 {
+		name = name[:120]
+	}
+	return name
+}
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"go-saas-api/internal/mailer"
+	"go-saas-api/pkg/token"
+)
+
+const passwordResetTTL = time.Hour
+
+// ForgotPassword emails a password reset link if the address belongs to a user.
+// The lookup and mail happen after the response, so neither the answer nor its
+// timing reveals whether the email exists.
+func (s *Service) ForgotPassword(req ForgotPasswordReq) {
+	s.runInBackground(func(ctx context.Context) {
+		if err := s.sendPasswordReset(ctx, req.Email); err != nil {
+			log.Printf("send password reset email: %v", err)
+		}
+	})
+}
+
+// sendPasswordReset stores a reset token for the user with email and mails a
+// link to it. Unknown emails are ignored.
+func (s *Service) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	raw, err := token.Random(32)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreatePasswordReset(ctx, user.ID, token.Hash(raw), time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+
+	link := s.appBaseURL + "/reset-password?token=" + url.QueryEscape(raw)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone requested a password reset for your account. Open the link below to choose a new password:\n\n%s\n\n"+
+			"The link expires in %d minutes and can be used once. If you did not request this, you can ignore this email.\n",
+			user.Name, link, int(passwordResetTTL.Minutes())),
+	})
+	if err != nil {
+		return fmt.Errorf("user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and logs out every existing session
+func (s *Service) ResetPassword(ctx context.Context, req ResetPasswordReq) error {
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.repo.ResetPassword(ctx, token.Hash(req.Token), hashedPassword)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	return s.repo.RevokeAllUserTokens(ctx, userID)
+}
@@ -0,0 +1,158 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// GetMe returns the current user's profile
+func (s *Service) GetMe(ctx context.Context, userID uint64) (*UserResponse, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserResponse(user), nil
+}
+
+// UpdateMe updates the current user's name and/or email.
+// A new email must be verified again; a verification email is sent to it.
+func (s *Service) UpdateMe(ctx context.Context, userID uint64, req UpdateMeReq) (*UserResponse, error) {
+	if req.Name == nil && req.Email == nil {
+		return nil, ErrNoFieldsToUpdate
+	}
+
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name != nil {
+		trimmed := strings.TrimSpace(*name)
+		name = &trimmed
+	}
+
+	email := req.Email
+	if email != nil && strings.EqualFold(*email, user.Email) {
+		email = nil // unchanged, keep the verification status
+	}
+	if email != nil {
+		exists, err := s.repo.EmailExists(ctx, *email)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrEmailTaken
+		}
+	}
+
+	updated, err := s.repo.UpdateProfile(ctx, userID, name, email)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrUserNotFound
+	}
+
+	user, err = s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if email != nil {
+		if err := s.sendVerification(ctx, user.ID, user.Email, user.Name); err != nil {
+			log.Printf("send verification email to user %d: %v", user.ID, err)
+		}
+	}
+	return toUserResponse(user), nil
+}
+
+// DeleteMe marks the account for deletion and logs out every session.
+// Logging in again within the grace period cancels the deletion.
+func (s *Service) DeleteMe(ctx context.Context, userID uint64, req DeleteMeReq) error {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return ErrPasswordIncorrect
+	}
+
+	deleted, err := s.repo.SoftDelete(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrUserNotFound
+	}
+
+	return s.repo.RevokeAllUserTokens(ctx, userID)
+}
+
+// PurgeDeletedUsers hard-deletes accounts whose grace period has passed
+func (s *Service) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	return s.repo.PurgeDeleted(ctx, time.Now().Add(-s.deletionGrace))
+}
+
+// RunPurger calls PurgeDeletedUsers and clears stale login attempts, OpenID
+// states and sessions every interval until ctx is cancelled
+func (s *Service) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.PurgeDeletedUsers(ctx)
+			if err != nil {
+				log.Printf("purge deleted users: %v", err)
+			} else if n > 0 {
+				log.Printf("🗑️  Purged %d deleted user(s)", n)
+			}
+
+			if _, err := s.repo.PurgeLoginAttempts(ctx, time.Now().Add(-loginFailureWindow)); err != nil {
+				log.Printf("purge login attempts: %v", err)
+			}
+			if _, err := s.repo.PurgeOIDCStates(ctx, time.Now()); err != nil {
+				log.Printf("purge oidc states: %v", err)
+			}
+			if _, err := s.repo.PurgeSessions(ctx, time.Now().Add(-refreshTokenTTL)); err != nil {
+				log.Printf("purge sessions: %v", err)
+			}
+		}
+	}
+}
+
+// activeUser loads a user that is not pending deletion
+func (s *Service) activeUser(ctx context.Context, userID uint64) (*User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.DeletedAt.Valid {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func toUserResponse(u *User) *UserResponse {
+	return &UserResponse{
+		ID:            u.ID,
+		Email:         u.Email,
+		Name:          u.Name,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		TwoFactor:     u.TOTPEnabledAt.Valid,
+	}
+}
@@ -2,14 +2,87 @@ package user
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// UserRepository is the storage used by Service.
+// Repository (Postgres) and MemoryRepository implement it; lookups that find
+// nothing return sql.ErrNoRows.
+type UserRepository interface {
+	Create(ctx context.Context, email, hashedPassword, name string) (uint64, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByID(ctx context.Context, id uint64) (*User, error)
+	UpdatePassword(ctx context.Context, id uint64, hashedPassword string) error
+	EmailExists(ctx context.Context, email string) (bool, error)
+	UpdateProfile(ctx context.Context, id uint64, name, email *string) (bool, error)
+	SoftDelete(ctx context.Context, id uint64) (bool, error)
+	Restore(ctx context.Context, id uint64) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	ListUsers(ctx context.Context, filter UserFilter) ([]User, int, error)
+	UpdateUserAdmin(ctx context.Context, id uint64, role *string, disabled *bool) (bool, error)
+
+	CreateRefreshToken(ctx context.Context, rt *RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldID uint64, next *RefreshToken) error
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	RevokeAllUserTokens(ctx context.Context, userID uint64) error
+
+	CreateSession(ctx context.Context, sess *Session, rt *RefreshToken) error
+	GetSessionByFamily(ctx context.Context, familyID string) (*Session, error)
+	ListSessions(ctx context.Context, userID uint64, activeSince time.Time) ([]Session, error)
+	TouchSession(ctx context.Context, id, userID uint64, seenAt time.Time) (bool, error)
+	RevokeSession(ctx context.Context, id, userID uint64) (bool, error)
+	PurgeSessions(ctx context.Context, before time.Time) (int64, error)
+	RevokeAccessToken(ctx context.Context, jti string, userID uint64, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+
+	CreateEmailVerification(ctx context.Context, jti string, userID uint64, email string, expiresAt time.Time) error
+	ConsumeEmailVerification(ctx context.Context, jti string, userID uint64, email string) (bool, error)
+	IsEmailVerified(ctx context.Context, userID uint64) (bool, error)
+
+	CreatePasswordReset(ctx context.Context, userID uint64, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uint64, error)
+
+	LoginLockedUntil(ctx context.Context, keys []string) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, key string, windowStart time.Time) (int, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ClearLoginFailures(ctx context.Context, key string) error
+	PurgeLoginAttempts(ctx context.Context, before time.Time) (int64, error)
+
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	ListAPIKeys(ctx context.Context, userID uint64) ([]APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id, userID uint64) (bool, error)
+	TouchAPIKey(ctx context.Context, id uint64, usedAt time.Time) error
+
+	CreateOIDCState(ctx context.Context, stateHash string, state *OIDCState) error
+	ConsumeOIDCState(ctx context.Context, stateHash, provider string) (*OIDCState, error)
+	PurgeOIDCStates(ctx context.Context, before time.Time) (int64, error)
+	GetIdentity(ctx context.Context, provider, subject string) (*Identity, error)
+	CreateIdentity(ctx context.Context, identity *Identity) error
+	CreateUserWithIdentity(ctx context.Context, email, hashedPassword, name string, emailVerified bool, identity *Identity) (uint64, error)
+
+	SetTOTPSecret(ctx context.Context, userID uint64, secret string) (bool, error)
+	EnableTOTP(ctx context.Context, userID uint64, step int64, codeHashes []string) (bool, error)
+	DisableTOTP(ctx context.Context, userID uint64) error
+	UseTOTPStep(ctx context.Context, userID uint64, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint64, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID uint64, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uint64) (int, error)
+}
+
 type Repository struct {
 	db *sqlx.DB
 }
 
+var _ UserRepository = (*Repository)(nil)
+
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{db: db}
 }
@@ -29,7 +102,9 @@ func (r *Repository) Create(ctx context.Context, email, hashedPassword, name str
 func (r *Repository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	var u User
 	err := r.db.GetContext(ctx, &u,
-		`SELECT id, email, password, name, created_at, updated_at FROM users WHERE email = $1`,
+		`SELECT id, email, password, name, created_at, updated_at, email_verified, email_verified_at, deleted_at,
+			role, disabled_at, totp_secret, totp_enabled_at, totp_last_step
+		FROM users WHERE email = $1`,
 		email,
 	)
 	if err != nil {
@@ -41,7 +116,9 @@ func (r *Repository) GetByEmail(ctx context.Context, email string) (*User, error
 func (r *Repository) GetByID(ctx context.Context, id uint64) (*User, error) {
 	var u User
 	err := r.db.GetContext(ctx, &u,
-		`SELECT id, email, password, name, created_at, updated_at FROM users WHERE id = $1`,
+		`SELECT id, email, password, name, created_at, updated_at, email_verified, email_verified_at, deleted_at,
+			role, disabled_at, totp_secret, totp_enabled_at, totp_last_step
+		FROM users WHERE id = $1`,
 		id,
 	)
 	if err != nil {
@@ -50,12 +127,26 @@ func (r *Repository) GetByID(ctx context.Context, id uint64) (*User, error) {
 	return &u, nil
 }
 
+// UpdatePassword sets the user's password and revokes all their tokens in one
+// transaction, so no session outlives the old password
 func (r *Repository) UpdatePassword(ctx context.Context, id uint64, hashedPassword string) error {
-	_, err := r.db.ExecContext(ctx,
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
 		`UPDATE users SET password = $1 WHERE id = $2`,
 		hashedPassword, id,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := revokeTokens(ctx, tx, `user_id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *Repository) EmailExists(ctx context.Context, email string) (bool, error) {
@@ -66,3 +157,912 @@ func (r *Repository) EmailExists(ctx context.Context, email string) (bool, error
 	)
 	return count > 0, err
 }
+
+// UpdateProfile updates the given fields. Changing the email resets its verification.
+// Returns false if the user does not exist.
+func (r *Repository) UpdateProfile(ctx context.Context, id uint64, name, email *string) (bool, error) {
+	var sets []string
+	var args []interface{}
+	paramIdx := 1
+
+	if name != nil {
+		sets = append(sets, fmt.Sprintf("name = $%d", paramIdx))
+		args = append(args, *name)
+		paramIdx++
+	}
+	if email != nil {
+		sets = append(sets, fmt.Sprintf("email = $%d", paramIdx), "email_verified = FALSE", "email_verified_at = NULL")
+		args = append(args, *email)
+		paramIdx++
+	}
+	if len(sets) == 0 {
+		return true, nil
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id = $%d", strings.Join(sets, ", "), paramIdx)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// SoftDelete marks the account for deletion. Returns false if it does not exist or is already marked.
+func (r *Repository) SoftDelete(ctx context.Context, id uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// Restore cancels a pending deletion
+func (r *Repository) Restore(ctx context.Context, id uint64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET deleted_at = NULL WHERE id = $1`, id)
+	return err
+}
+
+// PurgeDeleted hard-deletes accounts marked for deletion before the given time.
+// ON DELETE CASCADE removes their tokens, memberships and personal workspaces;
+// places and categories they created in shared workspaces stay, with user_id
+// set to NULL. A shared workspace losing all its owners is handed to its
+// longest-standing remaining member (editors first), or deleted if none remain.
+func (r *Repository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the affected workspaces, as owner changes in the workspace module do
+	_, err = tx.ExecContext(ctx,
+		`SELECT id FROM workspaces WHERE id IN (
+			SELECT workspace_id FROM workspace_members WHERE user_id IN (SELECT id FROM users WHERE deleted_at < $1)
+		) ORDER BY id FOR UPDATE`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Shared workspaces with no one left
+	_, err = tx.ExecContext(ctx,
+		`WITH purged AS (SELECT id FROM users WHERE deleted_at < $1)
+		DELETE FROM workspaces w
+		WHERE w.personal_user_id IS NULL
+			AND EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = w.id AND m.user_id IN (SELECT id FROM purged))
+			AND NOT EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = w.id AND m.user_id NOT IN (SELECT id FROM purged))`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Shared workspaces that would be left without an owner
+	_, err = tx.ExecContext(ctx,
+		`WITH purged AS (SELECT id FROM users WHERE deleted_at < $1),
+		orphaned AS (
+			SELECT w.id FROM workspaces w
+			WHERE w.personal_user_id IS NULL
+				AND EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = w.id AND m.role = 'owner' AND m.user_id IN (SELECT id FROM purged))
+				AND NOT EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = w.id AND m.role = 'owner' AND m.user_id NOT IN (SELECT id FROM purged))
+		),
+		heirs AS (
+			SELECT DISTINCT ON (m.workspace_id) m.workspace_id, m.user_id
+			FROM workspace_members m
+			WHERE m.workspace_id IN (SELECT id FROM orphaned) AND m.user_id NOT IN (SELECT id FROM purged)
+			ORDER BY m.workspace_id, CASE m.role WHEN 'editor' THEN 0 ELSE 1 END, m.created_at, m.user_id
+		)
+		UPDATE workspace_members m SET role = 'owner'
+		FROM heirs h WHERE m.workspace_id = h.workspace_id AND m.user_id = h.user_id`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// ListUsers returns one page of users matching filter and the total number of matches
+func (r *Repository) ListUsers(ctx context.Context, filter UserFilter) ([]User, int, error) {
+	var conds []string
+	var args []interface{}
+	paramIdx := 1
+
+	if filter.Query != "" {
+		conds = append(conds, fmt.Sprintf("(email ILIKE $%d OR name ILIKE $%d)", paramIdx, paramIdx))
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		paramIdx++
+	}
+	if filter.Role != nil {
+		conds = append(conds, fmt.Sprintf("role = $%d", paramIdx))
+		args = append(args, *filter.Role)
+		paramIdx++
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			conds = append(conds, "disabled_at IS NOT NULL")
+		} else {
+			conds = append(conds, "disabled_at IS NULL")
+		}
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM users "+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id, email, password, name, created_at, updated_at, email_verified, email_verified_at, deleted_at,
+			role, disabled_at, totp_secret, totp_enabled_at, totp_last_step
+		FROM users %s ORDER BY id LIMIT $%d OFFSET $%d`,
+		where, paramIdx, paramIdx+1,
+	)
+	args = append(args, filter.Limit, filter.Offset)
+
+	var users []User
+	if err := r.db.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateUserAdmin changes a user's role and/or disabled state. Returns false if the user does not exist.
+func (r *Repository) UpdateUserAdmin(ctx context.Context, id uint64, role *string, disabled *bool) (bool, error) {
+	var sets []string
+	var args []interface{}
+	paramIdx := 1
+
+	if role != nil {
+		sets = append(sets, fmt.Sprintf("role = $%d", paramIdx))
+		args = append(args, *role)
+		paramIdx++
+	}
+	if disabled != nil {
+		if *disabled {
+			sets = append(sets, "disabled_at = COALESCE(disabled_at, NOW())")
+		} else {
+			sets = append(sets, "disabled_at = NULL")
+		}
+	}
+	if len(sets) == 0 {
+		return true, nil
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id = $%d", strings.Join(sets, ", "), paramIdx)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Refresh token & revocation methods
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, rt *RefreshToken) error {
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, family_id, access_jti, access_expires_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		rt.UserID, rt.TokenHash, rt.FamilyID, rt.AccessJTI, rt.AccessExpiresAt, rt.ExpiresAt,
+	).Scan(&rt.ID)
+}
+
+func (r *Repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var rt RefreshToken
+	err := r.db.GetContext(ctx, &rt,
+		`SELECT id, user_id, token_hash, family_id, access_jti, access_expires_at, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens WHERE token_hash = $1`,
+		tokenHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// RotateRefreshToken stores next and marks oldID as replaced by it.
+// Returns sql.ErrNoRows if oldID was already revoked (e.g. a concurrent rotation).
+func (r *Repository) RotateRefreshToken(ctx context.Context, oldID uint64, next *RefreshToken) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, family_id, access_jti, access_expires_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		next.UserID, next.TokenHash, next.FamilyID, next.AccessJTI, next.AccessExpiresAt, next.ExpiresAt,
+	).Scan(&next.ID)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW(), replaced_by = $1 WHERE id = $2 AND revoked_at IS NULL`,
+		next.ID, oldID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// RevokeTokenFamily revokes every refresh token of a family and the access tokens issued with them
+func (r *Repository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	return r.revokeRefreshTokens(ctx, `family_id = $1`, familyID)
+}
+
+// RevokeAllUserTokens revokes every refresh and access token of a user
+func (r *Repository) RevokeAllUserTokens(ctx context.Context, userID uint64) error {
+	return r.revokeRefreshTokens(ctx, `user_id = $1`, userID)
+}
+
+func (r *Repository) revokeRefreshTokens(ctx context.Context, where string, arg any) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revokeTokens(ctx, tx, where, arg); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revokeTokens revokes the refresh tokens, access tokens and sessions matching
+// where, which may only refer to user_id and family_id
+func revokeTokens(ctx context.Context, tx *sqlx.Tx, where string, arg any) error {
+	// Access tokens are stateless, so block the ones that have not expired yet
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		SELECT access_jti, user_id, access_expires_at FROM refresh_tokens
+		WHERE `+where+` AND access_expires_at > NOW()
+		ON CONFLICT (jti) DO NOTHING`,
+		arg,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE `+where+` AND revoked_at IS NULL`,
+		arg,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = NOW() WHERE `+where+` AND revoked_at IS NULL`,
+		arg,
+	)
+	return err
+}
+
+// RevokeAccessToken blocks a single access token until it expires
+func (r *Repository) RevokeAccessToken(ctx context.Context, jti string, userID uint64, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`,
+		jti, userID, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Entries past their expiry are useless, drop them while we're here
+	_, err = r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < NOW()`)
+	return err
+}
+
+// IsTokenRevoked implements middleware.RevocationChecker
+func (r *Repository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`,
+		jti,
+	)
+	return exists, err
+}
+
+// Email verification methods
+
+// CreateEmailVerification stores a new verification token, invalidating the user's unused ones
+func (r *Repository) CreateEmailVerification(ctx context.Context, jti string, userID uint64, email string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM email_verification_tokens WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO email_verification_tokens (jti, user_id, email, expires_at) VALUES ($1, $2, $3, $4)`,
+		jti, userID, email, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeEmailVerification marks the token used and the user's email verified.
+// Returns false if the token is unknown, used, expired, or the user's email has changed since.
+func (r *Repository) ConsumeEmailVerification(ctx context.Context, jti string, userID uint64, email string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE email_verification_tokens SET used_at = NOW()
+		WHERE jti = $1 AND user_id = $2 AND email = $3 AND used_at IS NULL AND expires_at > NOW()`,
+		jti, userID, email,
+	)
+	if err != nil {
+		return false, err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return false, err
+	}
+
+	res, err = tx.ExecContext(ctx,
+		`UPDATE users SET email_verified = TRUE, email_verified_at = NOW() WHERE id = $1 AND email = $2`,
+		userID, email,
+	)
+	if err != nil {
+		return false, err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// IsEmailVerified implements middleware.VerificationChecker
+func (r *Repository) IsEmailVerified(ctx context.Context, userID uint64) (bool, error) {
+	var verified bool
+	err := r.db.GetContext(ctx, &verified, `SELECT email_verified FROM users WHERE id = $1`, userID)
+	return verified, err
+}
+
+// Password reset methods
+
+// CreatePasswordReset stores a new reset token hash, invalidating the user's unused ones
+func (r *Repository) CreatePasswordReset(ctx context.Context, userID uint64, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userID, tokenHash, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetPassword consumes a reset token and sets the new password in one transaction.
+// Returns the user ID, or sql.ErrNoRows if the token is unknown, used or expired.
+func (r *Repository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (uint64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID uint64
+	err = tx.GetContext(ctx, &userID,
+		`UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`,
+		tokenHash,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET password = $1 WHERE id = $2`,
+		passwordHash, userID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// Login attempt methods
+
+// LoginLockedUntil returns the latest lockout among keys, or the zero time if none is locked
+func (r *Repository) LoginLockedUntil(ctx context.Context, keys []string) (time.Time, error) {
+	var until sql.NullTime
+	err := r.db.GetContext(ctx, &until,
+		`SELECT MAX(locked_until) FROM login_attempts WHERE key = ANY($1)`,
+		pq.StringArray(keys),
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return until.Time, nil
+}
+
+// RecordLoginFailure increments the failure count for key and returns it.
+// Failures last recorded before windowStart are forgotten first.
+func (r *Repository) RecordLoginFailure(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	var failures int
+	err := r.db.GetContext(ctx, &failures,
+		`INSERT INTO login_attempts (key, failures, updated_at) VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.updated_at < $2 THEN 1 ELSE login_attempts.failures + 1 END,
+			updated_at = NOW()
+		RETURNING failures`,
+		key, windowStart,
+	)
+	return failures, err
+}
+
+func (r *Repository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE login_attempts SET locked_until = $1 WHERE key = $2`,
+		until, key,
+	)
+	return err
+}
+
+func (r *Repository) ClearLoginFailures(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, key)
+	return err
+}
+
+// PurgeLoginAttempts removes entries idle since before that are no longer locked
+func (r *Repository) PurgeLoginAttempts(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM login_attempts WHERE updated_at < $1 AND (locked_until IS NULL OR locked_until < NOW())`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// API key methods
+
+func (r *Repository) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		key.UserID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt,
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+// ListAPIKeys returns the user's keys that have not been revoked, newest first
+func (r *Repository) ListAPIKeys(ctx context.Context, userID uint64) ([]APIKey, error) {
+	keys := []APIKey{}
+	err := r.db.SelectContext(ctx, &keys,
+		`SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY id DESC`,
+		userID,
+	)
+	return keys, err
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	var key APIKey
+	err := r.db.GetContext(ctx, &key,
+		`SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys WHERE key_hash = $1`,
+		keyHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *Repository) RevokeAPIKey(ctx context.Context, id, userID uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// TouchAPIKey records a use of the key. Writes are skipped while last_used_at
+// is under a minute old so busy keys do not update the row on every request.
+func (r *Repository) TouchAPIKey(ctx context.Context, id uint64, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2 - INTERVAL '1 minute')`,
+		id, usedAt,
+	)
+	return err
+}
+
+// OpenID Connect methods
+
+func (r *Repository) CreateOIDCState(ctx context.Context, stateHash string, state *OIDCState) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO oidc_states (state_hash, provider, code_verifier, nonce, expires_at) VALUES ($1, $2, $3, $4, $5)`,
+		stateHash, state.Provider, state.CodeVerifier, state.Nonce, state.ExpiresAt,
+	)
+	return err
+}
+
+// ConsumeOIDCState deletes and returns a pending state of provider.
+// Returns sql.ErrNoRows if it is unknown, already used or expired.
+func (r *Repository) ConsumeOIDCState(ctx context.Context, stateHash, provider string) (*OIDCState, error) {
+	var state OIDCState
+	err := r.db.GetContext(ctx, &state,
+		`DELETE FROM oidc_states WHERE state_hash = $1 AND provider = $2 AND expires_at > NOW()
+		RETURNING provider, code_verifier, nonce, expires_at`,
+		stateHash, provider,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *Repository) PurgeOIDCStates(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM oidc_states WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *Repository) GetIdentity(ctx context.Context, provider, subject string) (*Identity, error) {
+	var identity Identity
+	err := r.db.GetContext(ctx, &identity,
+		`SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities WHERE provider = $1 AND subject = $2`,
+		provider, subject,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *Repository) CreateIdentity(ctx context.Context, identity *Identity) error {
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		identity.UserID, identity.Provider, identity.Subject, identity.Email,
+	).Scan(&identity.ID, &identity.CreatedAt)
+}
+
+// CreateUserWithIdentity creates a user and links identity to it in one transaction
+func (r *Repository) CreateUserWithIdentity(ctx context.Context, email, hashedPassword, name string, emailVerified bool, identity *Identity) (uint64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id uint64
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO users (email, password, name, email_verified, email_verified_at)
+		VALUES ($1, $2, $3, $4, CASE WHEN $4 THEN NOW() END) RETURNING id`,
+		email, hashedPassword, name, emailVerified,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	identity.UserID = id
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		identity.UserID, identity.Provider, identity.Subject, identity.Email,
+	).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// Two-factor methods
+
+// SetTOTPSecret stores a new, unconfirmed secret. Returns false if 2FA is already enabled.
+func (r *Repository) SetTOTPSecret(ctx context.Context, userID uint64, secret string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_secret = $1, totp_last_step = NULL WHERE id = $2 AND totp_enabled_at IS NULL`,
+		secret, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// EnableTOTP confirms the pending secret, records the step of the confirming
+// code and stores the first recovery codes. Returns false if there is nothing to confirm.
+func (r *Repository) EnableTOTP(ctx context.Context, userID uint64, step int64, codeHashes []string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE users SET totp_enabled_at = NOW(), totp_last_step = $1
+		WHERE id = $2 AND totp_enabled_at IS NULL AND totp_secret IS NOT NULL`,
+		step, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *Repository) DisableTOTP(ctx context.Context, userID uint64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = $1`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep records step as used. Returns false if it is not newer than the last used step.
+func (r *Repository) UseTOTPStep(ctx context.Context, userID uint64, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET totp_last_step = $1 WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`,
+		step, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID uint64, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeRecoveryCode marks an unused code as used. Returns false if there is none.
+func (r *Repository) ConsumeRecoveryCode(ctx context.Context, userID uint64, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left
+func (r *Repository) CountRecoveryCodes(ctx context.Context, userID uint64) (int, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n,
+		`SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	)
+	return n, err
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and inserts new ones
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID uint64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`,
+		userID, pq.StringArray(codeHashes),
+	)
+	return err
+}
+
+// Session methods
+
+// CreateSession stores a new session together with the first refresh token of its family
+func (r *Repository) CreateSession(ctx context.Context, sess *Session, rt *RefreshToken) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO sessions (user_id, family_id, user_agent, ip)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at, last_seen_at`,
+		sess.UserID, sess.FamilyID, sess.UserAgent, sess.IP,
+	).Scan(&sess.ID, &sess.CreatedAt, &sess.LastSeenAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, family_id, access_jti, access_expires_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		rt.UserID, rt.TokenHash, rt.FamilyID, rt.AccessJTI, rt.AccessExpiresAt, rt.ExpiresAt,
+	).Scan(&rt.ID, &rt.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *Repository) GetSessionByFamily(ctx context.Context, familyID string) (*Session, error) {
+	var sess Session
+	err := r.db.GetContext(ctx, &sess,
+		`SELECT id, user_id, family_id, user_agent, ip, created_at, last_seen_at, revoked_at
+		FROM sessions WHERE family_id = $1`,
+		familyID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// ListSessions returns the user's live sessions seen since activeSince, most recent first
+func (r *Repository) ListSessions(ctx context.Context, userID uint64, activeSince time.Time) ([]Session, error) {
+	sessions := []Session{}
+	err := r.db.SelectContext(ctx, &sessions,
+		`SELECT id, user_id, family_id, user_agent, ip, created_at, last_seen_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND last_seen_at > $2
+		ORDER BY last_seen_at DESC, id DESC`,
+		userID, activeSince,
+	)
+	return sessions, err
+}
+
+// TouchSession implements middleware.SessionTracker. It reports whether the
+// session is live and records activity at most once a minute.
+func (r *Repository) TouchSession(ctx context.Context, id, userID uint64, seenAt time.Time) (bool, error) {
+	var active bool
+	err := r.db.GetContext(ctx, &active,
+		`WITH live AS (
+			SELECT id FROM sessions WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		), touched AS (
+			UPDATE sessions SET last_seen_at = $3
+			WHERE id IN (SELECT id FROM live) AND last_seen_at < $3 - INTERVAL '1 minute'
+		)
+		SELECT EXISTS (SELECT 1 FROM live)`,
+		id, userID, seenAt,
+	)
+	return active, err
+}
+
+// RevokeSession revokes a session and its tokens. Returns false if the user has no such live session.
+func (r *Repository) RevokeSession(ctx context.Context, id, userID uint64) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var familyID string
+	err = tx.QueryRowContext(ctx,
+		`SELECT family_id FROM sessions WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL FOR UPDATE`,
+		id, userID,
+	).Scan(&familyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := revokeTokens(ctx, tx, `family_id = $1`, familyID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// PurgeSessions deletes sessions revoked or last seen before the cutoff
+func (r *Repository) PurgeSessions(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM sessions WHERE revoked_at < $1 OR last_seen_at < $1`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,826 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"go-saas-api/internal/testutil"
+)
+
+// backends lists every UserRepository implementation the suite runs against
+var backends = []struct {
+	name string
+	new  func(t *testing.T) UserRepository
+}{
+	{"memory", func(t *testing.T) UserRepository { return NewMemoryRepository() }},
+	{"postgres", func(t *testing.T) UserRepository { return NewRepository(testutil.PostgresDB(t)) }},
+}
+
+func TestRepositoryUsers(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo UserRepository)
+	}{
+		{"create and get by email and id", func(t *testing.T, ctx context.Context, repo UserRepository) {
+			id := mustCreateUser(t, ctx, repo, "a@example.com")
+
+			byEmail, err := repo.GetByEmail(ctx, "a@example.com")
+			if err != nil {
+				t.Fatalf("GetByEmail: %v", err)
+			}
+			byID, err := repo.GetByID(ctx, id)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if byEmail.ID != id || byID.Email != "a@example.com" || byID.Name != "Alice" {
+				t.Errorf("got %+v / %+v", byEmail, byID)
+			}
+		}},
+		{"missing user is sql.ErrNoRows", func(t *testing.T, ctx context.Context, repo UserRepository) {
+			if _, err := repo.GetByID(ctx, 999); err != sql.ErrNoRows {
+				t.Errorf("GetByID err = %v, want sql.ErrNoRows", err)
+			}
+			if _, err := repo.GetByEmail(ctx, "nobody@example.com"); err != sql.ErrNoRows {
+				t.Errorf("GetByEmail err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"email exists and is unique", func(t *testing.T, ctx context.Context, repo UserRepository) {
+			mustCreateUser(t, ctx, repo, "a@example.com")
+
+			exists, err := repo.EmailExists(ctx, "a@example.com")
+			if err != nil || !exists {
+				t.Errorf("EmailExists = %v, %v; want true", exists, err)
+			}
+			exists, err = repo.EmailExists(ctx, "b@example.com")
+			if err != nil || exists {
+				t.Errorf("EmailExists = %v, %v; want false", exists, err)
+			}
+			if _, err := repo.Create(ctx, "a@example.com", "hash", "Again"); err == nil {
+				t.Error("duplicate email was accepted")
+			}
+		}},
+		{"update password revokes tokens", func(t *testing.T, ctx context.Context, repo UserRepository) {
+			id := mustCreateUser(t, ctx, repo, "a@example.com")
+			mustCreateRefreshToken(t, ctx, repo, newTestRefreshToken(id, "family-a", "hash-a", "jti-a"))
+			if err := repo.UpdatePassword(ctx, id, "new-hash"); err != nil {
+				t.Fatalf("UpdatePassword: %v", err)
+			}
+			u, _ := repo.GetByID(ctx, id)
+			if u.Password != "new-hash" {
+				t.Errorf("password = %q, want new-hash", u.Password)
+			}
+			assertRevoked(t, ctx, repo, "jti-a", true)
+		}},
+		{"update profile resets verification on email change", func(t *testing.T, ctx context.Context, repo UserRepository) {
+			id := mustCreateUser(t, ctx, repo, "a@example.com")
+			_ = repo.CreateEmailVerification(ctx, "jti-1", id, "a@example.com", time.Now().Add(time.Hour))
+			_, _ = repo.ConsumeEmailVerification(ctx, "jti-1", id, "a@example.com")
+
+			name := "Alicia"
+			if ok, err := repo.UpdateProfile(ctx, id, &name, nil); err != nil || !ok {
+				t.Fatalf("UpdateProfile name = %v, %v", ok, err)
+			}
+			assertVerified(t, ctx, repo, id, true)
+
+			email := "new@example.com"
+			if ok, err := repo.UpdateProfile(ctx, id, nil, &email); err != nil || !ok {
+				t.Fatalf("UpdateProfile email = %v, %v", ok, err)
+			}
+			assertVerified(t, ctx, repo, id, false)
+
+			u, _ := repo.GetByID(ctx, id)
+			if u.Name != "Alicia" || u.Email != "new@example.com" {
+				t.Errorf("got %+v", u)
+			}
+			if ok, _ := repo.UpdateProfile(ctx, 999, &name, nil); ok {
+				t.Error("UpdateProfile of missing user returned true")
+			}
+		}},
+		{"list users filters and paginates", func(t *testing.T, ctx context.Context, repo UserRepository) {
+			alice := mustCreateUser(t, ctx, repo, "alice@example.com")
+			mustCreateUser(t, ctx, repo, "bob@example.com")
+			carol := mustCreateUser(t, ctx, repo, "carol@example.org")
+
+			admin, disabled := "admin", true
+			if ok, err := repo.UpdateUserAdmin(ctx, alice, &admin, nil); err != nil || !ok {
+				t.Fatalf("UpdateUserAdmin role = %v, %v", ok, err)
+			}
+			if ok, err := repo.UpdateUserAdmin(ctx, carol, nil, &disabled); err != nil || !ok {
+				t.Fatalf("UpdateUserAdmin disabled = %v, %v", ok, err)
+			}
+
+			tests := []struct {
+				name   string
+				filter UserFilter
+				want   []string
+				total  int
+			}{
+				{"all", UserFilter{Limit: 10}, []string{"alice@example.com", "bob@example.com", "carol@example.org"}, 3},
+				{"search", UserFilter{Query: "EXAMPLE.COM", Limit: 10}, []string{"alice@example.com", "bob@example.com"}, 2},
+				{"role", UserFilter{Role: &admin, Limit: 10}, []string{"alice@example.com"}, 1},
+				{"disabled", UserFilter{Disabled: &disabled, Limit: 10}, []string{"carol@example.org"}, 1},
+				{"page", UserFilter{Limit: 1, Offset: 1}, []string{"bob@example.com"}, 3},
+				{"wildcards are literal", UserFilter{Query: "%", Limit: 10}, nil, 0},
+			}
+			for _, tt := range tests {
+				users, total, err := repo.ListUsers(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("%s: ListUsers: %v", tt.name, err)
+				}
+				var got []string
+				for _, u := range users {
+					got = append(got, u.Email)
+				}
+				if total != tt.total || len(got) != len(tt.want) {
+					t.Errorf("%s: got %v (total %d), want %v (total %d)", tt.name, got, total, tt.want, tt.total)
+					continue
+				}
+				for i := range got {
+					if got[i] != tt.want[i] {
+						t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+						break
+					}
+				}
+			}
+
+			enabled := false
+			_, _ = repo.UpdateUserAdmin(ctx, carol, nil, &enabled)
+			if u, _ := repo.GetByID(ctx, carol); u.DisabledAt.Valid || u.Role != "user" {
+				t.Errorf("carol = role %q disabled %v, want user and enabled", u.Role, u.DisabledAt.Valid)
+			}
+		}},
+		{"soft delete, restore and purge", func(t *testing.T, ctx context.Context, repo UserRepository) {
+			id := mustCreateUser(t, ctx, repo, "a@example.com")
+
+			if ok, err := repo.SoftDelete(ctx, id); err != nil || !ok {
+				t.Fatalf("SoftDelete = %v, %v", ok, err)
+			}
+			if ok, _ := repo.SoftDelete(ctx, id); ok {
+				t.Error("second SoftDelete returned true")
+			}
+			if u, _ := repo.GetByID(ctx, id); !u.DeletedAt.Valid {
+				t.Error("deleted_at not set")
+			}
+
+			if err := repo.Restore(ctx, id); err != nil {
+				t.Fatalf("Restore: %v", err)
+			}
+			if u, _ := repo.GetByID(ctx, id); u.DeletedAt.Valid {
+				t.Error("deleted_at still set after Restore")
+			}
+
+			_, _ = repo.SoftDelete(ctx, id)
+			if n, _ := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour)); n != 0 {
+				t.Errorf("purged %d users still in grace period", n)
+			}
+			if n, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+				t.Errorf("PurgeDeleted = %d, %v; want 1", n, err)
+			}
+			if _, err := repo.GetByID(ctx, id); err != sql.ErrNoRows {
+				t.Errorf("GetByID after purge err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				tt.run(t, context.Background(), b.new(t))
+			})
+		}
+	}
+}
+
+func TestRepositoryRefreshTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64)
+	}{
+		{"create and get by hash", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			rt := newTestRefreshToken(userID, "family", "hash-1", "jti-1")
+			if err := repo.CreateRefreshToken(ctx, rt); err != nil {
+				t.Fatalf("CreateRefreshToken: %v", err)
+			}
+			if rt.ID == 0 {
+				t.Fatal("ID was not set")
+			}
+
+			got, err := repo.GetRefreshTokenByHash(ctx, "hash-1")
+			if err != nil {
+				t.Fatalf("GetRefreshTokenByHash: %v", err)
+			}
+			if got.ID != rt.ID || got.FamilyID != "family" || got.RevokedAt.Valid {
+				t.Errorf("got %+v", got)
+			}
+			if _, err := repo.GetRefreshTokenByHash(ctx, "unknown"); err != sql.ErrNoRows {
+				t.Errorf("unknown hash err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"rotate marks old token replaced, second rotation fails", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			first := newTestRefreshToken(userID, "family", "hash-1", "jti-1")
+			mustCreateRefreshToken(t, ctx, repo, first)
+
+			second := newTestRefreshToken(userID, "family", "hash-2", "jti-2")
+			if err := repo.RotateRefreshToken(ctx, first.ID, second); err != nil {
+				t.Fatalf("RotateRefreshToken: %v", err)
+			}
+
+			old, _ := repo.GetRefreshTokenByHash(ctx, "hash-1")
+			if !old.RevokedAt.Valid || !old.ReplacedBy.Valid || uint64(old.ReplacedBy.Int64) != second.ID {
+				t.Errorf("old token = %+v, want revoked and replaced by %d", old, second.ID)
+			}
+
+			third := newTestRefreshToken(userID, "family", "hash-3", "jti-3")
+			if err := repo.RotateRefreshToken(ctx, first.ID, third); err != sql.ErrNoRows {
+				t.Errorf("second rotation err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"revoke family revokes its access tokens only", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			mustCreateRefreshToken(t, ctx, repo, newTestRefreshToken(userID, "family-a", "hash-a", "jti-a"))
+			mustCreateRefreshToken(t, ctx, repo, newTestRefreshToken(userID, "family-b", "hash-b", "jti-b"))
+
+			if err := repo.RevokeTokenFamily(ctx, "family-a"); err != nil {
+				t.Fatalf("RevokeTokenFamily: %v", err)
+			}
+
+			assertRevoked(t, ctx, repo, "jti-a", true)
+			assertRevoked(t, ctx, repo, "jti-b", false)
+
+			a, _ := repo.GetRefreshTokenByHash(ctx, "hash-a")
+			b, _ := repo.GetRefreshTokenByHash(ctx, "hash-b")
+			if !a.RevokedAt.Valid || b.RevokedAt.Valid {
+				t.Errorf("revoked_at a=%v b=%v, want only a", a.RevokedAt.Valid, b.RevokedAt.Valid)
+			}
+		}},
+		{"revoke all user tokens", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			mustCreateRefreshToken(t, ctx, repo, newTestRefreshToken(userID, "family-a", "hash-a", "jti-a"))
+			mustCreateRefreshToken(t, ctx, repo, newTestRefreshToken(userID, "family-b", "hash-b", "jti-b"))
+
+			if err := repo.RevokeAllUserTokens(ctx, userID); err != nil {
+				t.Fatalf("RevokeAllUserTokens: %v", err)
+			}
+			assertRevoked(t, ctx, repo, "jti-a", true)
+			assertRevoked(t, ctx, repo, "jti-b", true)
+		}},
+		{"revoke single access token", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			assertRevoked(t, ctx, repo, "jti-x", false)
+			if err := repo.RevokeAccessToken(ctx, "jti-x", userID, time.Now().Add(time.Minute)); err != nil {
+				t.Fatalf("RevokeAccessToken: %v", err)
+			}
+			// Revoking twice is a no-op
+			if err := repo.RevokeAccessToken(ctx, "jti-x", userID, time.Now().Add(time.Minute)); err != nil {
+				t.Fatalf("RevokeAccessToken again: %v", err)
+			}
+			assertRevoked(t, ctx, repo, "jti-x", true)
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := b.new(t)
+				tt.run(t, ctx, repo, mustCreateUser(t, ctx, repo, "owner@example.com"))
+			})
+		}
+	}
+}
+
+func TestRepositoryEmailVerification(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64)
+	}{
+		{"consume marks user verified once", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			if err := repo.CreateEmailVerification(ctx, "jti-1", userID, "owner@example.com", time.Now().Add(time.Hour)); err != nil {
+				t.Fatalf("CreateEmailVerification: %v", err)
+			}
+			assertVerified(t, ctx, repo, userID, false)
+
+			ok, err := repo.ConsumeEmailVerification(ctx, "jti-1", userID, "owner@example.com")
+			if err != nil || !ok {
+				t.Fatalf("ConsumeEmailVerification = %v, %v; want true", ok, err)
+			}
+			assertVerified(t, ctx, repo, userID, true)
+
+			ok, err = repo.ConsumeEmailVerification(ctx, "jti-1", userID, "owner@example.com")
+			if err != nil || ok {
+				t.Errorf("second ConsumeEmailVerification = %v, %v; want false", ok, err)
+			}
+		}},
+		{"new token invalidates unused ones", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			_ = repo.CreateEmailVerification(ctx, "jti-1", userID, "owner@example.com", time.Now().Add(time.Hour))
+			_ = repo.CreateEmailVerification(ctx, "jti-2", userID, "owner@example.com", time.Now().Add(time.Hour))
+
+			if ok, _ := repo.ConsumeEmailVerification(ctx, "jti-1", userID, "owner@example.com"); ok {
+				t.Error("superseded token was accepted")
+			}
+			if ok, _ := repo.ConsumeEmailVerification(ctx, "jti-2", userID, "owner@example.com"); !ok {
+				t.Error("latest token was rejected")
+			}
+		}},
+		{"expired or mismatched email is rejected", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			_ = repo.CreateEmailVerification(ctx, "jti-old", userID, "owner@example.com", time.Now().Add(-time.Minute))
+			if ok, _ := repo.ConsumeEmailVerification(ctx, "jti-old", userID, "owner@example.com"); ok {
+				t.Error("expired token was accepted")
+			}
+
+			_ = repo.CreateEmailVerification(ctx, "jti-other", userID, "other@example.com", time.Now().Add(time.Hour))
+			if ok, _ := repo.ConsumeEmailVerification(ctx, "jti-other", userID, "other@example.com"); ok {
+				t.Error("token for a different email was accepted")
+			}
+			assertVerified(t, ctx, repo, userID, false)
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := b.new(t)
+				tt.run(t, ctx, repo, mustCreateUser(t, ctx, repo, "owner@example.com"))
+			})
+		}
+	}
+}
+
+func TestRepositoryPasswordReset(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64)
+	}{
+		{"reset sets password once", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			if err := repo.CreatePasswordReset(ctx, userID, "hash-1", time.Now().Add(time.Hour)); err != nil {
+				t.Fatalf("CreatePasswordReset: %v", err)
+			}
+
+			got, err := repo.ResetPassword(ctx, "hash-1", "new-hash")
+			if err != nil || got != userID {
+				t.Fatalf("ResetPassword = %d, %v; want %d", got, err, userID)
+			}
+			u, _ := repo.GetByID(ctx, userID)
+			if u.Password != "new-hash" {
+				t.Errorf("password = %q, want new-hash", u.Password)
+			}
+
+			if _, err := repo.ResetPassword(ctx, "hash-1", "other-hash"); err != sql.ErrNoRows {
+				t.Errorf("second ResetPassword err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"new token invalidates unused ones", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			_ = repo.CreatePasswordReset(ctx, userID, "hash-1", time.Now().Add(time.Hour))
+			_ = repo.CreatePasswordReset(ctx, userID, "hash-2", time.Now().Add(time.Hour))
+
+			if _, err := repo.ResetPassword(ctx, "hash-1", "new-hash"); err != sql.ErrNoRows {
+				t.Errorf("superseded token err = %v, want sql.ErrNoRows", err)
+			}
+			if _, err := repo.ResetPassword(ctx, "hash-2", "new-hash"); err != nil {
+				t.Errorf("latest token err = %v", err)
+			}
+		}},
+		{"expired token is rejected", func(t *testing.T, ctx context.Context, repo UserRepository, userID uint64) {
+			_ = repo.CreatePasswordReset(ctx, userID, "hash-old", time.Now().Add(-time.Minute))
+			if _, err := repo.ResetPassword(ctx, "hash-old", "new-hash"); err != sql.ErrNoRows {
+				t.Errorf("expired token err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := b.new(t)
+				tt.run(t, ctx, repo, mustCreateUser(t, ctx, repo, "owner@example.com"))
+			})
+		}
+	}
+}
+
+func TestRepositoryLoginAttempts(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := b.new(t)
+			windowStart := time.Now().Add(-time.Hour)
+
+			for want := 1; want <= 3; want++ {
+				got, err := repo.RecordLoginFailure(ctx, "email:a@example.com", windowStart)
+				if err != nil || got != want {
+					t.Fatalf("RecordLoginFailure = %d, %v; want %d", got, err, want)
+				}
+			}
+			// A window starting in the future forgets earlier failures
+			if got, _ := repo.RecordLoginFailure(ctx, "email:a@example.com", time.Now().Add(time.Minute)); got != 1 {
+				t.Errorf("failures after window reset = %d, want 1", got)
+			}
+
+			until, err := repo.LoginLockedUntil(ctx, []string{"email:a@example.com", "ip:192.0.2.1"})
+			if err != nil || !until.IsZero() {
+				t.Fatalf("LoginLockedUntil = %v, %v; want zero", until, err)
+			}
+
+			lock := time.Now().Add(time.Minute).Truncate(time.Microsecond)
+			_, _ = repo.RecordLoginFailure(ctx, "ip:192.0.2.1", windowStart)
+			if err := repo.LockLogin(ctx, "ip:192.0.2.1", lock); err != nil {
+				t.Fatalf("LockLogin: %v", err)
+			}
+			until, _ = repo.LoginLockedUntil(ctx, []string{"email:a@example.com", "ip:192.0.2.1"})
+			if !until.Equal(lock) {
+				t.Errorf("LoginLockedUntil = %v, want %v", until, lock)
+			}
+
+			if err := repo.ClearLoginFailures(ctx, "ip:192.0.2.1"); err != nil {
+				t.Fatalf("ClearLoginFailures: %v", err)
+			}
+			until, _ = repo.LoginLockedUntil(ctx, []string{"ip:192.0.2.1"})
+			if !until.IsZero() {
+				t.Errorf("LoginLockedUntil after clear = %v, want zero", until)
+			}
+
+			if n, err := repo.PurgeLoginAttempts(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+				t.Errorf("PurgeLoginAttempts = %d, %v; want 1", n, err)
+			}
+		})
+	}
+}
+
+func TestRepositoryAPIKeys(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := b.new(t)
+			userID := mustCreateUser(t, ctx, repo, "owner@example.com")
+			otherID := mustCreateUser(t, ctx, repo, "other@example.com")
+
+			key := &APIKey{UserID: userID, Name: "script", Prefix: "sk_abcdefgh", KeyHash: "hash-1", Scopes: []string{"places:read"}}
+			if err := repo.CreateAPIKey(ctx, key); err != nil || key.ID == 0 || key.CreatedAt.IsZero() {
+				t.Fatalf("CreateAPIKey = %+v, %v", key, err)
+			}
+			if err := repo.CreateAPIKey(ctx, &APIKey{UserID: userID, Name: "dup", Prefix: "sk_abcdefgh", KeyHash: "hash-1"}); err == nil {
+				t.Error("duplicate key hash was accepted")
+			}
+			if err := repo.CreateAPIKey(ctx, &APIKey{UserID: 999, Name: "orphan", Prefix: "sk_x", KeyHash: "hash-2"}); err == nil {
+				t.Error("key for a missing user was accepted")
+			}
+
+			got, err := repo.GetAPIKeyByHash(ctx, "hash-1")
+			if err != nil || got.ID != key.ID || len(got.Scopes) != 1 || got.Scopes[0] != "places:read" || got.LastUsedAt.Valid {
+				t.Fatalf("GetAPIKeyByHash = %+v, %v", got, err)
+			}
+			if _, err := repo.GetAPIKeyByHash(ctx, "missing"); err != sql.ErrNoRows {
+				t.Errorf("GetAPIKeyByHash missing err = %v, want sql.ErrNoRows", err)
+			}
+
+			// Uses within a minute of the last recorded one are not written
+			used := time.Now().Truncate(time.Microsecond)
+			_ = repo.TouchAPIKey(ctx, key.ID, used)
+			_ = repo.TouchAPIKey(ctx, key.ID, used.Add(30*time.Second))
+			got, _ = repo.GetAPIKeyByHash(ctx, "hash-1")
+			if !got.LastUsedAt.Valid || !got.LastUsedAt.Time.Equal(used) {
+				t.Errorf("LastUsedAt = %v, want %v", got.LastUsedAt, used)
+			}
+
+			if ok, err := repo.RevokeAPIKey(ctx, key.ID, otherID); err != nil || ok {
+				t.Errorf("RevokeAPIKey by another user = %v, %v; want false", ok, err)
+			}
+			if ok, err := repo.RevokeAPIKey(ctx, key.ID, userID); err != nil || !ok {
+				t.Errorf("RevokeAPIKey = %v, %v; want true", ok, err)
+			}
+			if ok, _ := repo.RevokeAPIKey(ctx, key.ID, userID); ok {
+				t.Error("revoking twice reported success")
+			}
+
+			keys, err := repo.ListAPIKeys(ctx, userID)
+			if err != nil || len(keys) != 0 {
+				t.Errorf("ListAPIKeys after revoke = %+v, %v; want none", keys, err)
+			}
+		})
+	}
+}
+
+func TestRepositoryOIDC(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := b.new(t)
+
+			state := &OIDCState{Provider: "stub", CodeVerifier: "verifier", Nonce: "nonce", ExpiresAt: time.Now().Add(time.Minute)}
+			if err := repo.CreateOIDCState(ctx, "state-1", state); err != nil {
+				t.Fatalf("CreateOIDCState: %v", err)
+			}
+			if _, err := repo.ConsumeOIDCState(ctx, "state-1", "other"); err != sql.ErrNoRows {
+				t.Errorf("ConsumeOIDCState for another provider err = %v, want sql.ErrNoRows", err)
+			}
+			got, err := repo.ConsumeOIDCState(ctx, "state-1", "stub")
+			if err != nil || got.CodeVerifier != "verifier" || got.Nonce != "nonce" {
+				t.Fatalf("ConsumeOIDCState = %+v, %v", got, err)
+			}
+			if _, err := repo.ConsumeOIDCState(ctx, "state-1", "stub"); err != sql.ErrNoRows {
+				t.Errorf("second ConsumeOIDCState err = %v, want sql.ErrNoRows", err)
+			}
+
+			_ = repo.CreateOIDCState(ctx, "state-old", &OIDCState{Provider: "stub", CodeVerifier: "v", Nonce: "n", ExpiresAt: time.Now().Add(-time.Minute)})
+			if _, err := repo.ConsumeOIDCState(ctx, "state-old", "stub"); err != sql.ErrNoRows {
+				t.Errorf("expired ConsumeOIDCState err = %v, want sql.ErrNoRows", err)
+			}
+			if n, err := repo.PurgeOIDCStates(ctx, time.Now()); err != nil || n != 1 {
+				t.Errorf("PurgeOIDCStates = %d, %v; want 1", n, err)
+			}
+
+			email := sql.NullString{String: "a@example.com", Valid: true}
+			identity := &Identity{Provider: "stub", Subject: "sub-1", Email: email}
+			id, err := repo.CreateUserWithIdentity(ctx, "a@example.com", "hash", "Alice", true, identity)
+			if err != nil || identity.UserID != id || identity.ID == 0 {
+				t.Fatalf("CreateUserWithIdentity = %d, %+v, %v", id, identity, err)
+			}
+			assertVerified(t, ctx, repo, id, true)
+
+			found, err := repo.GetIdentity(ctx, "stub", "sub-1")
+			if err != nil || found.UserID != id || found.Email != email {
+				t.Errorf("GetIdentity = %+v, %v", found, err)
+			}
+			if _, err := repo.GetIdentity(ctx, "other", "sub-1"); err != sql.ErrNoRows {
+				t.Errorf("GetIdentity for another provider err = %v, want sql.ErrNoRows", err)
+			}
+
+			otherID := mustCreateUser(t, ctx, repo, "b@example.com")
+			if err := repo.CreateIdentity(ctx, &Identity{UserID: otherID, Provider: "stub", Subject: "sub-1"}); err == nil {
+				t.Error("duplicate identity was accepted")
+			}
+			if err := repo.CreateIdentity(ctx, &Identity{UserID: otherID, Provider: "stub", Subject: "sub-2"}); err != nil {
+				t.Errorf("CreateIdentity: %v", err)
+			}
+
+			// A failed insert leaves no user behind
+			if _, err := repo.CreateUserWithIdentity(ctx, "c@example.com", "hash", "Carol", true, &Identity{Provider: "stub", Subject: "sub-2"}); err == nil {
+				t.Error("CreateUserWithIdentity with a taken identity succeeded")
+			}
+			if exists, _ := repo.EmailExists(ctx, "c@example.com"); exists {
+				t.Error("user was created although linking failed")
+			}
+		})
+	}
+}
+
+func TestRepositoryTwoFactor(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := b.new(t)
+			userID := mustCreateUser(t, ctx, repo, "a@example.com")
+
+			if ok, err := repo.EnableTOTP(ctx, userID, 1, []string{"code-1"}); err != nil || ok {
+				t.Errorf("EnableTOTP without a secret = %v, %v; want false", ok, err)
+			}
+			if ok, err := repo.SetTOTPSecret(ctx, userID, "SECRET"); err != nil || !ok {
+				t.Fatalf("SetTOTPSecret = %v, %v", ok, err)
+			}
+			if ok, err := repo.EnableTOTP(ctx, userID, 100, []string{"code-1", "code-2"}); err != nil || !ok {
+				t.Fatalf("EnableTOTP = %v, %v", ok, err)
+			}
+			u, _ := repo.GetByID(ctx, userID)
+			if u.TOTPSecret.String != "SECRET" || !u.TOTPEnabledAt.Valid || u.TOTPLastStep.Int64 != 100 {
+				t.Errorf("user after EnableTOTP = %+v", u)
+			}
+			if ok, _ := repo.SetTOTPSecret(ctx, userID, "OTHER"); ok {
+				t.Error("secret replaced while 2FA is enabled")
+			}
+
+			// Steps must increase so a code cannot be used twice
+			for _, tt := range []struct {
+				step int64
+				want bool
+			}{{100, false}, {99, false}, {101, true}, {101, false}} {
+				if ok, err := repo.UseTOTPStep(ctx, userID, tt.step); err != nil || ok != tt.want {
+					t.Errorf("UseTOTPStep(%d) = %v, %v; want %v", tt.step, ok, err, tt.want)
+				}
+			}
+
+			if ok, err := repo.ConsumeRecoveryCode(ctx, userID, "code-1"); err != nil || !ok {
+				t.Errorf("ConsumeRecoveryCode = %v, %v; want true", ok, err)
+			}
+			if ok, _ := repo.ConsumeRecoveryCode(ctx, userID, "code-1"); ok {
+				t.Error("recovery code used twice")
+			}
+			if n, err := repo.CountRecoveryCodes(ctx, userID); err != nil || n != 1 {
+				t.Errorf("CountRecoveryCodes = %d, %v; want 1", n, err)
+			}
+
+			if err := repo.ReplaceRecoveryCodes(ctx, userID, []string{"code-1", "code-3", "code-4"}); err != nil {
+				t.Fatalf("ReplaceRecoveryCodes: %v", err)
+			}
+			if n, _ := repo.CountRecoveryCodes(ctx, userID); n != 3 {
+				t.Errorf("CountRecoveryCodes after replace = %d, want 3", n)
+			}
+			if ok, _ := repo.ConsumeRecoveryCode(ctx, userID, "code-2"); ok {
+				t.Error("replaced recovery code still works")
+			}
+
+			if err := repo.DisableTOTP(ctx, userID); err != nil {
+				t.Fatalf("DisableTOTP: %v", err)
+			}
+			u, _ = repo.GetByID(ctx, userID)
+			if u.TOTPSecret.Valid || u.TOTPEnabledAt.Valid || u.TOTPLastStep.Valid {
+				t.Errorf("user after DisableTOTP = %+v", u)
+			}
+			if n, _ := repo.CountRecoveryCodes(ctx, userID); n != 0 {
+				t.Errorf("CountRecoveryCodes after disable = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestRepositorySessions(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := b.new(t)
+			userID := mustCreateUser(t, ctx, repo, "a@example.com")
+			otherID := mustCreateUser(t, ctx, repo, "b@example.com")
+
+			newSession := func(family, hash string) *Session {
+				t.Helper()
+				sess := &Session{UserID: userID, FamilyID: family, UserAgent: "curl/8.5.0", IP: "192.0.2.1"}
+				if err := repo.CreateSession(ctx, sess, newTestRefreshToken(userID, family, hash, "jti-"+family)); err != nil {
+					t.Fatalf("CreateSession: %v", err)
+				}
+				return sess
+			}
+			first := newSession("fam-1", "hash-1")
+			second := newSession("fam-2", "hash-2")
+			if first.ID == 0 || first.CreatedAt.IsZero() || first.LastSeenAt.IsZero() {
+				t.Errorf("CreateSession = %+v", first)
+			}
+			if rt, err := repo.GetRefreshTokenByHash(ctx, "hash-1"); err != nil || rt.FamilyID != "fam-1" {
+				t.Errorf("refresh token of the session = %+v, %v", rt, err)
+			}
+
+			// A failed refresh token insert leaves no session behind
+			err := repo.CreateSession(ctx, &Session{UserID: userID, FamilyID: "fam-3"}, newTestRefreshToken(userID, "fam-3", "hash-1", "jti-3"))
+			if err == nil {
+				t.Error("CreateSession with a duplicate token hash succeeded")
+			}
+			if _, err := repo.GetSessionByFamily(ctx, "fam-3"); err != sql.ErrNoRows {
+				t.Errorf("GetSessionByFamily after failed create err = %v, want sql.ErrNoRows", err)
+			}
+
+			got, err := repo.GetSessionByFamily(ctx, "fam-1")
+			if err != nil || got.ID != first.ID || got.UserAgent != "curl/8.5.0" || got.IP != "192.0.2.1" {
+				t.Errorf("GetSessionByFamily = %+v, %v", got, err)
+			}
+
+			// Activity within a minute of the last recorded one is not written
+			seen := time.Now().Add(2 * time.Minute).Truncate(time.Microsecond)
+			if ok, err := repo.TouchSession(ctx, first.ID, userID, seen); err != nil || !ok {
+				t.Errorf("TouchSession = %v, %v; want true", ok, err)
+			}
+			_, _ = repo.TouchSession(ctx, first.ID, userID, seen.Add(30*time.Second))
+			if ok, _ := repo.TouchSession(ctx, first.ID, otherID, seen); ok {
+				t.Error("TouchSession accepted another user's session")
+			}
+			sessions, err := repo.ListSessions(ctx, userID, time.Now().Add(-time.Hour))
+			if err != nil || len(sessions) != 2 || sessions[0].ID != first.ID || !sessions[0].LastSeenAt.Equal(seen) {
+				t.Fatalf("ListSessions = %+v, %v; want the touched session first", sessions, err)
+			}
+			if sessions, _ := repo.ListSessions(ctx, userID, seen.Add(-time.Second)); len(sessions) != 1 {
+				t.Errorf("ListSessions active since = %d sessions, want 1", len(sessions))
+			}
+
+			if ok, err := repo.RevokeSession(ctx, first.ID, otherID); err != nil || ok {
+				t.Errorf("RevokeSession by another user = %v, %v; want false", ok, err)
+			}
+			if ok, err := repo.RevokeSession(ctx, first.ID, userID); err != nil || !ok {
+				t.Fatalf("RevokeSession = %v, %v; want true", ok, err)
+			}
+			if ok, _ := repo.RevokeSession(ctx, first.ID, userID); ok {
+				t.Error("revoking twice reported success")
+			}
+			if ok, _ := repo.TouchSession(ctx, first.ID, userID, time.Now()); ok {
+				t.Error("TouchSession accepted a revoked session")
+			}
+			if rt, _ := repo.GetRefreshTokenByHash(ctx, "hash-1"); !rt.RevokedAt.Valid {
+				t.Error("refresh token of a revoked session is still live")
+			}
+			assertRevoked(t, ctx, repo, "jti-fam-1", true)
+			assertRevoked(t, ctx, repo, "jti-fam-2", false)
+
+			// Revoking all of a user's tokens ends their sessions too
+			if err := repo.RevokeAllUserTokens(ctx, userID); err != nil {
+				t.Fatalf("RevokeAllUserTokens: %v", err)
+			}
+			if ok, _ := repo.TouchSession(ctx, second.ID, userID, time.Now()); ok {
+				t.Error("session survived RevokeAllUserTokens")
+			}
+
+			if n, err := repo.PurgeSessions(ctx, time.Now().Add(time.Minute)); err != nil || n != 2 {
+				t.Errorf("PurgeSessions = %d, %v; want 2", n, err)
+			}
+		})
+	}
+}
+
+// TestRepositoryPurgeDeletedWorkspaces covers shared workspaces of purged
+// owners, which only the Postgres repository handles
+func TestRepositoryPurgeDeletedWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.PostgresDB(t)
+	repo := NewRepository(db)
+
+	owner := mustCreateUser(t, ctx, repo, "owner@example.com")
+	viewer := mustCreateUser(t, ctx, repo, "viewer@example.com")
+	editor := mustCreateUser(t, ctx, repo, "editor@example.com")
+
+	var team, solo uint64
+	if err := db.Get(&team, `INSERT INTO workspaces (name) VALUES ('Team') RETURNING id`); err != nil {
+		t.Fatalf("insert workspace: %v", err)
+	}
+	if err := db.Get(&solo, `INSERT INTO workspaces (name) VALUES ('Solo') RETURNING id`); err != nil {
+		t.Fatalf("insert workspace: %v", err)
+	}
+	_, err := db.Exec(`INSERT INTO workspace_members (workspace_id, user_id, role)
+		VALUES ($1, $2, 'owner'), ($1, $3, 'viewer'), ($1, $4, 'editor'), ($5, $2, 'owner')`,
+		team, owner, viewer, editor, solo)
+	if err != nil {
+		t.Fatalf("insert members: %v", err)
+	}
+
+	_, _ = repo.SoftDelete(ctx, owner)
+	if n, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Fatalf("PurgeDeleted = %d, %v; want 1", n, err)
+	}
+
+	roles := map[uint64]string{}
+	rows, err := db.Query(`SELECT user_id, role FROM workspace_members WHERE workspace_id = $1`, team)
+	if err != nil {
+		t.Fatalf("select members: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id uint64
+		var role string
+		if err := rows.Scan(&id, &role); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		roles[id] = role
+	}
+	if len(roles) != 2 || roles[editor] != "owner" || roles[viewer] != "viewer" {
+		t.Errorf("team members = %v, want the editor promoted to owner", roles)
+	}
+
+	var exists bool
+	if err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM workspaces WHERE id = $1)`, solo); err != nil || exists {
+		t.Errorf("workspace without members exists = %v, %v; want deleted", exists, err)
+	}
+}
+
+func mustCreateUser(t *testing.T, ctx context.Context, repo UserRepository, email string) uint64 {
+	t.Helper()
+	id, err := repo.Create(ctx, email, "hash", "Alice")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return id
+}
+
+func newTestRefreshToken(userID uint64, family, hash, jti string) *RefreshToken {
+	return &RefreshToken{
+		UserID:          userID,
+		TokenHash:       hash,
+		FamilyID:        family,
+		AccessJTI:       jti,
+		AccessExpiresAt: time.Now().Add(accessTokenTTL),
+		ExpiresAt:       time.Now().Add(refreshTokenTTL),
+	}
+}
+
+func mustCreateRefreshToken(t *testing.T, ctx context.Context, repo UserRepository, rt *RefreshToken) {
+	t.Helper()
+	if err := repo.CreateRefreshToken(ctx, rt); err != nil {
+		t.Fatalf("CreateRefreshToken: %v", err)
+	}
+}
+
+func assertRevoked(t *testing.T, ctx context.Context, repo UserRepository, jti string, want bool) {
+	t.Helper()
+	got, err := repo.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		t.Fatalf("IsTokenRevoked: %v", err)
+	}
+	if got != want {
+		t.Errorf("IsTokenRevoked(%q) = %v, want %v", jti, got, want)
+	}
+}
+
+func assertVerified(t *testing.T, ctx context.Context, repo UserRepository, userID uint64, want bool) {
+	t.Helper()
+	got, err := repo.IsEmailVerified(ctx, userID)
+	if err != nil {
+		t.Fatalf("IsEmailVerified: %v", err)
+	}
+	if got != want {
+		t.Errorf("IsEmailVerified = %v, want %v", got, want)
+	}
+}
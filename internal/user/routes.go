@@ -6,11 +6,51 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, h *Handler, authMW *middleware.AuthMiddleware) {
-	auth := r.Group("/auth")
+func RegisterRoutes(r *gin.Engine, h *Handler, authMW *middleware.AuthMiddleware, rl *middleware.RateLimiter) {
+	// Auth routes - strict per-IP rate limit
+	auth := r.Group("/auth", rl.Limit("auth"))
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.POST("/2fa/verify", h.VerifyMFA)
+		auth.POST("/refresh", h.Refresh)
+		auth.POST("/logout", authMW.RequireAuth(), h.Logout)
+		auth.GET("/sessions", authMW.RequireAuth(), h.ListSessions)
+		auth.DELETE("/sessions/:id", authMW.RequireAuth(), h.RevokeSession)
 		auth.POST("/change-password", authMW.RequireAuth(), h.ChangePassword)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/resend-verification", authMW.RequireAuth(), h.ResendVerification)
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
+		auth.GET("/oidc/providers", h.ListOIDCProviders)
+		auth.POST("/oidc/:provider/start", h.StartOIDCLogin)
+		auth.POST("/oidc/:provider/callback", h.CompleteOIDCLogin)
+	}
+
+	// Current user profile - require authentication
+	me := r.Group("/me", authMW.RequireAuth(), rl.Limit("api"))
+	{
+		me.GET("", h.GetMe)
+		me.PATCH("", h.UpdateMe)
+		me.DELETE("", h.DeleteMe)
+
+		me.GET("/2fa", h.TwoFactorStatus)
+		me.POST("/2fa/setup", h.SetupTwoFactor)
+		me.POST("/2fa/confirm", h.ConfirmTwoFactor)
+		me.POST("/2fa/disable", h.DisableTwoFactor)
+		me.POST("/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+
+		// Personal API keys can only be managed with an access token
+		me.GET("/api-keys", h.ListAPIKeys)
+		me.POST("/api-keys", h.CreateAPIKey)
+		me.DELETE("/api-keys/:id", h.RevokeAPIKey)
+	}
+
+	// Admin routes - require the admin role
+	admin := r.Group("/admin/users", authMW.RequireAuth(), authMW.RequireRole(middleware.RoleAdmin), rl.Limit("api"))
+	{
+		admin.GET("", h.ListUsers)
+		admin.GET("/:id", h.GetUser)
+		admin.PATCH("/:id", h.UpdateUser)
 	}
 }
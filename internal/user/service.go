@@ -3,123 +3,417 @@ package user
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"log"
+	"strconv"
+	"sync"
 	"time"
 
+	"go-saas-api/internal/jwtauth"
+	"go-saas-api/internal/mailer"
+	"go-saas-api/internal/middleware"
+	"go-saas-api/internal/oidc"
+	"go-saas-api/pkg/token"
+
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	// backgroundTimeout bounds work done after the response, such as sending mail
+	backgroundTimeout = 30 * time.Second
+)
+
 type Service struct {
-	repo      *Repository
-	jwtSecret []byte
+	repo          UserRepository
+	keys          *jwtauth.KeySet
+	mailer        mailer.Mailer
+	appBaseURL    string
+	deletionGrace time.Duration // how long a deleted account can still be restored
+	oidcProviders map[string]*oidc.Provider
+	background    sync.WaitGroup // work started by requests that outlives them
 }
 
-func NewService(repo *Repository, jwtSecret string) *Service {
+func NewService(repo UserRepository, keys *jwtauth.KeySet, m mailer.Mailer, appBaseURL string, deletionGrace time.Duration) *Service {
 	return &Service{
-		repo:      repo,
-		jwtSecret: []byte(jwtSecret),
+		repo:          repo,
+		keys:          keys,
+		mailer:        m,
+		appBaseURL:    appBaseURL,
+		deletionGrace: deletionGrace,
 	}
 }
 
-func (s *Service) Register(ctx context.Context, req RegisterReq) (*AuthResponse, error) {
+func (s *Service) Register(ctx context.Context, req RegisterReq, client ClientInfo) (*AuthResponse, error) {
 	// Check if email already exists
 	exists, err := s.repo.EmailExists(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("email already registered")
+		return nil, ErrEmailTaken
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(req.Password)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create user
-	id, err := s.repo.Create(ctx, req.Email, string(hashedPassword), req.Name)
+	id, err := s.repo.Create(ctx, req.Email, hashedPassword, req.Name)
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(id)
-	if err != nil {
-		return nil, err
-	}
+	// Mail after the response so a slow mail server cannot fail a registration
+	// that has already been saved; the user can resend it
+	s.runInBackground(func(ctx context.Context) {
+		if err := s.sendVerification(ctx, id, req.Email, req.Name); err != nil {
+			log.Printf("send verification email to user %d: %v", id, err)
+		}
+	})
 
-	return &AuthResponse{
-		Token: token,
-		User: UserResponse{
-			ID:    id,
-			Email: req.Email,
-			Name:  req.Name,
-		},
-	}, nil
+	// Generate access & refresh tokens
+	return s.startSession(ctx, UserResponse{
+		ID:    id,
+		Email: req.Email,
+		Name:  req.Name,
+		Role:  middleware.RoleUser,
+	}, client)
 }
 
-func (s *Service) Login(ctx context.Context, req LoginReq) (*AuthResponse, error) {
+// Login checks the password. If the user has 2FA on, the response holds an MFA
+// challenge to complete with VerifyMFA instead of tokens.
+func (s *Service) Login(ctx context.Context, req LoginReq, client ClientInfo) (*LoginResponse, error) {
+	// Refuse early while the account or IP is locked out
+	keys := loginKeys(req.Email, client.IP)
+	if err := s.checkLoginLock(ctx, keys); err != nil {
+		return nil, err
+	}
+
 	// Get user by email
 	user, err := s.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("invalid credentials")
+		if err != sql.ErrNoRows {
+			return nil, err
 		}
-		return nil, err
+		// Spend the same bcrypt time as for a known email
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		if err := s.recordLoginFailure(ctx, keys); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
 	}
 
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		if err := s.recordLoginFailure(ctx, keys); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+	// With 2FA on, failures are only cleared once the second factor passes, so
+	// re-entering the password cannot reset the count of wrong codes
+	if !user.TOTPEnabledAt.Valid {
+		if err := s.repo.ClearLoginFailures(ctx, keys[0].key); err != nil {
+			return nil, err
+		}
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(user.ID)
+	return s.finishLogin(ctx, user, client)
+}
+
+// finishLogin starts a session for an authenticated user who may still log in,
+// or returns an MFA challenge if the user has two-factor authentication on
+func (s *Service) finishLogin(ctx context.Context, user *User, client ClientInfo) (*LoginResponse, error) {
+	if err := s.checkCanLogin(user); err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabledAt.Valid {
+		challenge, err := s.newMFAChallenge(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResponse{MFAChallenge: challenge}, nil
+	}
+
+	auth, err := s.resumeSession(ctx, user, client)
 	if err != nil {
 		return nil, err
 	}
+	return &LoginResponse{AuthResponse: auth}, nil
+}
 
-	return &AuthResponse{
-		Token: token,
-		User: UserResponse{
-			ID:    user.ID,
-			Email: user.Email,
-			Name:  user.Name,
-		},
-	}, nil
+// checkCanLogin rejects disabled accounts and those past their deletion grace period
+func (s *Service) checkCanLogin(user *User) error {
+	if user.DisabledAt.Valid {
+		return ErrAccountDisabled
+	}
+	if user.DeletedAt.Valid && time.Since(user.DeletedAt.Time) > s.deletionGrace {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// resumeSession starts a session for a fully authenticated user
+func (s *Service) resumeSession(ctx context.Context, user *User, client ClientInfo) (*AuthResponse, error) {
+	// Logging in during the grace period cancels a pending deletion
+	if user.DeletedAt.Valid {
+		if err := s.repo.Restore(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Generate access & refresh tokens
+	return s.startSession(ctx, *toUserResponse(user), client)
 }
 
 func (s *Service) ChangePassword(ctx context.Context, userID uint64, req ChangePasswordReq) error {
 	// Get current user
 	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
 		return err
 	}
 
 	// Verify old password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword))
 	if err != nil {
-		return errors.New("old password is incorrect")
+		return ErrOldPasswordIncorrect
 	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(req.NewPassword)
 	if err != nil {
 		return err
 	}
 
-	// Update password
-	return s.repo.UpdatePassword(ctx, userID, string(hashedPassword))
+	// Update password, logging out every existing session with it
+	return s.repo.UpdatePassword(ctx, userID, hashedPassword)
 }
 
-func (s *Service) generateToken(userID uint64) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+// Refresh exchanges a refresh token for a new token pair (rotation).
+// Presenting an already rotated token revokes the whole token family.
+func (s *Service) Refresh(ctx context.Context, req RefreshReq) (*AuthResponse, error) {
+	current, err := s.repo.GetRefreshTokenByHash(ctx, token.Hash(req.RefreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if current.RevokedAt.Valid {
+		if current.ReplacedBy.Valid {
+			// Token was already exchanged: someone is replaying it
+			if err := s.repo.RevokeTokenFamily(ctx, current.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.GetByID(ctx, current.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if user.DeletedAt.Valid {
+		return nil, ErrInvalidRefreshToken
+	}
+	if user.DisabledAt.Valid {
+		return nil, ErrAccountDisabled
+	}
+
+	// Sessions revoked from another device cannot be refreshed
+	sess, err := s.repo.GetSessionByFamily(ctx, current.FamilyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if sess.RevokedAt.Valid {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The new access token picks up role changes
+	access, refresh, next, err := s.newTokenPair(user.ID, user.Role, current.FamilyID, sess.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RotateRefreshToken(ctx, current.ID, next); err != nil {
+		if err == sql.ErrNoRows {
+			// Lost a race against another rotation of the same token
+			if err := s.repo.RevokeTokenFamily(ctx, current.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, err
+	}
+	if _, err := s.repo.TouchSession(ctx, sess.ID, user.ID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+		User:         *toUserResponse(user),
+	}, nil
+}
+
+// Logout revokes the calling access token and the refresh token family it belongs to
+func (s *Service) Logout(ctx context.Context, userID uint64, jti string, accessExpiresAt time.Time, req LogoutReq) error {
+	if err := s.repo.RevokeAccessToken(ctx, jti, userID, accessExpiresAt); err != nil {
+		return err
+	}
+
+	rt, err := s.repo.GetRefreshTokenByHash(ctx, token.Hash(req.RefreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	// Never let a user revoke someone else's session
+	if rt.UserID != userID {
+		return nil
+	}
+	return s.repo.RevokeTokenFamily(ctx, rt.FamilyID)
+}
+
+// startSession records a new session and issues the first token pair of its family
+func (s *Service) startSession(ctx context.Context, u UserResponse, client ClientInfo) (*AuthResponse, error) {
+	familyID, err := token.Random(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, rt, err := newRefreshToken(u.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	sess := &Session{
+		UserID:    u.ID,
+		FamilyID:  familyID,
+		UserAgent: truncate(client.UserAgent, maxUserAgentLen),
+		IP:        client.IP,
+	}
+	if err := s.repo.CreateSession(ctx, sess, rt); err != nil {
+		return nil, err
+	}
+
+	access, err := s.generateToken(u.ID, u.Role, rt.AccessJTI, sess.ID, rt.AccessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		Token:        access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+		User:         u,
+	}, nil
+}
+
+// newTokenPair signs an access token and creates an (unsaved) refresh token for it
+func (s *Service) newTokenPair(userID uint64, role, familyID string, sessionID uint64) (string, string, *RefreshToken, error) {
+	refresh, rt, err := newRefreshToken(userID, familyID)
+	if err != nil {
+		return "", "", nil, err
+	}
+	access, err := s.generateToken(userID, role, rt.AccessJTI, sessionID, rt.AccessExpiresAt)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return access, refresh, rt, nil
+}
+
+// newRefreshToken creates an (unsaved) refresh token and picks the jti of the
+// access token to issue with it
+func newRefreshToken(userID uint64, familyID string) (string, *RefreshToken, error) {
+	now := time.Now()
+
+	jti, err := token.Random(16)
+	if err != nil {
+		return "", nil, err
+	}
+	refresh, err := token.Random(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rt := &RefreshToken{
+		UserID:          userID,
+		TokenHash:       token.Hash(refresh),
+		FamilyID:        familyID,
+		AccessJTI:       jti,
+		AccessExpiresAt: now.Add(accessTokenTTL),
+		ExpiresAt:       now.Add(refreshTokenTTL),
+	}
+	return refresh, rt, nil
+}
+
+func (s *Service) generateToken(userID uint64, role, jti string, sessionID uint64, expiresAt time.Time) (string, error) {
+	return s.keys.Sign(jwt.MapClaims{
+		"sub":  strconv.FormatUint(userID, 10),
+		"role": role,
+		"sid":  strconv.FormatUint(sessionID, 10),
+		"jti":  jti,
+		"exp":  expiresAt.Unix(),
+	})
+}
+
+// runInBackground runs fn on its own context, detached from the request that
+// started it
+func (s *Service) runInBackground(fn func(ctx context.Context)) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+// WaitBackground waits for work started by runInBackground to finish, or for
+// ctx to be done
+func (s *Service) WaitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hashPassword returns the bcrypt hash stored for a password
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	return string(hashed), nil
 }
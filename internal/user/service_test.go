@@ -0,0 +1,867 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-saas-api/internal/jwtauth"
+	"go-saas-api/internal/mailer"
+	"go-saas-api/internal/middleware"
+	"go-saas-api/internal/oidc"
+	"go-saas-api/internal/testutil"
+	"go-saas-api/internal/totp"
+	"go-saas-api/pkg/apperror"
+	"go-saas-api/pkg/token"
+)
+
+// recordingMailer keeps sent messages in memory
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var tokenParam = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken returns the token from the link in the most recent message
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no mail sent")
+	}
+	match := tokenParam.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if match == nil {
+		t.Fatal("no token in mail body")
+	}
+	token, _ := url.QueryUnescape(match[1])
+	return token
+}
+
+var testClient = ClientInfo{IP: "192.0.2.1", UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"}
+
+func newTestService() (*Service, *MemoryRepository) {
+	svc, repo, _ := newTestServiceWithMailer()
+	return svc, repo
+}
+
+func newTestServiceWithMailer() (*Service, *MemoryRepository, *recordingMailer) {
+	repo := NewMemoryRepository()
+	mail := &recordingMailer{}
+	keys := jwtauth.NewHMAC("test-secret", "test", "test")
+	return NewService(repo, keys, mail, "http://app.test", time.Hour), repo, mail
+}
+
+func TestServiceRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+
+	reg, err := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if reg.Token == "" || reg.RefreshToken == "" || reg.User.Email != "a@example.com" {
+		t.Errorf("Register returned %+v", reg)
+	}
+
+	tests := []struct {
+		name    string
+		req     LoginReq
+		wantErr error
+	}{
+		{"valid credentials", LoginReq{Email: "a@example.com", Password: "secret1"}, nil},
+		{"wrong password", LoginReq{Email: "a@example.com", Password: "nope"}, ErrInvalidCredentials},
+		{"unknown email", LoginReq{Email: "b@example.com", Password: "secret1"}, ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Login(ctx, tt.req, testClient)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Login err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Again"}, testClient); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("duplicate Register err = %v, want ErrEmailTaken", err)
+	}
+}
+
+func TestServiceRefreshRotationAndReuse(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService()
+
+	reg, err := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	rotated, err := svc.Refresh(ctx, RefreshReq{RefreshToken: reg.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if rotated.RefreshToken == reg.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// Replaying the first token is reuse and kills the whole family
+	if _, err := svc.Refresh(ctx, RefreshReq{RefreshToken: reg.RefreshToken}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replay err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := svc.Refresh(ctx, RefreshReq{RefreshToken: rotated.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after reuse err = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	rt, _ := repo.GetRefreshTokenByHash(ctx, token.Hash(rotated.RefreshToken))
+	assertRevoked(t, ctx, repo, rt.AccessJTI, true)
+
+	if _, err := svc.Refresh(ctx, RefreshReq{RefreshToken: "garbage"}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestServiceChangePasswordRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService()
+
+	reg, err := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	err = svc.ChangePassword(ctx, reg.User.ID, ChangePasswordReq{OldPassword: "wrong", NewPassword: "secret2"})
+	if !errors.Is(err, ErrOldPasswordIncorrect) {
+		t.Fatalf("ChangePassword with wrong password err = %v", err)
+	}
+	err = svc.ChangePassword(ctx, reg.User.ID+1000, ChangePasswordReq{OldPassword: "secret1", NewPassword: "secret2"})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("ChangePassword of unknown user err = %v, want ErrUserNotFound", err)
+	}
+
+	if err := svc.ChangePassword(ctx, reg.User.ID, ChangePasswordReq{OldPassword: "secret1", NewPassword: "secret2"}); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	if _, err := svc.Refresh(ctx, RefreshReq{RefreshToken: reg.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after password change err = %v, want ErrInvalidRefreshToken", err)
+	}
+	rt, _ := repo.GetRefreshTokenByHash(ctx, token.Hash(reg.RefreshToken))
+	assertRevoked(t, ctx, repo, rt.AccessJTI, true)
+
+	if _, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret2"}, testClient); err != nil {
+		t.Errorf("Login with new password: %v", err)
+	}
+}
+
+func TestServiceEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	svc, _, mail := newTestServiceWithMailer()
+
+	reg, err := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if reg.User.EmailVerified {
+		t.Fatal("new user is already verified")
+	}
+	svc.WaitBackground(ctx) // the verification mail is sent after Register returns
+	first := mail.lastToken(t)
+
+	// Resending supersedes the first token
+	if err := svc.ResendVerification(ctx, reg.User.ID); err != nil {
+		t.Fatalf("ResendVerification: %v", err)
+	}
+	second := mail.lastToken(t)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"access token is not a verification token", reg.Token, ErrInvalidVerificationToken},
+		{"superseded token", first, ErrInvalidVerificationToken},
+		{"garbage", "not-a-token", ErrInvalidVerificationToken},
+		{"latest token", second, nil},
+		{"token is single-use", second, ErrInvalidVerificationToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.VerifyEmail(ctx, VerifyEmailReq{Token: tt.token})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyEmail err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	login, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, testClient)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !login.User.EmailVerified {
+		t.Error("user is not verified after VerifyEmail")
+	}
+	if err := svc.ResendVerification(ctx, reg.User.ID); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("ResendVerification err = %v, want ErrEmailAlreadyVerified", err)
+	}
+}
+
+func TestServicePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, mail := newTestServiceWithMailer()
+
+	reg, err := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	svc.WaitBackground(ctx)
+	sent := len(mail.sent)
+
+	// Unknown emails are ignored without sending anything
+	svc.ForgotPassword(ForgotPasswordReq{Email: "nobody@example.com"})
+	svc.WaitBackground(ctx)
+	if len(mail.sent) != sent {
+		t.Fatal("mail sent for an unknown email")
+	}
+
+	svc.ForgotPassword(ForgotPasswordReq{Email: "a@example.com"})
+	svc.WaitBackground(ctx)
+	reset := mail.lastToken(t)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"unknown token", "garbage", ErrInvalidResetToken},
+		{"valid token", reset, nil},
+		{"token is single-use", reset, ErrInvalidResetToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.ResetPassword(ctx, ResetPasswordReq{Token: tt.token, NewPassword: "secret2"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ResetPassword err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret2"}, testClient); err != nil {
+		t.Errorf("Login with new password: %v", err)
+	}
+	rt, _ := repo.GetRefreshTokenByHash(ctx, token.Hash(reg.RefreshToken))
+	assertRevoked(t, ctx, repo, rt.AccessJTI, true)
+}
+
+func TestServiceUpdateMe(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, mail := newTestServiceWithMailer()
+
+	reg, _ := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	svc.WaitBackground(ctx)
+	_ = svc.VerifyEmail(ctx, VerifyEmailReq{Token: mail.lastToken(t)})
+	_, _ = svc.Register(ctx, RegisterReq{Email: "b@example.com", Password: "secret1", Name: "Bob"}, testClient)
+	svc.WaitBackground(ctx)
+
+	strPtr := func(s string) *string { return &s }
+	tests := []struct {
+		name         string
+		req          UpdateMeReq
+		wantErr      error
+		wantVerified bool
+	}{
+		{"no fields", UpdateMeReq{}, ErrNoFieldsToUpdate, true},
+		{"name only keeps verification", UpdateMeReq{Name: strPtr(" Alicia ")}, nil, true},
+		{"same email keeps verification", UpdateMeReq{Email: strPtr("A@example.com")}, nil, true},
+		{"taken email", UpdateMeReq{Email: strPtr("b@example.com")}, ErrEmailTaken, true},
+		{"new email needs verification", UpdateMeReq{Email: strPtr("c@example.com")}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.UpdateMe(ctx, reg.User.ID, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateMe err = %v, want %v", err, tt.wantErr)
+			}
+			assertVerified(t, ctx, repo, reg.User.ID, tt.wantVerified)
+		})
+	}
+
+	me, err := svc.GetMe(ctx, reg.User.ID)
+	if err != nil {
+		t.Fatalf("GetMe: %v", err)
+	}
+	if me.Name != "Alicia" || me.Email != "c@example.com" {
+		t.Errorf("GetMe = %+v", me)
+	}
+
+	// The link sent to the new address verifies it
+	if err := svc.VerifyEmail(ctx, VerifyEmailReq{Token: mail.lastToken(t)}); err != nil {
+		t.Errorf("VerifyEmail new address: %v", err)
+	}
+}
+
+func TestServiceDeleteMe(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService()
+
+	reg, _ := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+
+	if err := svc.DeleteMe(ctx, reg.User.ID, DeleteMeReq{Password: "wrong"}); !errors.Is(err, ErrPasswordIncorrect) {
+		t.Fatalf("DeleteMe with wrong password err = %v", err)
+	}
+	if err := svc.DeleteMe(ctx, reg.User.ID, DeleteMeReq{Password: "secret1"}); err != nil {
+		t.Fatalf("DeleteMe: %v", err)
+	}
+
+	if _, err := svc.GetMe(ctx, reg.User.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("GetMe after delete err = %v, want ErrUserNotFound", err)
+	}
+	if _, err := svc.Refresh(ctx, RefreshReq{RefreshToken: reg.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh after delete err = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	// Logging in within the grace period restores the account
+	if _, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, testClient); err != nil {
+		t.Fatalf("Login during grace period: %v", err)
+	}
+	if _, err := svc.GetMe(ctx, reg.User.ID); err != nil {
+		t.Errorf("GetMe after restore: %v", err)
+	}
+
+	// Accounts still within the grace period are not purged
+	_ = svc.DeleteMe(ctx, reg.User.ID, DeleteMeReq{Password: "secret1"})
+	if n, err := svc.PurgeDeletedUsers(ctx); err != nil || n != 0 {
+		t.Errorf("PurgeDeletedUsers = %d, %v; want 0", n, err)
+	}
+	if n, err := repo.PurgeDeleted(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Errorf("PurgeDeleted = %d, %v; want 1", n, err)
+	}
+	if _, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, testClient); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login after purge err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestServiceLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+
+	if _, err := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// The account key locks after its free attempts, even from different IPs
+	for i := 0; i < accountFreeAttempts; i++ {
+		ip := fmt.Sprintf("198.51.100.%d", i)
+		if _, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "wrong"}, ClientInfo{IP: ip}); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d err = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+
+	_, err := svc.Login(ctx, LoginReq{Email: "A@example.com", Password: "secret1"}, testClient)
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) || !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Fatalf("locked login err = %v, want ErrTooManyLoginAttempts", err)
+	}
+	if appErr.RetryAfter <= 0 || appErr.RetryAfter > loginBackoffBase {
+		t.Errorf("RetryAfter = %v, want (0, %v]", appErr.RetryAfter, loginBackoffBase)
+	}
+
+	// Unknown emails count against the IP like any other failure
+	const ip = "203.0.113.7"
+	for i := 0; i < ipFreeAttempts; i++ {
+		email := fmt.Sprintf("nobody%d@example.com", i)
+		if _, err := svc.Login(ctx, LoginReq{Email: email, Password: "wrong"}, ClientInfo{IP: ip}); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d err = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+	if _, err := svc.Login(ctx, LoginReq{Email: "someone@example.com", Password: "x"}, ClientInfo{IP: ip}); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Errorf("login from locked IP err = %v, want ErrTooManyLoginAttempts", err)
+	}
+}
+
+func TestLoginBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{accountFreeAttempts - 1, 0},
+		{accountFreeAttempts, loginBackoffBase},
+		{accountFreeAttempts + 1, 2 * loginBackoffBase},
+		{accountFreeAttempts + 3, 8 * loginBackoffBase},
+		{accountFreeAttempts + 5, loginBackoffMax},
+		{accountFreeAttempts + 100, loginBackoffMax},
+	}
+	for _, tt := range tests {
+		if got := loginBackoff(tt.failures, accountFreeAttempts); got != tt.want {
+			t.Errorf("loginBackoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestServiceAdminUpdateUser(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService()
+
+	admin, _ := svc.Register(ctx, RegisterReq{Email: "admin@example.com", Password: "secret1", Name: "Admin"}, testClient)
+	member, _ := svc.Register(ctx, RegisterReq{Email: "member@example.com", Password: "secret1", Name: "Member"}, testClient)
+
+	if err := svc.SetRoleByEmail(ctx, "admin@example.com", middleware.RoleAdmin); err != nil {
+		t.Fatalf("SetRoleByEmail: %v", err)
+	}
+	login, err := svc.Login(ctx, LoginReq{Email: "admin@example.com", Password: "secret1"}, testClient)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if got := tokenRole(t, svc, login.Token); got != middleware.RoleAdmin || login.User.Role != middleware.RoleAdmin {
+		t.Errorf("role claim = %q, user role = %q; want admin", got, login.User.Role)
+	}
+
+	adminRole, userRole, disable := middleware.RoleAdmin, middleware.RoleUser, true
+	tests := []struct {
+		name    string
+		id      uint64
+		req     AdminUpdateUserReq
+		wantErr error
+	}{
+		{"no fields", member.User.ID, AdminUpdateUserReq{}, ErrNoFieldsToUpdate},
+		{"cannot demote self", admin.User.ID, AdminUpdateUserReq{Role: &userRole}, ErrCannotModifySelf},
+		{"cannot disable self", admin.User.ID, AdminUpdateUserReq{Disabled: &disable}, ErrCannotModifySelf},
+		{"self no-op is fine", admin.User.ID, AdminUpdateUserReq{Role: &adminRole}, nil},
+		{"missing user", 999, AdminUpdateUserReq{Disabled: &disable}, ErrUserNotFound},
+		{"disable member", member.User.ID, AdminUpdateUserReq{Disabled: &disable}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.UpdateUser(ctx, admin.User.ID, tt.id, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateUser err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// A disabled user is logged out and cannot log back in
+	rt, _ := repo.GetRefreshTokenByHash(ctx, token.Hash(member.RefreshToken))
+	assertRevoked(t, ctx, repo, rt.AccessJTI, true)
+	if _, err := svc.Login(ctx, LoginReq{Email: "member@example.com", Password: "secret1"}, testClient); !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("Login of disabled user err = %v, want ErrAccountDisabled", err)
+	}
+
+	items, total, err := svc.ListUsers(ctx, ListUsersQuery{Disabled: &disable})
+	if err != nil || total != 1 || len(items) != 1 || !items[0].Disabled || items[0].DisabledAt == nil {
+		t.Errorf("ListUsers disabled = %+v, %d, %v", items, total, err)
+	}
+}
+
+func TestServiceAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService()
+	auth := NewAPIKeyAuthenticator(repo)
+
+	reg, _ := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	userID := reg.User.ID
+
+	created, err := svc.CreateAPIKey(ctx, userID, CreateAPIKeyReq{
+		Name:   "sync script",
+		Scopes: []string{middleware.ScopePlacesWrite, middleware.ScopePlacesRead, middleware.ScopePlacesRead},
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, middleware.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Errorf("key %q does not start with prefix %q", created.Key, created.Prefix)
+	}
+	if want := []string{middleware.ScopePlacesRead, middleware.ScopePlacesWrite}; !slices.Equal(created.Scopes, want) {
+		t.Errorf("scopes = %v, want %v", created.Scopes, want)
+	}
+
+	principal, err := auth.AuthenticateAPIKey(ctx, created.Key)
+	if err != nil || principal == nil || principal.UserID != userID || principal.Role != middleware.RoleUser {
+		t.Fatalf("AuthenticateAPIKey = %+v, %v", principal, err)
+	}
+	items, _ := svc.ListAPIKeys(ctx, userID)
+	if len(items) != 1 || items[0].LastUsedAt == nil {
+		t.Errorf("ListAPIKeys = %+v, want one used key", items)
+	}
+
+	days := 1
+	expiring, _ := svc.CreateAPIKey(ctx, userID, CreateAPIKeyReq{Name: "temp", Scopes: []string{middleware.ScopePlacesRead}, ExpiresInDays: &days})
+	if expiring.ExpiresAt == nil {
+		t.Error("ExpiresAt not set")
+	}
+	stored, _ := repo.GetAPIKeyByHash(ctx, token.Hash(expiring.Key))
+	stored.ExpiresAt.Time = time.Now().Add(-time.Minute)
+	repo.apiKeys[stored.ID] = *stored
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"unknown key", middleware.APIKeyPrefix + "nope"},
+		{"not an api key", "eyJhbGciOi"},
+		{"expired key", expiring.Key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if p, err := auth.AuthenticateAPIKey(ctx, tt.key); err != nil || p != nil {
+				t.Errorf("AuthenticateAPIKey = %+v, %v; want nil", p, err)
+			}
+		})
+	}
+
+	// Keys stop working when the owner is disabled, and after revocation
+	disable, enable := true, false
+	_, _ = repo.UpdateUserAdmin(ctx, userID, nil, &disable)
+	if p, _ := auth.AuthenticateAPIKey(ctx, created.Key); p != nil {
+		t.Error("key of a disabled user was accepted")
+	}
+	_, _ = repo.UpdateUserAdmin(ctx, userID, nil, &enable)
+
+	if err := svc.RevokeAPIKey(ctx, userID+1, created.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey by another user err = %v, want ErrAPIKeyNotFound", err)
+	}
+	if err := svc.RevokeAPIKey(ctx, userID, created.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if p, _ := auth.AuthenticateAPIKey(ctx, created.Key); p != nil {
+		t.Error("revoked key was accepted")
+	}
+
+	for i := len(mustListAPIKeys(t, svc, userID)); i < maxAPIKeysPerUser; i++ {
+		if _, err := svc.CreateAPIKey(ctx, userID, CreateAPIKeyReq{Name: "bulk", Scopes: []string{middleware.ScopePlacesRead}}); err != nil {
+			t.Fatalf("CreateAPIKey %d: %v", i, err)
+		}
+	}
+	if _, err := svc.CreateAPIKey(ctx, userID, CreateAPIKeyReq{Name: "one too many", Scopes: []string{middleware.ScopePlacesRead}}); !errors.Is(err, ErrTooManyAPIKeys) {
+		t.Errorf("CreateAPIKey over limit err = %v, want ErrTooManyAPIKeys", err)
+	}
+}
+
+func TestServiceOIDCLogin(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService()
+	stub := testutil.NewOIDCProvider(t)
+	svc.RegisterOIDCProvider(oidc.NewProvider(oidc.Config{
+		Name:         "stub",
+		Issuer:       stub.Issuer,
+		ClientID:     stub.ClientID,
+		ClientSecret: stub.ClientSecret,
+		RedirectURL:  "http://app.test/auth/callback/stub",
+	}, nil))
+
+	// login runs the whole flow for identity as the frontend would
+	login := func(t *testing.T, identity testutil.OIDCIdentity) (*LoginResponse, OIDCCallbackReq, error) {
+		t.Helper()
+		start, err := svc.StartOIDCLogin(ctx, "stub")
+		if err != nil {
+			t.Fatalf("StartOIDCLogin: %v", err)
+		}
+		code, state := stub.Login(t, start.AuthorizationURL, identity)
+		if state != start.State {
+			t.Fatalf("state = %q, want %q", state, start.State)
+		}
+		req := OIDCCallbackReq{Code: code, State: state}
+		resp, err := svc.CompleteOIDCLogin(ctx, "stub", req, testClient)
+		return resp, req, err
+	}
+
+	alice := testutil.OIDCIdentity{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+	first, req, err := login(t, alice)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if first.Token == "" || first.User.Email != "alice@example.com" || !first.User.EmailVerified || first.User.Name != "Alice" {
+		t.Errorf("first login = %+v", first.User)
+	}
+	if _, err := svc.CompleteOIDCLogin(ctx, "stub", req, testClient); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("replayed callback err = %v, want ErrInvalidOIDCState", err)
+	}
+
+	// The identity stays linked even if the provider reports another email later
+	again, _, err := login(t, testutil.OIDCIdentity{Subject: "alice-sub", Email: "new@example.com", EmailVerified: true})
+	if err != nil || again.User.ID != first.User.ID {
+		t.Errorf("second login = %+v, %v; want user %d", again, err, first.User.ID)
+	}
+
+	// Password accounts are linked only once their email is verified
+	bob, _ := svc.Register(ctx, RegisterReq{Email: "bob@example.com", Password: "secret1", Name: "Bob"}, testClient)
+	bobIdentity := testutil.OIDCIdentity{Subject: "bob-sub", Email: "bob@example.com", EmailVerified: true}
+	if _, _, err := login(t, bobIdentity); !errors.Is(err, ErrOIDCAccountExists) {
+		t.Errorf("link to unverified account err = %v, want ErrOIDCAccountExists", err)
+	}
+	u, _ := repo.GetByID(ctx, bob.User.ID)
+	u.EmailVerified = true
+	repo.users[u.ID] = *u
+	linked, _, err := login(t, bobIdentity)
+	if err != nil || linked.User.ID != bob.User.ID {
+		t.Errorf("link to verified account = %+v, %v; want user %d", linked, err, bob.User.ID)
+	}
+
+	tests := []struct {
+		name     string
+		identity testutil.OIDCIdentity
+		wantErr  error
+	}{
+		{"unverified provider email", testutil.OIDCIdentity{Subject: "carol-sub", Email: "carol@example.com"}, ErrOIDCEmailNotVerified},
+		{"no email", testutil.OIDCIdentity{Subject: "dave-sub", EmailVerified: true}, ErrOIDCEmailNotVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := login(t, tt.identity); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := svc.StartOIDCLogin(ctx, "missing"); !errors.Is(err, ErrOIDCProviderNotFound) {
+		t.Errorf("unknown provider err = %v, want ErrOIDCProviderNotFound", err)
+	}
+	if _, err := svc.CompleteOIDCLogin(ctx, "stub", OIDCCallbackReq{Code: "x", State: "forged"}, testClient); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("forged state err = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestServiceTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService()
+
+	reg, _ := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	userID := reg.User.ID
+
+	if _, err := svc.ConfirmTwoFactor(ctx, userID, ConfirmTwoFactorReq{Code: "123456"}); !errors.Is(err, ErrTwoFactorNotSetUp) {
+		t.Errorf("ConfirmTwoFactor before setup err = %v, want ErrTwoFactorNotSetUp", err)
+	}
+
+	setup, err := svc.SetupTwoFactor(ctx, userID)
+	if err != nil {
+		t.Fatalf("SetupTwoFactor: %v", err)
+	}
+	if !strings.HasPrefix(setup.OTPAuthURI, "otpauth://totp/test:a@example.com?") || !strings.Contains(setup.OTPAuthURI, "secret="+setup.Secret) {
+		t.Errorf("OTPAuthURI = %s", setup.OTPAuthURI)
+	}
+
+	// Until confirmed, login does not ask for a code
+	if login, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, testClient); err != nil || login.AuthResponse == nil {
+		t.Fatalf("Login before confirm = %+v, %v; want tokens", login, err)
+	}
+
+	// Codes are taken from the step before the current one so each is fresh
+	code := func(offset int64) string {
+		c, err := totp.Code(setup.Secret, totp.Step(time.Now())+offset)
+		if err != nil {
+			t.Fatalf("totp.Code: %v", err)
+		}
+		return c
+	}
+	if _, err := svc.ConfirmTwoFactor(ctx, userID, ConfirmTwoFactorReq{Code: code(5)}); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("ConfirmTwoFactor with a wrong code err = %v, want ErrInvalidMFACode", err)
+	}
+	recovery, err := svc.ConfirmTwoFactor(ctx, userID, ConfirmTwoFactorReq{Code: code(-1)})
+	if err != nil || len(recovery.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("ConfirmTwoFactor = %+v, %v", recovery, err)
+	}
+	if _, err := svc.SetupTwoFactor(ctx, userID); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+		t.Errorf("SetupTwoFactor when enabled err = %v, want ErrTwoFactorAlreadyEnabled", err)
+	}
+	if me, _ := svc.GetMe(ctx, userID); !me.TwoFactor {
+		t.Error("GetMe does not report 2FA as enabled")
+	}
+
+	// mfaLogin logs in with the password and returns the challenge token
+	mfaLogin := func(t *testing.T) string {
+		t.Helper()
+		login, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, testClient)
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		if login.AuthResponse != nil || login.MFAChallenge == nil || !login.MFARequired || login.MFAToken == "" {
+			t.Fatalf("Login with 2FA = %+v, want only a challenge", login)
+		}
+		return login.MFAToken
+	}
+
+	mfaToken := mfaLogin(t)
+	if _, err := svc.keys.Parse(mfaToken, svc.keys.Audience); err == nil {
+		t.Error("challenge token was accepted as an access token")
+	}
+	if _, err := svc.VerifyMFA(ctx, VerifyMFAReq{MFAToken: mfaToken, Code: code(-1)}, testClient); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("VerifyMFA with the code used to confirm err = %v, want ErrInvalidMFACode", err)
+	}
+	auth, err := svc.VerifyMFA(ctx, VerifyMFAReq{MFAToken: mfaToken, Code: code(0)}, testClient)
+	if err != nil || auth.Token == "" || auth.User.ID != userID {
+		t.Fatalf("VerifyMFA = %+v, %v", auth, err)
+	}
+	if _, err := svc.VerifyMFA(ctx, VerifyMFAReq{MFAToken: mfaToken, Code: code(1)}, testClient); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Errorf("reused challenge err = %v, want ErrInvalidMFAToken", err)
+	}
+
+	// Recovery codes work once, in any case and without the dash
+	recoveryCode := strings.ToUpper(strings.ReplaceAll(recovery.RecoveryCodes[0], "-", ""))
+	if _, err := svc.VerifyMFA(ctx, VerifyMFAReq{MFAToken: mfaLogin(t), Code: recoveryCode}, testClient); err != nil {
+		t.Errorf("VerifyMFA with a recovery code: %v", err)
+	}
+	if _, err := svc.VerifyMFA(ctx, VerifyMFAReq{MFAToken: mfaLogin(t), Code: recoveryCode}, testClient); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("reused recovery code err = %v, want ErrInvalidMFACode", err)
+	}
+	if status, _ := svc.TwoFactorStatus(ctx, userID); !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Errorf("TwoFactorStatus = %+v", status)
+	}
+
+	// Wrong codes count towards the login lockout, and the password does not reset it
+	_ = repo.ClearLoginFailures(ctx, "email:a@example.com")
+	for range accountFreeAttempts {
+		_, _ = svc.VerifyMFA(ctx, VerifyMFAReq{MFAToken: mfaLogin(t), Code: "000000"}, ClientInfo{IP: "198.51.100.7"})
+	}
+	if _, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, testClient); !errors.Is(err, ErrTooManyLoginAttempts) {
+		t.Errorf("Login after wrong codes err = %v, want ErrTooManyLoginAttempts", err)
+	}
+	_ = repo.ClearLoginFailures(ctx, "email:a@example.com")
+
+	regenerated, err := svc.RegenerateRecoveryCodes(ctx, userID, TwoFactorPasswordReq{Password: "secret1"})
+	if err != nil || len(regenerated.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("RegenerateRecoveryCodes = %+v, %v", regenerated, err)
+	}
+	if _, err := svc.VerifyMFA(ctx, VerifyMFAReq{MFAToken: mfaLogin(t), Code: recovery.RecoveryCodes[1]}, testClient); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("old recovery code after regenerate err = %v, want ErrInvalidMFACode", err)
+	}
+
+	if err := svc.DisableTwoFactor(ctx, userID, TwoFactorPasswordReq{Password: "wrong"}); !errors.Is(err, ErrPasswordIncorrect) {
+		t.Errorf("DisableTwoFactor with wrong password err = %v, want ErrPasswordIncorrect", err)
+	}
+	if err := svc.DisableTwoFactor(ctx, userID, TwoFactorPasswordReq{Password: "secret1"}); err != nil {
+		t.Fatalf("DisableTwoFactor: %v", err)
+	}
+	if login, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, testClient); err != nil || login.AuthResponse == nil {
+		t.Errorf("Login after disable = %+v, %v; want tokens", login, err)
+	}
+	if _, err := svc.RegenerateRecoveryCodes(ctx, userID, TwoFactorPasswordReq{Password: "secret1"}); !errors.Is(err, ErrTwoFactorNotEnabled) {
+		t.Errorf("RegenerateRecoveryCodes when disabled err = %v, want ErrTwoFactorNotEnabled", err)
+	}
+}
+
+func TestServiceSessions(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+
+	reg, _ := svc.Register(ctx, RegisterReq{Email: "a@example.com", Password: "secret1", Name: "Alice"}, testClient)
+	userID := reg.User.ID
+	phone := ClientInfo{IP: "198.51.100.7", UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"}
+	login, err := svc.Login(ctx, LoginReq{Email: "a@example.com", Password: "secret1"}, phone)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	current := tokenSession(t, svc, login.Token)
+	sessions, err := svc.ListSessions(ctx, userID, current)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("ListSessions = %+v, %v; want 2", sessions, err)
+	}
+	byDevice := map[string]SessionResponse{}
+	for _, sess := range sessions {
+		byDevice[sess.Device] = sess
+	}
+	if sess := byDevice["Safari on iOS"]; sess.ID != current || !sess.Current || sess.IP != "198.51.100.7" {
+		t.Errorf("phone session = %+v", sess)
+	}
+	if sess := byDevice["Firefox on Linux"]; sess.ID != tokenSession(t, svc, reg.Token) || sess.Current {
+		t.Errorf("registration session = %+v", sess)
+	}
+
+	// Logging out the phone from the other session stops its refresh token
+	if err := svc.RevokeSession(ctx, userID, current); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, err := svc.Refresh(ctx, RefreshReq{RefreshToken: login.RefreshToken}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh of a revoked session err = %v, want ErrInvalidRefreshToken", err)
+	}
+	if err := svc.RevokeSession(ctx, userID, current); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("RevokeSession twice err = %v, want ErrSessionNotFound", err)
+	}
+
+	// Refreshing keeps the session
+	refreshed, err := svc.Refresh(ctx, RefreshReq{RefreshToken: reg.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := tokenSession(t, svc, refreshed.Token); got != tokenSession(t, svc, reg.Token) {
+		t.Errorf("refreshed token session = %d, want %d", got, tokenSession(t, svc, reg.Token))
+	}
+	if sessions, _ := svc.ListSessions(ctx, userID, 0); len(sessions) != 1 {
+		t.Errorf("ListSessions after revoke = %+v, want 1 session", sessions)
+	}
+
+	// Other users cannot revoke the session
+	other, _ := svc.Register(ctx, RegisterReq{Email: "b@example.com", Password: "secret1", Name: "Bob"}, testClient)
+	if err := svc.RevokeSession(ctx, other.User.ID, tokenSession(t, svc, reg.Token)); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("RevokeSession of another user's session err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestDeviceLabel(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", "Chrome on Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15", "Safari on macOS"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/126.0 Mobile/15E148 Safari/604.1", "Chrome on iPadOS"},
+		{"curl/8.5.0", "curl"},
+		{"", "Unknown device"},
+		{"(compatible)", "Unknown device"},
+	}
+	for _, tt := range tests {
+		if got := deviceLabel(tt.userAgent); got != tt.want {
+			t.Errorf("deviceLabel(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+func mustListAPIKeys(t *testing.T, svc *Service, userID uint64) []APIKeyResponse {
+	t.Helper()
+	items, err := svc.ListAPIKeys(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("ListAPIKeys: %v", err)
+	}
+	return items
+}
+
+// tokenRole returns the role claim of an access token signed by svc
+func tokenRole(t *testing.T, svc *Service, token string) string {
+	t.Helper()
+	claims, err := svc.keys.Parse(token, svc.keys.Audience)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	role, _ := claims["role"].(string)
+	return role
+}
+
+func tokenSession(t *testing.T, svc *Service, token string) uint64 {
+	t.Helper()
+	claims, err := svc.keys.Parse(token, svc.keys.Audience)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	sid, _ := claims["sid"].(string)
+	id, err := strconv.ParseUint(sid, 10, 64)
+	if err != nil {
+		t.Fatalf("token sid = %q", sid)
+	}
+	return id
+}
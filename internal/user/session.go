@@ -0,0 +1,109 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxUserAgentLen matches sessions.user_agent
+const maxUserAgentLen = 512
+
+// ListSessions returns the user's live sessions, marking the one the request came from
+func (s *Service) ListSessions(ctx context.Context, userID, currentSessionID uint64) ([]SessionResponse, error) {
+	sessions, err := s.repo.ListSessions(ctx, userID, time.Now().Add(-refreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]SessionResponse, len(sessions))
+	for i := range sessions {
+		items[i] = toSessionResponse(&sessions[i], currentSessionID)
+	}
+	return items, nil
+}
+
+// RevokeSession logs out one session; its access and refresh tokens stop working at once
+func (s *Service) RevokeSession(ctx context.Context, userID, id uint64) error {
+	revoked, err := s.repo.RevokeSession(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// deviceLabel turns a User-Agent into a short label such as "Firefox on Windows"
+func deviceLabel(userAgent string) string {
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	})
+	os := firstMatch(userAgent, [][2]string{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+
+	// API clients such as curl/8.5.0: use the product name
+	product, _, _ := strings.Cut(userAgent, "/")
+	product = strings.TrimSpace(product)
+	if product == "" || strings.ContainsAny(product, " ()") {
+		return "Unknown device"
+	}
+	return truncate(product, 40)
+}
+
+// firstMatch returns the label of the first marker found in s
+func firstMatch(s string, markers [][2]string) string {
+	for _, m := range markers {
+		if strings.Contains(s, m[0]) {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func toSessionResponse(sess *Session, currentSessionID uint64) SessionResponse {
+	return SessionResponse{
+		ID:         sess.ID,
+		Device:     deviceLabel(sess.UserAgent),
+		UserAgent:  sess.UserAgent,
+		IP:         sess.IP,
+		Current:    sess.ID == currentSessionID,
+		CreatedAt:  sess.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: sess.LastSeenAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,279 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-saas-api/internal/totp"
+	"go-saas-api/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaAudience keeps MFA challenge tokens from being accepted as access tokens
+	mfaAudience = "mfa"
+
+	recoveryCodeCount = 10
+)
+
+// recoveryEncoding avoids padding and is shown in lower case, e.g. "k3m9q-x2c7a"
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorStatus reports whether 2FA is on and how many recovery codes are left
+func (s *Service) TwoFactorStatus(ctx context.Context, userID uint64) (*TwoFactorStatusResponse, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabledAt.Valid {
+		return &TwoFactorStatusResponse{}, nil
+	}
+
+	n, err := s.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorStatusResponse{Enabled: true, RecoveryCodesRemaining: n}, nil
+}
+
+// SetupTwoFactor generates a new secret for the user to add to an authenticator
+// app. It has no effect on login until confirmed with ConfirmTwoFactor.
+func (s *Service) SetupTwoFactor(ctx context.Context, userID uint64) (*TwoFactorSetupResponse, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.SetTOTPSecret(ctx, userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.keys.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor turns 2FA on once the user proves their app generates valid
+// codes, and returns the first set of recovery codes
+func (s *Service) ConfirmTwoFactor(ctx context.Context, userID uint64, req ConfirmTwoFactorReq) (*RecoveryCodesResponse, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if !user.TOTPSecret.Valid {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret.String, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.repo.EnableTOTP(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns 2FA off and deletes the recovery codes
+func (s *Service) DisableTwoFactor(ctx context.Context, userID uint64, req TwoFactorPasswordReq) error {
+	user, err := s.twoFactorUser(ctx, userID, req.Password)
+	if err != nil {
+		return err
+	}
+	return s.repo.DisableTOTP(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, used or not
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID uint64, req TwoFactorPasswordReq) (*RecoveryCodesResponse, error) {
+	user, err := s.twoFactorUser(ctx, userID, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyMFA completes a login that returned an MFA challenge. The code is either
+// the current authenticator code or an unused recovery code. Wrong codes count
+// towards the same lockout as wrong passwords.
+func (s *Service) VerifyMFA(ctx context.Context, req VerifyMFAReq, client ClientInfo) (*AuthResponse, error) {
+	userID, jti, expiresAt, err := s.parseMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	revoked, err := s.repo.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if !user.TOTPEnabledAt.Valid {
+		return nil, ErrInvalidMFAToken
+	}
+	if err := s.checkCanLogin(user); err != nil {
+		return nil, err
+	}
+
+	keys := loginKeys(user.Email, client.IP)
+	if err := s.checkLoginLock(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.checkSecondFactor(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.recordLoginFailure(ctx, keys); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidMFACode
+	}
+	if err := s.repo.ClearLoginFailures(ctx, keys[0].key); err != nil {
+		return nil, err
+	}
+
+	// A challenge completes one login only
+	if err := s.repo.RevokeAccessToken(ctx, jti, user.ID, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return s.resumeSession(ctx, user, client)
+}
+
+// checkSecondFactor accepts an authenticator code not used before, or consumes a recovery code
+func (s *Service) checkSecondFactor(ctx context.Context, user *User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(user.TOTPSecret.String, code, time.Now()); ok {
+		return s.repo.UseTOTPStep(ctx, user.ID, step)
+	}
+	return s.repo.ConsumeRecoveryCode(ctx, user.ID, token.Hash(normalizeRecoveryCode(code)))
+}
+
+// twoFactorUser loads a user with 2FA on after re-checking their password
+func (s *Service) twoFactorUser(ctx context.Context, userID uint64, password string) (*User, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrPasswordIncorrect
+	}
+	if !user.TOTPEnabledAt.Valid {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return user, nil
+}
+
+// newMFAChallenge signs the short-lived token that stands in for the password
+// between the two login steps
+func (s *Service) newMFAChallenge(userID uint64) (*MFAChallenge, error) {
+	jti, err := token.Random(16)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := s.keys.Sign(jwt.MapClaims{
+		"sub": strconv.FormatUint(userID, 10),
+		"aud": mfaAudience,
+		"jti": jti,
+		"exp": time.Now().Add(mfaChallengeTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MFAChallenge{
+		MFARequired:  true,
+		MFAToken:     signed,
+		MFAExpiresIn: int64(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+func (s *Service) parseMFAToken(tokenString string) (uint64, string, time.Time, error) {
+	claims, err := s.keys.Parse(tokenString, mfaAudience)
+	if err != nil {
+		return 0, "", time.Time{}, err
+	}
+
+	sub, _ := claims.GetSubject()
+	userID, err := strconv.ParseUint(sub, 10, 64)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims.GetExpirationTime()
+	if err != nil || userID == 0 || jti == "" || exp == nil {
+		return 0, "", time.Time{}, jwt.ErrTokenInvalidClaims
+	}
+	return userID, jti, exp.Time, nil
+}
+
+// newRecoveryCodes returns codes to show the user and the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7) // 50 random bits
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = token.Hash(raw)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes as users retype codes
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
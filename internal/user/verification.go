@@ -0,0 +1,99 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-saas-api/internal/mailer"
+	"go-saas-api/pkg/token"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	// emailVerificationAudience keeps verification tokens from being accepted as access tokens
+	emailVerificationAudience = "email-verification"
+)
+
+// VerifyEmail consumes a verification token and marks the user's email as verified
+func (s *Service) VerifyEmail(ctx context.Context, req VerifyEmailReq) error {
+	userID, email, jti, err := s.parseVerificationToken(req.Token)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	ok, err := s.repo.ConsumeEmailVerification(ctx, jti, userID, email)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Unknown, already used, superseded, or the email changed since it was sent
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+// ResendVerification sends a fresh verification email, invalidating earlier ones
+func (s *Service) ResendVerification(ctx context.Context, userID uint64) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	return s.sendVerification(ctx, user.ID, user.Email, user.Name)
+}
+
+// sendVerification stores a single-use token for the user's current email and mails a link to it
+func (s *Service) sendVerification(ctx context.Context, userID uint64, email, name string) error {
+	jti, err := token.Random(16)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(emailVerificationTTL)
+
+	signed, err := s.keys.Sign(jwt.MapClaims{
+		"aud":   emailVerificationAudience,
+		"sub":   strconv.FormatUint(userID, 10),
+		"email": email,
+		"jti":   jti,
+		"exp":   expiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateEmailVerification(ctx, jti, userID, email, expiresAt); err != nil {
+		return err
+	}
+
+	link := s.appBaseURL + "/verify-email?token=" + url.QueryEscape(signed)
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\n"+
+			"The link expires in %d hours. If you did not create an account, you can ignore this email.\n",
+			name, link, int(emailVerificationTTL.Hours())),
+	})
+}
+
+func (s *Service) parseVerificationToken(tokenString string) (uint64, string, string, error) {
+	claims, err := s.keys.Parse(tokenString, emailVerificationAudience)
+	if err != nil {
+		return 0, "", "", jwt.ErrTokenInvalidClaims
+	}
+
+	sub, _ := claims.GetSubject()
+	userID, err := strconv.ParseUint(sub, 10, 64)
+	email, _ := claims["email"].(string)
+	jti, _ := claims["jti"].(string)
+	if err != nil || userID == 0 || email == "" || jti == "" {
+		return 0, "", "", jwt.ErrTokenInvalidClaims
+	}
+	return userID, email, jti, nil
+}
@@ -0,0 +1,45 @@
+package workspace
+
+type CreateWorkspaceReq struct {
+	Name string `json:"name" validate:"required,min=1,max=120"`
+}
+
+type UpdateWorkspaceReq struct {
+	Name *string `json:"name" validate:"omitempty,min=1,max=120"`
+}
+
+type UpdateMemberReq struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type CreateInvitationReq struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type InvitationTokenReq struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+type WorkspaceResponse struct {
+	ID        uint64 `json:"id"`
+	Name      string `json:"name"`
+	Personal  bool   `json:"personal"`
+	Role      string `json:"role"` // the caller's role
+	CreatedAt string `json:"created_at"`
+}
+
+type MemberResponse struct {
+	UserID   uint64 `json:"user_id"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joined_at"`
+}
+
+type InvitationResponse struct {
+	ID        uint64  `json:"id"`
+	Email     string  `json:"email"`
+	Role      string  `json:"role"`
+	InvitedBy *uint64 `json:"invited_by"`
+	ExpiresAt string  `json:"expires_at"`
+	CreatedAt string  `json:"created_at"`
+}
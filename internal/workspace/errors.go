@@ -0,0 +1,16 @@
+package workspace
+
+import "go-saas-api/pkg/apperror"
+
+var (
+	ErrWorkspaceNotFound  = apperror.New(apperror.KindNotFound, "workspace_not_found", "workspace not found")
+	ErrMemberNotFound     = apperror.New(apperror.KindNotFound, "member_not_found", "member not found")
+	ErrInvitationNotFound = apperror.New(apperror.KindNotFound, "invitation_not_found", "invitation not found")
+	ErrNoFieldsToUpdate   = apperror.New(apperror.KindInvalid, "no_fields_to_update", "no fields to update")
+	ErrPersonalWorkspace  = apperror.New(apperror.KindForbidden, "personal_workspace", "a personal workspace cannot be deleted or left by its user")
+	ErrLastOwner          = apperror.New(apperror.KindConflict, "last_owner", "a workspace must keep at least one owner")
+	ErrAlreadyMember      = apperror.New(apperror.KindConflict, "already_member", "this user is already a member of the workspace")
+	ErrInvalidInvitation  = apperror.New(apperror.KindInvalid, "invalid_invitation", "invalid or expired invitation")
+	ErrInvitationForOther = apperror.New(apperror.KindForbidden, "invitation_for_other_email", "this invitation was sent to another email address")
+	ErrEmailNotVerified   = apperror.New(apperror.KindForbidden, "email_not_verified", "email address must be verified first")
+)
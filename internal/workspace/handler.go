@@ -0,0 +1,416 @@
+package workspace
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-saas-api/pkg/apperror"
+	"go-saas-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Handler struct {
+	service *Service
+	v       *validator.Validate
+}
+
+func NewHandler(service *Service, v *validator.Validate) *Handler {
+	return &Handler{
+		service: service,
+		v:       v,
+	}
+}
+
+// Workspace Handlers
+
+// GET /workspaces
+func (h *Handler) ListWorkspaces(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListWorkspaces(ctx, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
+
+// POST /workspaces
+func (h *Handler) CreateWorkspace(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req CreateWorkspaceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.CreateWorkspace(ctx, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, result)
+}
+
+// GET /workspaces/:id
+func (h *Handler) GetWorkspace(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.GetWorkspace(ctx, id, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// PATCH /workspaces/:id
+func (h *Handler) UpdateWorkspace(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req UpdateWorkspaceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.RenameWorkspace(ctx, id, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// DELETE /workspaces/:id
+func (h *Handler) DeleteWorkspace(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DeleteWorkspace(ctx, id, userID.(uint64)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "workspace deleted")
+}
+
+// Member Handlers
+
+// GET /workspaces/:id/members
+func (h *Handler) ListMembers(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListMembers(ctx, id, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
+
+// PATCH /workspaces/:id/members/:userId
+func (h *Handler) UpdateMember(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req UpdateMemberReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.UpdateMemberRole(ctx, id, userID.(uint64), memberID, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "member role updated")
+}
+
+// DELETE /workspaces/:id/members/:userId
+func (h *Handler) RemoveMember(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.RemoveMember(ctx, id, userID.(uint64), memberID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "member removed")
+}
+
+// Invitation Handlers
+
+// GET /workspaces/:id/invitations
+func (h *Handler) ListInvitations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	items, err := h.service.ListInvitations(ctx, id, userID.(uint64))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"items": items})
+}
+
+// POST /workspaces/:id/invitations
+func (h *Handler) CreateInvitation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	var req CreateInvitationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // SMTP can be slow
+	defer cancel()
+
+	result, err := h.service.Invite(ctx, id, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, result)
+}
+
+// DELETE /workspaces/:id/invitations/:invitationId
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+	invitationID, err := strconv.ParseUint(c.Param("invitationId"), 10, 64)
+	if err != nil {
+		c.Error(apperror.ErrInvalidID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.RevokeInvitation(ctx, id, userID.(uint64), invitationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "invitation revoked")
+}
+
+// POST /invitations/accept
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req InvitationTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	result, err := h.service.AcceptInvitation(ctx, userID.(uint64), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// POST /invitations/decline
+func (h *Handler) DeclineInvitation(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperror.ErrUnauthorized)
+		return
+	}
+
+	var req InvitationTokenReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.ErrInvalidJSON)
+		return
+	}
+	if err := h.v.Struct(req); err != nil {
+		c.Error(apperror.ErrValidation.WithDetails(response.ValidationErrors(c, err)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+
+	if err := h.service.DeclineInvitation(ctx, userID.(uint64), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessMessage(c, http.StatusOK, "invitation declined")
+}
@@ -0,0 +1,196 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-saas-api/internal/mailer"
+	"go-saas-api/internal/middleware"
+	"go-saas-api/pkg/token"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+// Invite mails an invitation to join the workspace. Inviting an address that
+// already has a pending invitation replaces it.
+func (s *Service) Invite(ctx context.Context, id, userID uint64, req CreateInvitationReq) (*InvitationResponse, error) {
+	if err := s.requireRole(ctx, id, userID, middleware.WorkspaceRoleOwner); err != nil {
+		return nil, err
+	}
+
+	w, err := s.repo.GetWorkspace(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	// Existing accounts that already belong to the workspace need no invitation
+	invitee, err := s.users.GetByEmail(ctx, req.Email)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		_, err := s.repo.GetMemberRole(ctx, id, invitee.ID)
+		if err == nil {
+			return nil, ErrAlreadyMember
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	inviter, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := token.Random(32)
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invitation{
+		WorkspaceID: id,
+		Email:       req.Email,
+		Role:        req.Role,
+		TokenHash:   token.Hash(raw),
+		InvitedBy:   sql.NullInt64{Int64: int64(userID), Valid: true},
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}
+	if err := s.repo.CreateInvitation(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	link := s.appBaseURL + "/invitations?token=" + url.QueryEscape(raw)
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      req.Email,
+		Subject: fmt.Sprintf("%s invited you to %s", inviter.Name, w.Name),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to join the workspace \"%s\" as %s. Open the link below to accept or decline:\n\n%s\n\n"+
+			"The invitation expires in %d days. If you were not expecting it, you can ignore this email.\n",
+			inviter.Name, w.Name, req.Role, link, int(invitationTTL.Hours()/24)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toInvitationResponse(inv)
+	return &resp, nil
+}
+
+// ListInvitations returns the workspace's pending invitations to its owners
+func (s *Service) ListInvitations(ctx context.Context, id, userID uint64) ([]InvitationResponse, error) {
+	if err := s.requireRole(ctx, id, userID, middleware.WorkspaceRoleOwner); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.repo.ListInvitations(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]InvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		items = append(items, toInvitationResponse(&invitations[i]))
+	}
+	return items, nil
+}
+
+// RevokeInvitation deletes an invitation so its link stops working
+func (s *Service) RevokeInvitation(ctx context.Context, id, userID, invitationID uint64) error {
+	if err := s.requireRole(ctx, id, userID, middleware.WorkspaceRoleOwner); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.DeleteInvitation(ctx, invitationID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation adds the user to the invitation's workspace. The invitation must
+// have been sent to the user's own, verified email address. Users who are already
+// members keep their current role.
+func (s *Service) AcceptInvitation(ctx context.Context, userID uint64, req InvitationTokenReq) (*WorkspaceResponse, error) {
+	inv, err := s.invitationFor(ctx, userID, req.Token, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.repo.AcceptInvitation(ctx, inv.ID, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Accepted, declined or revoked concurrently
+		return nil, ErrInvalidInvitation
+	}
+	return s.GetWorkspace(ctx, inv.WorkspaceID, userID)
+}
+
+// DeclineInvitation turns an invitation down so it can no longer be used
+func (s *Service) DeclineInvitation(ctx context.Context, userID uint64, req InvitationTokenReq) error {
+	inv, err := s.invitationFor(ctx, userID, req.Token, false)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.repo.DeclineInvitation(ctx, inv.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidInvitation
+	}
+	return nil
+}
+
+// invitationFor returns the pending invitation behind token if it was sent to the user's email
+func (s *Service) invitationFor(ctx context.Context, userID uint64, raw string, requireVerified bool) (*Invitation, error) {
+	inv, err := s.repo.GetInvitationByHash(ctx, token.Hash(raw))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+	if !inv.pending(time.Now()) {
+		return nil, ErrInvalidInvitation
+	}
+
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(u.Email, inv.Email) {
+		return nil, ErrInvitationForOther
+	}
+	// Only a verified address proves the user received the invitation
+	if requireVerified && !u.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+	return inv, nil
+}
+
+func toInvitationResponse(inv *Invitation) InvitationResponse {
+	resp := InvitationResponse{
+		ID:        inv.ID,
+		Email:     inv.Email,
+		Role:      inv.Role,
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+	}
+	if inv.InvitedBy.Valid {
+		invitedBy := uint64(inv.InvitedBy.Int64)
+		resp.InvitedBy = &invitedBy
+	}
+	return resp
+}
@@ -0,0 +1,308 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository is an in-process WorkspaceRepository for tests and local experiments.
+// It mirrors the Postgres repository, including the cascades from a deleted
+// workspace to its members and invitations.
+type MemoryRepository struct {
+	mu              sync.Mutex
+	workspaces      map[uint64]Workspace
+	members         []Member
+	invitations     map[uint64]Invitation
+	nextWorkspaceID uint64
+	nextInviteID    uint64
+}
+
+var _ WorkspaceRepository = (*MemoryRepository)(nil)
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		workspaces:  map[uint64]Workspace{},
+		invitations: map[uint64]Invitation{},
+	}
+}
+
+// Workspace Repository Methods
+
+func (r *MemoryRepository) EnsurePersonalWorkspace(ctx context.Context, userID uint64, name string) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, w := range r.workspaces {
+		if w.PersonalUserID.Valid && uint64(w.PersonalUserID.Int64) == userID {
+			return w.ID, nil
+		}
+	}
+	w := r.insertWorkspace(name, userID)
+	w.PersonalUserID = sql.NullInt64{Int64: int64(userID), Valid: true}
+	r.workspaces[w.ID] = w
+	return w.ID, nil
+}
+
+func (r *MemoryRepository) CreateWorkspace(ctx context.Context, name string, ownerID uint64) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insertWorkspace(name, ownerID).ID, nil
+}
+
+func (r *MemoryRepository) GetWorkspace(ctx context.Context, id uint64) (*Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.workspaces[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &w, nil
+}
+
+func (r *MemoryRepository) ListMemberships(ctx context.Context, userID uint64) ([]Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []Membership
+	for _, m := range r.members {
+		if m.UserID == userID {
+			items = append(items, Membership{Workspace: r.workspaces[m.WorkspaceID], Role: m.Role})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.PersonalUserID.Valid != b.PersonalUserID.Valid {
+			return a.PersonalUserID.Valid
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	return items, nil
+}
+
+func (r *MemoryRepository) RenameWorkspace(ctx context.Context, id uint64, name string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.workspaces[id]
+	if !ok {
+		return false, nil
+	}
+	w.Name = name
+	w.UpdatedAt = time.Now()
+	r.workspaces[id] = w
+	return true, nil
+}
+
+func (r *MemoryRepository) DeleteWorkspace(ctx context.Context, id uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.workspaces[id]; !ok {
+		return false, nil
+	}
+	delete(r.workspaces, id)
+	r.removeMembers(func(m Member) bool { return m.WorkspaceID == id })
+	for invID, inv := range r.invitations {
+		if inv.WorkspaceID == id {
+			delete(r.invitations, invID)
+		}
+	}
+	return true, nil
+}
+
+// Member Repository Methods
+
+func (r *MemoryRepository) GetMemberRole(ctx context.Context, workspaceID, userID uint64) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m := r.member(workspaceID, userID); m != nil {
+		return m.Role, nil
+	}
+	return "", sql.ErrNoRows
+}
+
+func (r *MemoryRepository) ListMembers(ctx context.Context, workspaceID uint64) ([]Member, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// members is kept in insertion order, which matches ORDER BY created_at
+	var items []Member
+	for _, m := range r.members {
+		if m.WorkspaceID == workspaceID {
+			items = append(items, m)
+		}
+	}
+	return items, nil
+}
+
+func (r *MemoryRepository) SetMemberRole(ctx context.Context, workspaceID, userID uint64, role string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := r.member(workspaceID, userID)
+	if m == nil {
+		return false, nil
+	}
+	if role != "owner" && r.lastOwner(workspaceID, userID) {
+		return false, ErrLastOwner
+	}
+	m.Role = role
+	return true, nil
+}
+
+func (r *MemoryRepository) RemoveMember(ctx context.Context, workspaceID, userID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastOwner(workspaceID, userID) {
+		return false, ErrLastOwner
+	}
+	removed := r.removeMembers(func(m Member) bool { return m.WorkspaceID == workspaceID && m.UserID == userID })
+	return removed > 0, nil
+}
+
+// Invitation Repository Methods
+
+func (r *MemoryRepository) CreateInvitation(ctx context.Context, inv *Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, other := range r.invitations {
+		if other.WorkspaceID == inv.WorkspaceID && strings.EqualFold(other.Email, inv.Email) &&
+			!other.AcceptedAt.Valid && !other.DeclinedAt.Valid {
+			delete(r.invitations, id)
+		}
+	}
+
+	r.nextInviteID++
+	inv.ID = r.nextInviteID
+	inv.CreatedAt = time.Now()
+	r.invitations[inv.ID] = *inv
+	return nil
+}
+
+func (r *MemoryRepository) ListInvitations(ctx context.Context, workspaceID uint64, now time.Time) ([]Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var items []Invitation
+	for _, inv := range r.invitations {
+		if inv.WorkspaceID == workspaceID && inv.pending(now) {
+			items = append(items, inv)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	return items, nil
+}
+
+func (r *MemoryRepository) GetInvitationByHash(ctx context.Context, tokenHash string) (*Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, inv := range r.invitations {
+		if inv.TokenHash == tokenHash {
+			return &inv, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepository) AcceptInvitation(ctx context.Context, id, userID uint64, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[id]
+	if !ok || !inv.pending(now) {
+		return false, nil
+	}
+	inv.AcceptedAt = sql.NullTime{Time: now, Valid: true}
+	r.invitations[id] = inv
+
+	if r.member(inv.WorkspaceID, userID) == nil {
+		r.members = append(r.members, Member{WorkspaceID: inv.WorkspaceID, UserID: userID, Role: inv.Role, CreatedAt: now})
+	}
+	return true, nil
+}
+
+func (r *MemoryRepository) DeclineInvitation(ctx context.Context, id uint64, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[id]
+	if !ok || !inv.pending(now) {
+		return false, nil
+	}
+	inv.DeclinedAt = sql.NullTime{Time: now, Valid: true}
+	r.invitations[id] = inv
+	return true, nil
+}
+
+func (r *MemoryRepository) DeleteInvitation(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[id]
+	if !ok || inv.WorkspaceID != workspaceID {
+		return false, nil
+	}
+	delete(r.invitations, id)
+	return true, nil
+}
+
+// Helpers - callers hold mu
+
+// insertWorkspace stores a new workspace with ownerID as its owner
+func (r *MemoryRepository) insertWorkspace(name string, ownerID uint64) Workspace {
+	r.nextWorkspaceID++
+	now := time.Now()
+	w := Workspace{ID: r.nextWorkspaceID, Name: name, CreatedAt: now, UpdatedAt: now}
+	r.workspaces[w.ID] = w
+	r.members = append(r.members, Member{WorkspaceID: w.ID, UserID: ownerID, Role: "owner", CreatedAt: now})
+	return w
+}
+
+func (r *MemoryRepository) member(workspaceID, userID uint64) *Member {
+	for i := range r.members {
+		if r.members[i].WorkspaceID == workspaceID && r.members[i].UserID == userID {
+			return &r.members[i]
+		}
+	}
+	return nil
+}
+
+// lastOwner reports whether userID is the workspace's only owner
+func (r *MemoryRepository) lastOwner(workspaceID, userID uint64) bool {
+	if m := r.member(workspaceID, userID); m == nil || m.Role != "owner" {
+		return false
+	}
+	for _, m := range r.members {
+		if m.WorkspaceID == workspaceID && m.UserID != userID && m.Role == "owner" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MemoryRepository) removeMembers(match func(Member) bool) int {
+	kept := r.members[:0]
+	removed := 0
+	for _, m := range r.members {
+		if match(m) {
+			removed++
+			continue
+		}
+		kept = append(kept, m)
+	}
+	r.members = kept
+	return removed
+}
@@ -0,0 +1,49 @@
+package workspace
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Workspace owns places and categories and is shared by its members
+type Workspace struct {
+	ID             uint64        `db:"id"`
+	Name           string        `db:"name"`
+	PersonalUserID sql.NullInt64 `db:"personal_user_id"` // set on the workspace each user gets automatically
+	CreatedAt      time.Time     `db:"created_at"`
+	UpdatedAt      time.Time     `db:"updated_at"`
+}
+
+// Membership is a workspace together with one member's role in it
+type Membership struct {
+	Workspace
+	Role string `db:"role"`
+}
+
+// Member is a user's role in a workspace
+type Member struct {
+	WorkspaceID uint64    `db:"workspace_id"`
+	UserID      uint64    `db:"user_id"`
+	Role        string    `db:"role"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Invitation asks the holder of an email address to join a workspace. Only the
+// hash of its token is stored; the token itself is mailed to the invitee.
+type Invitation struct {
+	ID          uint64        `db:"id"`
+	WorkspaceID uint64        `db:"workspace_id"`
+	Email       string        `db:"email"`
+	Role        string        `db:"role"`
+	TokenHash   string        `db:"token_hash"`
+	InvitedBy   sql.NullInt64 `db:"invited_by"`
+	ExpiresAt   time.Time     `db:"expires_at"`
+	AcceptedAt  sql.NullTime  `db:"accepted_at"`
+	DeclinedAt  sql.NullTime  `db:"declined_at"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+// pending reports whether the invitation can still be accepted or declined
+func (inv *Invitation) pending(now time.Time) bool {
+	return !inv.AcceptedAt.Valid && !inv.DeclinedAt.Valid && now.Before(inv.ExpiresAt)
+}
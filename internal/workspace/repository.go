@@ -0,0 +1,394 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WorkspaceRepository is the storage used by Service.
+// Repository (Postgres) and MemoryRepository implement it; lookups that find
+// nothing return sql.ErrNoRows and updates/deletes of missing rows return false.
+type WorkspaceRepository interface {
+	EnsurePersonalWorkspace(ctx context.Context, userID uint64, name string) (uint64, error)
+	CreateWorkspace(ctx context.Context, name string, ownerID uint64) (uint64, error)
+	GetWorkspace(ctx context.Context, id uint64) (*Workspace, error)
+	ListMemberships(ctx context.Context, userID uint64) ([]Membership, error)
+	RenameWorkspace(ctx context.Context, id uint64, name string) (bool, error)
+	DeleteWorkspace(ctx context.Context, id uint64) (bool, error)
+
+	GetMemberRole(ctx context.Context, workspaceID, userID uint64) (string, error)
+	ListMembers(ctx context.Context, workspaceID uint64) ([]Member, error)
+	SetMemberRole(ctx context.Context, workspaceID, userID uint64, role string) (bool, error)
+	RemoveMember(ctx context.Context, workspaceID, userID uint64) (bool, error)
+
+	CreateInvitation(ctx context.Context, inv *Invitation) error
+	ListInvitations(ctx context.Context, workspaceID uint64, now time.Time) ([]Invitation, error)
+	GetInvitationByHash(ctx context.Context, tokenHash string) (*Invitation, error)
+	AcceptInvitation(ctx context.Context, id, userID uint64, now time.Time) (bool, error)
+	DeclineInvitation(ctx context.Context, id uint64, now time.Time) (bool, error)
+	DeleteInvitation(ctx context.Context, id, workspaceID uint64) (bool, error)
+}
+
+type Repository struct {
+	db *sqlx.DB
+}
+
+var _ WorkspaceRepository = (*Repository)(nil)
+
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Workspace Repository Methods
+
+// EnsurePersonalWorkspace returns the user's personal workspace, creating it with
+// the user as owner on first use
+func (r *Repository) EnsurePersonalWorkspace(ctx context.Context, userID uint64, name string) (uint64, error) {
+	var id uint64
+	err := r.db.GetContext(ctx, &id, `SELECT id FROM workspaces WHERE personal_user_id = $1`, userID)
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &id,
+		`INSERT INTO workspaces (name, personal_user_id) VALUES ($1, $2)
+		ON CONFLICT (personal_user_id) DO NOTHING RETURNING id`,
+		name, userID,
+	)
+	if err == sql.ErrNoRows {
+		// Created by a concurrent request
+		err = r.db.GetContext(ctx, &id, `SELECT id FROM workspaces WHERE personal_user_id = $1`, userID)
+		return id, err
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := addMember(ctx, tx, id, userID, "owner"); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// CreateWorkspace creates a shared workspace with ownerID as its first owner
+func (r *Repository) CreateWorkspace(ctx context.Context, name string, ownerID uint64) (uint64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id uint64
+	if err := tx.GetContext(ctx, &id, `INSERT INTO workspaces (name) VALUES ($1) RETURNING id`, name); err != nil {
+		return 0, err
+	}
+	if err := addMember(ctx, tx, id, ownerID, "owner"); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *Repository) GetWorkspace(ctx context.Context, id uint64) (*Workspace, error) {
+	var w Workspace
+	err := r.db.GetContext(ctx, &w,
+		`SELECT id, name, personal_user_id, created_at, updated_at FROM workspaces WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListMemberships returns the user's workspaces, the personal one first
+func (r *Repository) ListMemberships(ctx context.Context, userID uint64) ([]Membership, error) {
+	var items []Membership
+	err := r.db.SelectContext(ctx, &items,
+		`SELECT w.id, w.name, w.personal_user_id, w.created_at, w.updated_at, m.role
+		FROM workspace_members m
+		JOIN workspaces w ON w.id = m.workspace_id
+		WHERE m.user_id = $1
+		ORDER BY w.personal_user_id IS NULL, w.name, w.id`,
+		userID,
+	)
+	return items, err
+}
+
+func (r *Repository) RenameWorkspace(ctx context.Context, id uint64, name string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE workspaces SET name = $1 WHERE id = $2`, name, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DeleteWorkspace removes a workspace with its members, invitations and places (ON DELETE CASCADE)
+func (r *Repository) DeleteWorkspace(ctx context.Context, id uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM workspaces WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// Member Repository Methods
+
+func (r *Repository) GetMemberRole(ctx context.Context, workspaceID, userID uint64) (string, error) {
+	var role string
+	err := r.db.GetContext(ctx, &role,
+		`SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`,
+		workspaceID, userID,
+	)
+	return role, err
+}
+
+func (r *Repository) ListMembers(ctx context.Context, workspaceID uint64) ([]Member, error) {
+	var items []Member
+	err := r.db.SelectContext(ctx, &items,
+		`SELECT workspace_id, user_id, role, created_at FROM workspace_members
+		WHERE workspace_id = $1 ORDER BY created_at, user_id`,
+		workspaceID,
+	)
+	return items, err
+}
+
+// SetMemberRole changes a member's role. Demoting the last owner fails with
+// ErrLastOwner.
+func (r *Repository) SetMemberRole(ctx context.Context, workspaceID, userID uint64, role string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if role != "owner" {
+		if err := checkOtherOwner(ctx, tx, workspaceID, userID); err != nil {
+			if err == sql.ErrNoRows {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND user_id = $3`,
+		role, workspaceID, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, tx.Commit()
+}
+
+// RemoveMember removes a membership. Removing the last owner fails with ErrLastOwner.
+func (r *Repository) RemoveMember(ctx context.Context, workspaceID, userID uint64) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOtherOwner(ctx, tx, workspaceID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`,
+		workspaceID, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, tx.Commit()
+}
+
+// checkOtherOwner locks the workspace inside tx and returns ErrLastOwner if
+// userID is its only owner. The lock makes owners demoting or removing each
+// other at the same time take turns, so the second sees the first's change.
+// A missing workspace gives sql.ErrNoRows.
+func checkOtherOwner(ctx context.Context, tx *sqlx.Tx, workspaceID, userID uint64) error {
+	var id uint64
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM workspaces WHERE id = $1 FOR UPDATE`, workspaceID).Scan(&id); err != nil {
+		return err
+	}
+
+	var isOwner, otherOwner bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT
+			EXISTS (SELECT 1 FROM workspace_members WHERE workspace_id = $1 AND user_id = $2 AND role = 'owner'),
+			EXISTS (SELECT 1 FROM workspace_members WHERE workspace_id = $1 AND user_id <> $2 AND role = 'owner')`,
+		workspaceID, userID,
+	).Scan(&isOwner, &otherOwner)
+	if err != nil {
+		return err
+	}
+	if isOwner && !otherOwner {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// addMember inserts a membership inside tx; an existing membership keeps its role
+func addMember(ctx context.Context, tx *sqlx.Tx, workspaceID, userID uint64, role string) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, user_id) DO NOTHING`,
+		workspaceID, userID, role,
+	)
+	return err
+}
+
+// Invitation Repository Methods
+
+// CreateInvitation stores inv, replacing any pending invitation to the same
+// address, and sets its ID and CreatedAt
+func (r *Repository) CreateInvitation(ctx context.Context, inv *Invitation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM workspace_invitations
+		WHERE workspace_id = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL AND declined_at IS NULL`,
+		inv.WorkspaceID, inv.Email,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowxContext(ctx,
+		`INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		inv.WorkspaceID, inv.Email, inv.Role, inv.TokenHash, inv.InvitedBy, inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListInvitations returns the invitations of a workspace still awaiting an answer
+func (r *Repository) ListInvitations(ctx context.Context, workspaceID uint64, now time.Time) ([]Invitation, error) {
+	var items []Invitation
+	err := r.db.SelectContext(ctx, &items,
+		`SELECT id, workspace_id, email, role, token_hash, invited_by, expires_at, accepted_at, declined_at, created_at
+		FROM workspace_invitations
+		WHERE workspace_id = $1 AND accepted_at IS NULL AND declined_at IS NULL AND expires_at > $2
+		ORDER BY created_at DESC, id DESC`,
+		workspaceID, now,
+	)
+	return items, err
+}
+
+func (r *Repository) GetInvitationByHash(ctx context.Context, tokenHash string) (*Invitation, error) {
+	var inv Invitation
+	err := r.db.GetContext(ctx, &inv,
+		`SELECT id, workspace_id, email, role, token_hash, invited_by, expires_at, accepted_at, declined_at, created_at
+		FROM workspace_invitations WHERE token_hash = $1`,
+		tokenHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// AcceptInvitation marks a pending invitation accepted and adds userID to the
+// workspace with the invited role in one transaction. It returns false if the
+// invitation was answered, revoked or expired in the meantime.
+func (r *Repository) AcceptInvitation(ctx context.Context, id, userID uint64, now time.Time) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var inv Invitation
+	err = tx.GetContext(ctx, &inv,
+		`UPDATE workspace_invitations SET accepted_at = $2
+		WHERE id = $1 AND accepted_at IS NULL AND declined_at IS NULL AND expires_at > $2
+		RETURNING workspace_id, role`,
+		id, now,
+	)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := addMember(ctx, tx, inv.WorkspaceID, userID, inv.Role); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Repository) DeclineInvitation(ctx context.Context, id uint64, now time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE workspace_invitations SET declined_at = $2
+		WHERE id = $1 AND accepted_at IS NULL AND declined_at IS NULL AND expires_at > $2`,
+		id, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *Repository) DeleteInvitation(ctx context.Context, id, workspaceID uint64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM workspace_invitations WHERE id = $1 AND workspace_id = $2`,
+		id, workspaceID,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,359 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"go-saas-api/internal/testutil"
+)
+
+// backends lists every WorkspaceRepository implementation the suite runs against.
+// new returns the repository and the IDs of three users.
+var backends = []struct {
+	name string
+	new  func(t *testing.T) (WorkspaceRepository, []uint64)
+}{
+	{"memory", func(t *testing.T) (WorkspaceRepository, []uint64) {
+		return NewMemoryRepository(), []uint64{1, 2, 3}
+	}},
+	{"postgres", func(t *testing.T) (WorkspaceRepository, []uint64) {
+		db := testutil.PostgresDB(t)
+		var ids []uint64
+		err := db.Select(&ids, `INSERT INTO users (email, password, name)
+			VALUES ('a@example.com', 'x', 'A'), ('b@example.com', 'x', 'B'), ('c@example.com', 'x', 'C') RETURNING id`)
+		if err != nil {
+			t.Fatalf("insert users: %v", err)
+		}
+		return NewRepository(db), ids
+	}},
+}
+
+func TestRepositoryWorkspaces(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo WorkspaceRepository, users []uint64)
+	}{
+		{"personal workspace is created once", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, users []uint64) {
+			first, err := repo.EnsurePersonalWorkspace(ctx, users[0], "Personal")
+			if err != nil {
+				t.Fatalf("EnsurePersonalWorkspace: %v", err)
+			}
+			second, err := repo.EnsurePersonalWorkspace(ctx, users[0], "Personal")
+			if err != nil || second != first {
+				t.Fatalf("second EnsurePersonalWorkspace = %d, %v; want %d", second, err, first)
+			}
+
+			w, err := repo.GetWorkspace(ctx, first)
+			if err != nil {
+				t.Fatalf("GetWorkspace: %v", err)
+			}
+			if !w.PersonalUserID.Valid || uint64(w.PersonalUserID.Int64) != users[0] {
+				t.Errorf("personal_user_id = %v, want %d", w.PersonalUserID, users[0])
+			}
+			if role, err := repo.GetMemberRole(ctx, first, users[0]); err != nil || role != "owner" {
+				t.Errorf("role = %q, %v; want owner", role, err)
+			}
+		}},
+		{"missing workspace is sql.ErrNoRows", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, users []uint64) {
+			if _, err := repo.GetWorkspace(ctx, 999); err != sql.ErrNoRows {
+				t.Errorf("GetWorkspace err = %v, want sql.ErrNoRows", err)
+			}
+			if _, err := repo.GetMemberRole(ctx, 999, users[0]); err != sql.ErrNoRows {
+				t.Errorf("GetMemberRole err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"memberships list personal first, then by name", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, users []uint64) {
+			zeta := mustCreateWorkspace(t, ctx, repo, "Zeta", users[0])
+			alpha := mustCreateWorkspace(t, ctx, repo, "Alpha", users[0])
+			personal, _ := repo.EnsurePersonalWorkspace(ctx, users[0], "Personal")
+			mustCreateWorkspace(t, ctx, repo, "Other", users[1])
+
+			items, err := repo.ListMemberships(ctx, users[0])
+			if err != nil {
+				t.Fatalf("ListMemberships: %v", err)
+			}
+			want := []uint64{personal, alpha, zeta}
+			if len(items) != len(want) {
+				t.Fatalf("got %d memberships, want %d", len(items), len(want))
+			}
+			for i, id := range want {
+				if items[i].ID != id || items[i].Role != "owner" {
+					t.Errorf("items[%d] = %d/%s, want %d/owner", i, items[i].ID, items[i].Role, id)
+				}
+			}
+		}},
+		{"rename and delete", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, users []uint64) {
+			id := mustCreateWorkspace(t, ctx, repo, "Team", users[0])
+
+			if ok, err := repo.RenameWorkspace(ctx, id, "Crew"); err != nil || !ok {
+				t.Fatalf("RenameWorkspace = %v, %v", ok, err)
+			}
+			if w, _ := repo.GetWorkspace(ctx, id); w == nil || w.Name != "Crew" {
+				t.Errorf("workspace after rename = %+v", w)
+			}
+
+			if ok, err := repo.DeleteWorkspace(ctx, id); err != nil || !ok {
+				t.Fatalf("DeleteWorkspace = %v, %v", ok, err)
+			}
+			if _, err := repo.GetMemberRole(ctx, id, users[0]); err != sql.ErrNoRows {
+				t.Errorf("membership survived deletion: %v", err)
+			}
+			if ok, _ := repo.DeleteWorkspace(ctx, id); ok {
+				t.Error("deleting twice reported success")
+			}
+			if ok, _ := repo.RenameWorkspace(ctx, id, "Gone"); ok {
+				t.Error("renaming a deleted workspace reported success")
+			}
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				repo, users := b.new(t)
+				tt.run(t, context.Background(), repo, users)
+			})
+		}
+	}
+}
+
+func TestRepositoryMembers(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64)
+	}{
+		{"accepting an invitation adds a member", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			addTestMember(t, ctx, repo, wsID, users[1], "b@example.com", "editor")
+
+			members, err := repo.ListMembers(ctx, wsID)
+			if err != nil {
+				t.Fatalf("ListMembers: %v", err)
+			}
+			if len(members) != 2 || members[0].UserID != users[0] || members[1].UserID != users[1] || members[1].Role != "editor" {
+				t.Errorf("members = %+v", members)
+			}
+		}},
+		{"set role and remove", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			addTestMember(t, ctx, repo, wsID, users[1], "b@example.com", "viewer")
+
+			if n := countOwners(t, ctx, repo, wsID); n != 1 {
+				t.Errorf("owners = %d, want 1", n)
+			}
+			if ok, err := repo.SetMemberRole(ctx, wsID, users[1], "owner"); err != nil || !ok {
+				t.Fatalf("SetMemberRole = %v, %v", ok, err)
+			}
+			if n := countOwners(t, ctx, repo, wsID); n != 2 {
+				t.Errorf("owners = %d, want 2", n)
+			}
+
+			if ok, err := repo.RemoveMember(ctx, wsID, users[1]); err != nil || !ok {
+				t.Fatalf("RemoveMember = %v, %v", ok, err)
+			}
+			if _, err := repo.GetMemberRole(ctx, wsID, users[1]); err != sql.ErrNoRows {
+				t.Errorf("GetMemberRole err = %v, want sql.ErrNoRows", err)
+			}
+			if ok, _ := repo.RemoveMember(ctx, wsID, users[1]); ok {
+				t.Error("removing twice reported success")
+			}
+			if ok, _ := repo.SetMemberRole(ctx, wsID, users[2], "editor"); ok {
+				t.Error("SetMemberRole on a non-member reported success")
+			}
+		}},
+		{"last owner cannot be demoted or removed", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			if _, err := repo.SetMemberRole(ctx, wsID, users[0], "editor"); err != ErrLastOwner {
+				t.Errorf("SetMemberRole err = %v, want ErrLastOwner", err)
+			}
+			if _, err := repo.RemoveMember(ctx, wsID, users[0]); err != ErrLastOwner {
+				t.Errorf("RemoveMember err = %v, want ErrLastOwner", err)
+			}
+			if role, _ := repo.GetMemberRole(ctx, wsID, users[0]); role != "owner" {
+				t.Errorf("role = %q, want owner", role)
+			}
+		}},
+		{"owners demoting each other at once keep one owner", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			addTestMember(t, ctx, repo, wsID, users[1], "b@example.com", "owner")
+
+			var wg sync.WaitGroup
+			for _, id := range users[:2] {
+				wg.Add(1)
+				go func(id uint64) {
+					defer wg.Done()
+					_, _ = repo.SetMemberRole(ctx, wsID, id, "editor")
+				}(id)
+			}
+			wg.Wait()
+
+			if n := countOwners(t, ctx, repo, wsID); n != 1 {
+				t.Errorf("owners = %d, want 1", n)
+			}
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo, users := b.new(t)
+				tt.run(t, ctx, repo, mustCreateWorkspace(t, ctx, repo, "Team", users[0]), users)
+			})
+		}
+	}
+}
+
+func TestRepositoryInvitations(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64)
+	}{
+		{"create, get by hash and list pending", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			inv := mustCreateInvitation(t, ctx, repo, wsID, users[0], "b@example.com", "hash-1", time.Now().Add(time.Hour))
+			mustCreateInvitation(t, ctx, repo, wsID, users[0], "c@example.com", "hash-2", time.Now().Add(-time.Minute))
+
+			got, err := repo.GetInvitationByHash(ctx, "hash-1")
+			if err != nil {
+				t.Fatalf("GetInvitationByHash: %v", err)
+			}
+			if got.ID != inv.ID || got.Email != "b@example.com" || got.Role != "editor" || got.InvitedBy.Int64 != int64(users[0]) {
+				t.Errorf("got %+v", got)
+			}
+			if _, err := repo.GetInvitationByHash(ctx, "unknown"); err != sql.ErrNoRows {
+				t.Errorf("unknown hash err = %v, want sql.ErrNoRows", err)
+			}
+
+			items, err := repo.ListInvitations(ctx, wsID, time.Now())
+			if err != nil {
+				t.Fatalf("ListInvitations: %v", err)
+			}
+			if len(items) != 1 || items[0].ID != inv.ID {
+				t.Errorf("pending invitations = %+v, want only %d", items, inv.ID)
+			}
+		}},
+		{"re-inviting replaces the pending invitation", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			mustCreateInvitation(t, ctx, repo, wsID, users[0], "b@example.com", "hash-1", time.Now().Add(time.Hour))
+			second := mustCreateInvitation(t, ctx, repo, wsID, users[0], "B@Example.com", "hash-2", time.Now().Add(time.Hour))
+
+			if _, err := repo.GetInvitationByHash(ctx, "hash-1"); err != sql.ErrNoRows {
+				t.Errorf("old invitation err = %v, want sql.ErrNoRows", err)
+			}
+			items, _ := repo.ListInvitations(ctx, wsID, time.Now())
+			if len(items) != 1 || items[0].ID != second.ID {
+				t.Errorf("pending invitations = %+v, want only %d", items, second.ID)
+			}
+		}},
+		{"accept once, keeping an existing role", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			inv := mustCreateInvitation(t, ctx, repo, wsID, users[0], "a@example.com", "hash-1", time.Now().Add(time.Hour))
+
+			if ok, err := repo.AcceptInvitation(ctx, inv.ID, users[0], time.Now()); err != nil || !ok {
+				t.Fatalf("AcceptInvitation = %v, %v", ok, err)
+			}
+			if role, _ := repo.GetMemberRole(ctx, wsID, users[0]); role != "owner" {
+				t.Errorf("role = %q, want owner kept", role)
+			}
+			if ok, _ := repo.AcceptInvitation(ctx, inv.ID, users[0], time.Now()); ok {
+				t.Error("accepting twice reported success")
+			}
+		}},
+		{"expired invitation cannot be accepted", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			inv := mustCreateInvitation(t, ctx, repo, wsID, users[0], "b@example.com", "hash-1", time.Now().Add(time.Hour))
+
+			if ok, _ := repo.AcceptInvitation(ctx, inv.ID, users[1], time.Now().Add(2*time.Hour)); ok {
+				t.Error("expired invitation was accepted")
+			}
+			if _, err := repo.GetMemberRole(ctx, wsID, users[1]); err != sql.ErrNoRows {
+				t.Errorf("GetMemberRole err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+		{"declined invitation cannot be accepted", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			inv := mustCreateInvitation(t, ctx, repo, wsID, users[0], "b@example.com", "hash-1", time.Now().Add(time.Hour))
+
+			if ok, err := repo.DeclineInvitation(ctx, inv.ID, time.Now()); err != nil || !ok {
+				t.Fatalf("DeclineInvitation = %v, %v", ok, err)
+			}
+			if ok, _ := repo.AcceptInvitation(ctx, inv.ID, users[1], time.Now()); ok {
+				t.Error("declined invitation was accepted")
+			}
+			if ok, _ := repo.DeclineInvitation(ctx, inv.ID, time.Now()); ok {
+				t.Error("declining twice reported success")
+			}
+		}},
+		{"delete is scoped to the workspace", func(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64, users []uint64) {
+			inv := mustCreateInvitation(t, ctx, repo, wsID, users[0], "b@example.com", "hash-1", time.Now().Add(time.Hour))
+			other := mustCreateWorkspace(t, ctx, repo, "Other", users[1])
+
+			if ok, _ := repo.DeleteInvitation(ctx, inv.ID, other); ok {
+				t.Error("deleted an invitation through another workspace")
+			}
+			if ok, err := repo.DeleteInvitation(ctx, inv.ID, wsID); err != nil || !ok {
+				t.Fatalf("DeleteInvitation = %v, %v", ok, err)
+			}
+			if _, err := repo.GetInvitationByHash(ctx, "hash-1"); err != sql.ErrNoRows {
+				t.Errorf("GetInvitationByHash err = %v, want sql.ErrNoRows", err)
+			}
+		}},
+	}
+
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo, users := b.new(t)
+				tt.run(t, ctx, repo, mustCreateWorkspace(t, ctx, repo, "Team", users[0]), users)
+			})
+		}
+	}
+}
+
+func mustCreateWorkspace(t *testing.T, ctx context.Context, repo WorkspaceRepository, name string, ownerID uint64) uint64 {
+	t.Helper()
+	id, err := repo.CreateWorkspace(ctx, name, ownerID)
+	if err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	return id
+}
+
+func mustCreateInvitation(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID, invitedBy uint64, email, hash string, expiresAt time.Time) *Invitation {
+	t.Helper()
+	inv := &Invitation{
+		WorkspaceID: wsID,
+		Email:       email,
+		Role:        "editor",
+		TokenHash:   hash,
+		InvitedBy:   sql.NullInt64{Int64: int64(invitedBy), Valid: true},
+		ExpiresAt:   expiresAt,
+	}
+	if err := repo.CreateInvitation(ctx, inv); err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	return inv
+}
+
+// addTestMember adds userID to the workspace by accepting an invitation, then sets their role
+func addTestMember(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID, userID uint64, email, role string) {
+	t.Helper()
+	inv := mustCreateInvitation(t, ctx, repo, wsID, userID, email, "member-"+email, time.Now().Add(time.Hour))
+	if ok, err := repo.AcceptInvitation(ctx, inv.ID, userID, time.Now()); err != nil || !ok {
+		t.Fatalf("AcceptInvitation = %v, %v", ok, err)
+	}
+	if ok, err := repo.SetMemberRole(ctx, wsID, userID, role); err != nil || !ok {
+		t.Fatalf("SetMemberRole = %v, %v", ok, err)
+	}
+}
+
+func countOwners(t *testing.T, ctx context.Context, repo WorkspaceRepository, wsID uint64) int {
+	t.Helper()
+	members, err := repo.ListMembers(ctx, wsID)
+	if err != nil {
+		t.Fatalf("ListMembers: %v", err)
+	}
+	n := 0
+	for _, m := range members {
+		if m.Role == "owner" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,33 @@
+package workspace
+
+import (
+	"go-saas-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, h *Handler, authMW *middleware.AuthMiddleware, rl *middleware.RateLimiter) {
+	// Workspaces and their members can only be managed with an access token
+	workspaces := r.Group("/workspaces", authMW.RequireAuth(), rl.Limit("api"))
+	{
+		workspaces.GET("", h.ListWorkspaces)
+		workspaces.POST("", h.CreateWorkspace)
+		workspaces.GET("/:id", h.GetWorkspace)
+		workspaces.PATCH("/:id", h.UpdateWorkspace)
+		workspaces.DELETE("/:id", h.DeleteWorkspace)
+
+		workspaces.GET("/:id/members", h.ListMembers)
+		workspaces.PATCH("/:id/members/:userId", h.UpdateMember)
+		workspaces.DELETE("/:id/members/:userId", h.RemoveMember)
+
+		workspaces.GET("/:id/invitations", h.ListInvitations)
+		workspaces.POST("/:id/invitations", h.CreateInvitation)
+		workspaces.DELETE("/:id/invitations/:invitationId", h.RevokeInvitation)
+	}
+
+	invitations := r.Group("/invitations", authMW.RequireAuth(), rl.Limit("api"))
+	{
+		invitations.POST("/accept", h.AcceptInvitation)
+		invitations.POST("/decline", h.DeclineInvitation)
+	}
+}
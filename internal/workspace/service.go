@@ -0,0 +1,294 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go-saas-api/internal/mailer"
+	"go-saas-api/internal/middleware"
+	"go-saas-api/internal/user"
+)
+
+const personalWorkspaceName = "Personal"
+
+// UserDirectory looks up the accounts that members and invitees belong to
+type UserDirectory interface {
+	GetByID(ctx context.Context, id uint64) (*user.User, error)
+	GetByEmail(ctx context.Context, email string) (*user.User, error)
+}
+
+type Service struct {
+	repo       WorkspaceRepository
+	users      UserDirectory
+	mailer     mailer.Mailer
+	appBaseURL string
+}
+
+var _ middleware.WorkspaceResolver = (*Service)(nil)
+
+func NewService(repo WorkspaceRepository, users UserDirectory, m mailer.Mailer, appBaseURL string) *Service {
+	return &Service{
+		repo:       repo,
+		users:      users,
+		mailer:     m,
+		appBaseURL: appBaseURL,
+	}
+}
+
+// ResolveWorkspace returns the workspace a request acts in and the user's role there.
+// workspaceID 0 selects the user's personal workspace, which is created on first use.
+func (s *Service) ResolveWorkspace(ctx context.Context, userID, workspaceID uint64) (uint64, string, error) {
+	if workspaceID == 0 {
+		id, err := s.repo.EnsurePersonalWorkspace(ctx, userID, personalWorkspaceName)
+		if err != nil {
+			return 0, "", err
+		}
+		workspaceID = id
+	}
+
+	role, err := s.memberRole(ctx, workspaceID, userID)
+	if err != nil {
+		return 0, "", err
+	}
+	return workspaceID, role, nil
+}
+
+// Workspace Service Methods
+
+// ListWorkspaces returns every workspace the user belongs to, personal one first
+func (s *Service) ListWorkspaces(ctx context.Context, userID uint64) ([]WorkspaceResponse, error) {
+	if _, err := s.repo.EnsurePersonalWorkspace(ctx, userID, personalWorkspaceName); err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.repo.ListMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]WorkspaceResponse, 0, len(memberships))
+	for i := range memberships {
+		items = append(items, toWorkspaceResponse(&memberships[i].Workspace, memberships[i].Role))
+	}
+	return items, nil
+}
+
+// CreateWorkspace creates a shared workspace owned by the user
+func (s *Service) CreateWorkspace(ctx context.Context, userID uint64, req CreateWorkspaceReq) (*WorkspaceResponse, error) {
+	id, err := s.repo.CreateWorkspace(ctx, req.Name, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWorkspace(ctx, id, userID)
+}
+
+func (s *Service) GetWorkspace(ctx context.Context, id, userID uint64) (*WorkspaceResponse, error) {
+	role, err := s.memberRole(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := s.repo.GetWorkspace(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+
+	resp := toWorkspaceResponse(w, role)
+	return &resp, nil
+}
+
+// RenameWorkspace is limited to owners
+func (s *Service) RenameWorkspace(ctx context.Context, id, userID uint64, req UpdateWorkspaceReq) (*WorkspaceResponse, error) {
+	if req.Name == nil {
+		return nil, ErrNoFieldsToUpdate
+	}
+	if err := s.requireRole(ctx, id, userID, middleware.WorkspaceRoleOwner); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.repo.RenameWorkspace(ctx, id, *req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrWorkspaceNotFound
+	}
+	return s.GetWorkspace(ctx, id, userID)
+}
+
+// DeleteWorkspace removes a shared workspace together with its places and
+// categories. Personal workspaces live as long as their user.
+func (s *Service) DeleteWorkspace(ctx context.Context, id, userID uint64) error {
+	if err := s.requireRole(ctx, id, userID, middleware.WorkspaceRoleOwner); err != nil {
+		return err
+	}
+
+	w, err := s.repo.GetWorkspace(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrWorkspaceNotFound
+		}
+		return err
+	}
+	if w.PersonalUserID.Valid {
+		return ErrPersonalWorkspace
+	}
+
+	ok, err := s.repo.DeleteWorkspace(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWorkspaceNotFound
+	}
+	return nil
+}
+
+// Member Service Methods
+
+// ListMembers is open to every member of the workspace
+func (s *Service) ListMembers(ctx context.Context, id, userID uint64) ([]MemberResponse, error) {
+	if _, err := s.memberRole(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.ListMembers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]MemberResponse, 0, len(members))
+	for i := range members {
+		items = append(items, toMemberResponse(&members[i]))
+	}
+	return items, nil
+}
+
+// UpdateMemberRole lets an owner change a member's role. The last owner cannot be
+// demoted and a personal workspace's user always stays its owner.
+func (s *Service) UpdateMemberRole(ctx context.Context, id, userID, memberID uint64, req UpdateMemberReq) error {
+	if err := s.requireRole(ctx, id, userID, middleware.WorkspaceRoleOwner); err != nil {
+		return err
+	}
+
+	current, err := s.repo.GetMemberRole(ctx, id, memberID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	if current == req.Role {
+		return nil
+	}
+	if current == middleware.WorkspaceRoleOwner {
+		if err := s.checkNotPersonal(ctx, id, memberID); err != nil {
+			return err
+		}
+	}
+
+	// The repository refuses to demote the last owner
+	ok, err := s.repo.SetMemberRole(ctx, id, memberID, req.Role)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// RemoveMember lets an owner remove anyone, and any member remove themselves to
+// leave the workspace
+func (s *Service) RemoveMember(ctx context.Context, id, userID, memberID uint64) error {
+	role, err := s.memberRole(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if memberID != userID && role != middleware.WorkspaceRoleOwner {
+		return middleware.ErrInsufficientWorkspaceRole
+	}
+
+	if _, err := s.repo.GetMemberRole(ctx, id, memberID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	if err := s.checkNotPersonal(ctx, id, memberID); err != nil {
+		return err
+	}
+
+	// The repository refuses to remove the last owner
+	ok, err := s.repo.RemoveMember(ctx, id, memberID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMemberNotFound
+	}
+	return nil
+}
+
+// Helpers
+
+// memberRole returns the user's role in the workspace. Workspaces the user does
+// not belong to are reported as not found so their existence is not revealed.
+func (s *Service) memberRole(ctx context.Context, workspaceID, userID uint64) (string, error) {
+	role, err := s.repo.GetMemberRole(ctx, workspaceID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrWorkspaceNotFound
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+func (s *Service) requireRole(ctx context.Context, workspaceID, userID uint64, minRole string) error {
+	role, err := s.memberRole(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !middleware.HasWorkspaceRole(role, minRole) {
+		return middleware.ErrInsufficientWorkspaceRole
+	}
+	return nil
+}
+
+// checkNotPersonal rejects changes that would take a personal workspace away from its user
+func (s *Service) checkNotPersonal(ctx context.Context, workspaceID, memberID uint64) error {
+	w, err := s.repo.GetWorkspace(ctx, workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrWorkspaceNotFound
+		}
+		return err
+	}
+	if w.PersonalUserID.Valid && uint64(w.PersonalUserID.Int64) == memberID {
+		return ErrPersonalWorkspace
+	}
+	return nil
+}
+
+func toWorkspaceResponse(w *Workspace, role string) WorkspaceResponse {
+	return WorkspaceResponse{
+		ID:        w.ID,
+		Name:      w.Name,
+		Personal:  w.PersonalUserID.Valid,
+		Role:      role,
+		CreatedAt: w.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toMemberResponse(m *Member) MemberResponse {
+	return MemberResponse{
+		UserID:   m.UserID,
+		Role:     m.Role,
+		JoinedAt: m.CreatedAt.Format(time.RFC3339),
+	}
+}
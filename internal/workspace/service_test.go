@@ -0,0 +1,321 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-saas-api/internal/mailer"
+	"go-saas-api/internal/middleware"
+	"go-saas-api/internal/user"
+)
+
+// stubUsers is a UserDirectory backed by a fixed set of accounts
+type stubUsers map[uint64]*user.User
+
+func (u stubUsers) GetByID(ctx context.Context, id uint64) (*user.User, error) {
+	if usr, ok := u[id]; ok {
+		return usr, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (u stubUsers) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	for _, usr := range u {
+		if strings.EqualFold(usr.Email, email) {
+			return usr, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// recordingMailer keeps sent messages in memory
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var tokenParam = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken returns the token from the link in the most recent message
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no mail sent")
+	}
+	match := tokenParam.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if match == nil {
+		t.Fatal("no token in mail body")
+	}
+	token, _ := url.QueryUnescape(match[1])
+	return token
+}
+
+const (
+	ownerID      = 1
+	inviteeID    = 2
+	unverifiedID = 3
+)
+
+func newTestService() (*Service, *recordingMailer) {
+	users := stubUsers{
+		ownerID:      {ID: ownerID, Email: "owner@example.com", Name: "Olivia", EmailVerified: true},
+		inviteeID:    {ID: inviteeID, Email: "invitee@example.com", Name: "Ivan", EmailVerified: true},
+		unverifiedID: {ID: unverifiedID, Email: "unverified@example.com", Name: "Uma"},
+	}
+	mail := &recordingMailer{}
+	return NewService(NewMemoryRepository(), users, mail, "http://app.test"), mail
+}
+
+// mustInviteAndJoin invites userID to the workspace and accepts on their behalf
+func mustInviteAndJoin(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64, email string, userID uint64, role string) {
+	t.Helper()
+	if _, err := svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: email, Role: role}); err != nil {
+		t.Fatalf("Invite: %v", err)
+	}
+	if _, err := svc.AcceptInvitation(ctx, userID, InvitationTokenReq{Token: mail.lastToken(t)}); err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+}
+
+func TestServiceResolveWorkspace(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService()
+
+	personal, role, err := svc.ResolveWorkspace(ctx, ownerID, 0)
+	if err != nil || role != middleware.WorkspaceRoleOwner {
+		t.Fatalf("ResolveWorkspace(personal) = %d, %q, %v", personal, role, err)
+	}
+	again, _, _ := svc.ResolveWorkspace(ctx, ownerID, 0)
+	if again != personal {
+		t.Errorf("personal workspace changed from %d to %d", personal, again)
+	}
+
+	if _, _, err := svc.ResolveWorkspace(ctx, inviteeID, personal); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Errorf("non-member err = %v, want ErrWorkspaceNotFound", err)
+	}
+
+	items, err := svc.ListWorkspaces(ctx, inviteeID)
+	if err != nil || len(items) != 1 || !items[0].Personal || items[0].ID == personal {
+		t.Errorf("ListWorkspaces = %+v, %v; want only the invitee's personal workspace", items, err)
+	}
+}
+
+func TestServiceMembers(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64)
+	}{
+		{"last owner cannot be demoted or leave", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			if err := svc.UpdateMemberRole(ctx, wsID, ownerID, ownerID, UpdateMemberReq{Role: "viewer"}); !errors.Is(err, ErrLastOwner) {
+				t.Errorf("demote err = %v, want ErrLastOwner", err)
+			}
+			if err := svc.RemoveMember(ctx, wsID, ownerID, ownerID); !errors.Is(err, ErrLastOwner) {
+				t.Errorf("leave err = %v, want ErrLastOwner", err)
+			}
+
+			mustInviteAndJoin(t, ctx, svc, mail, wsID, "invitee@example.com", inviteeID, "owner")
+			if err := svc.RemoveMember(ctx, wsID, ownerID, ownerID); err != nil {
+				t.Errorf("leave with a second owner: %v", err)
+			}
+		}},
+		{"only owners manage members", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			mustInviteAndJoin(t, ctx, svc, mail, wsID, "invitee@example.com", inviteeID, "editor")
+
+			err := svc.UpdateMemberRole(ctx, wsID, inviteeID, inviteeID, UpdateMemberReq{Role: "owner"})
+			if !errors.Is(err, middleware.ErrInsufficientWorkspaceRole) {
+				t.Errorf("editor promoting themselves err = %v, want ErrInsufficientWorkspaceRole", err)
+			}
+			if err := svc.RemoveMember(ctx, wsID, inviteeID, ownerID); !errors.Is(err, middleware.ErrInsufficientWorkspaceRole) {
+				t.Errorf("editor removing owner err = %v, want ErrInsufficientWorkspaceRole", err)
+			}
+			if _, err := svc.Invite(ctx, wsID, inviteeID, CreateInvitationReq{Email: "x@example.com", Role: "viewer"}); !errors.Is(err, middleware.ErrInsufficientWorkspaceRole) {
+				t.Errorf("editor inviting err = %v, want ErrInsufficientWorkspaceRole", err)
+			}
+
+			// Anyone can leave
+			if err := svc.RemoveMember(ctx, wsID, inviteeID, inviteeID); err != nil {
+				t.Fatalf("leave: %v", err)
+			}
+			members, _ := svc.ListMembers(ctx, wsID, ownerID)
+			if len(members) != 1 {
+				t.Errorf("members after leaving = %+v", members)
+			}
+		}},
+		{"non-members see nothing", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			if _, err := svc.GetWorkspace(ctx, wsID, inviteeID); !errors.Is(err, ErrWorkspaceNotFound) {
+				t.Errorf("GetWorkspace err = %v, want ErrWorkspaceNotFound", err)
+			}
+			if _, err := svc.ListMembers(ctx, wsID, inviteeID); !errors.Is(err, ErrWorkspaceNotFound) {
+				t.Errorf("ListMembers err = %v, want ErrWorkspaceNotFound", err)
+			}
+			if err := svc.DeleteWorkspace(ctx, wsID, inviteeID); !errors.Is(err, ErrWorkspaceNotFound) {
+				t.Errorf("DeleteWorkspace err = %v, want ErrWorkspaceNotFound", err)
+			}
+		}},
+		{"personal workspace stays with its user", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			personal, _, _ := svc.ResolveWorkspace(ctx, ownerID, 0)
+			mustInviteAndJoin(t, ctx, svc, mail, personal, "invitee@example.com", inviteeID, "owner")
+
+			if err := svc.DeleteWorkspace(ctx, personal, ownerID); !errors.Is(err, ErrPersonalWorkspace) {
+				t.Errorf("delete err = %v, want ErrPersonalWorkspace", err)
+			}
+			if err := svc.RemoveMember(ctx, personal, inviteeID, ownerID); !errors.Is(err, ErrPersonalWorkspace) {
+				t.Errorf("remove err = %v, want ErrPersonalWorkspace", err)
+			}
+			if err := svc.UpdateMemberRole(ctx, personal, inviteeID, ownerID, UpdateMemberReq{Role: "viewer"}); !errors.Is(err, ErrPersonalWorkspace) {
+				t.Errorf("demote err = %v, want ErrPersonalWorkspace", err)
+			}
+		}},
+		{"rename and delete", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			if _, err := svc.RenameWorkspace(ctx, wsID, ownerID, UpdateWorkspaceReq{}); !errors.Is(err, ErrNoFieldsToUpdate) {
+				t.Errorf("empty rename err = %v, want ErrNoFieldsToUpdate", err)
+			}
+			name := "Crew"
+			w, err := svc.RenameWorkspace(ctx, wsID, ownerID, UpdateWorkspaceReq{Name: &name})
+			if err != nil || w.Name != "Crew" {
+				t.Fatalf("RenameWorkspace = %+v, %v", w, err)
+			}
+			if err := svc.DeleteWorkspace(ctx, wsID, ownerID); err != nil {
+				t.Fatalf("DeleteWorkspace: %v", err)
+			}
+			if _, err := svc.GetWorkspace(ctx, wsID, ownerID); !errors.Is(err, ErrWorkspaceNotFound) {
+				t.Errorf("GetWorkspace err = %v, want ErrWorkspaceNotFound", err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, mail := newTestService()
+			w, err := svc.CreateWorkspace(ctx, ownerID, CreateWorkspaceReq{Name: "Team"})
+			if err != nil {
+				t.Fatalf("CreateWorkspace: %v", err)
+			}
+			tt.run(t, ctx, svc, mail, w.ID)
+		})
+	}
+}
+
+func TestServiceInvitations(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64)
+	}{
+		{"accept joins with the invited role", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			inv, err := svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: "Invitee@example.com", Role: "viewer"})
+			if err != nil {
+				t.Fatalf("Invite: %v", err)
+			}
+			if inv.InvitedBy == nil || *inv.InvitedBy != ownerID {
+				t.Errorf("invited_by = %v, want %d", inv.InvitedBy, ownerID)
+			}
+			if mail.sent[0].To != "Invitee@example.com" || !strings.Contains(mail.sent[0].Body, "http://app.test/invitations?token=") {
+				t.Errorf("mail = %+v", mail.sent[0])
+			}
+
+			token := mail.lastToken(t)
+			w, err := svc.AcceptInvitation(ctx, inviteeID, InvitationTokenReq{Token: token})
+			if err != nil {
+				t.Fatalf("AcceptInvitation: %v", err)
+			}
+			if w.ID != wsID || w.Role != "viewer" {
+				t.Errorf("joined %+v, want workspace %d as viewer", w, wsID)
+			}
+			if _, role, err := svc.ResolveWorkspace(ctx, inviteeID, wsID); err != nil || role != "viewer" {
+				t.Errorf("ResolveWorkspace = %q, %v; want viewer", role, err)
+			}
+
+			if _, err := svc.AcceptInvitation(ctx, inviteeID, InvitationTokenReq{Token: token}); !errors.Is(err, ErrInvalidInvitation) {
+				t.Errorf("reused token err = %v, want ErrInvalidInvitation", err)
+			}
+			if _, err := svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: "invitee@example.com", Role: "editor"}); !errors.Is(err, ErrAlreadyMember) {
+				t.Errorf("inviting a member err = %v, want ErrAlreadyMember", err)
+			}
+		}},
+		{"invitation is bound to the invited email", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			_, _ = svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: "invitee@example.com", Role: "editor"})
+			token := mail.lastToken(t)
+
+			if _, err := svc.AcceptInvitation(ctx, unverifiedID, InvitationTokenReq{Token: token}); !errors.Is(err, ErrInvitationForOther) {
+				t.Errorf("accept err = %v, want ErrInvitationForOther", err)
+			}
+			if err := svc.DeclineInvitation(ctx, unverifiedID, InvitationTokenReq{Token: token}); !errors.Is(err, ErrInvitationForOther) {
+				t.Errorf("decline err = %v, want ErrInvitationForOther", err)
+			}
+		}},
+		{"accepting requires a verified email", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			_, _ = svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: "unverified@example.com", Role: "editor"})
+
+			_, err := svc.AcceptInvitation(ctx, unverifiedID, InvitationTokenReq{Token: mail.lastToken(t)})
+			if !errors.Is(err, ErrEmailNotVerified) {
+				t.Errorf("err = %v, want ErrEmailNotVerified", err)
+			}
+		}},
+		{"declined invitation cannot be accepted", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			_, _ = svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: "invitee@example.com", Role: "editor"})
+			token := mail.lastToken(t)
+
+			if err := svc.DeclineInvitation(ctx, inviteeID, InvitationTokenReq{Token: token}); err != nil {
+				t.Fatalf("DeclineInvitation: %v", err)
+			}
+			if _, err := svc.AcceptInvitation(ctx, inviteeID, InvitationTokenReq{Token: token}); !errors.Is(err, ErrInvalidInvitation) {
+				t.Errorf("err = %v, want ErrInvalidInvitation", err)
+			}
+			if items, _ := svc.ListInvitations(ctx, wsID, ownerID); len(items) != 0 {
+				t.Errorf("pending invitations = %+v, want none", items)
+			}
+		}},
+		{"re-inviting and revoking invalidate earlier links", func(t *testing.T, ctx context.Context, svc *Service, mail *recordingMailer, wsID uint64) {
+			_, _ = svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: "invitee@example.com", Role: "viewer"})
+			first := mail.lastToken(t)
+			inv, _ := svc.Invite(ctx, wsID, ownerID, CreateInvitationReq{Email: "invitee@example.com", Role: "editor"})
+			second := mail.lastToken(t)
+
+			if _, err := svc.AcceptInvitation(ctx, inviteeID, InvitationTokenReq{Token: first}); !errors.Is(err, ErrInvalidInvitation) {
+				t.Errorf("replaced token err = %v, want ErrInvalidInvitation", err)
+			}
+			items, _ := svc.ListInvitations(ctx, wsID, ownerID)
+			if len(items) != 1 || items[0].ID != inv.ID || items[0].Role != "editor" {
+				t.Errorf("pending invitations = %+v", items)
+			}
+
+			if err := svc.RevokeInvitation(ctx, wsID, ownerID, inv.ID); err != nil {
+				t.Fatalf("RevokeInvitation: %v", err)
+			}
+			if _, err := svc.AcceptInvitation(ctx, inviteeID, InvitationTokenReq{Token: second}); !errors.Is(err, ErrInvalidInvitation) {
+				t.Errorf("revoked token err = %v, want ErrInvalidInvitation", err)
+			}
+			if err := svc.RevokeInvitation(ctx, wsID, ownerID, inv.ID); !errors.Is(err, ErrInvitationNotFound) {
+				t.Errorf("revoking twice err = %v, want ErrInvitationNotFound", err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, mail := newTestService()
+			w, err := svc.CreateWorkspace(ctx, ownerID, CreateWorkspaceReq{Name: "Team"})
+			if err != nil {
+				t.Fatalf("CreateWorkspace: %v", err)
+			}
+			tt.run(t, ctx, svc, mail, w.ID)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package apperror
+
+import "time"
+
+// Kind classifies an error; the error middleware maps it to an HTTP status
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooManyRequests
+)
+
+// Error is a domain error with a stable, machine-readable code.
+// Message is safe to show to clients.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details any
+
+	// RetryAfter is sent as the Retry-After header when set
+	RetryAfter time.Duration
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches errors by code, so copies made by WithDetails still match their sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails returns a copy of e carrying extra client-facing details
+func (e *Error) WithDetails(details any) *Error {
+	cp := *e
+	cp.Details = details
+	return &cp
+}
+
+// WithRetryAfter returns a copy of e telling the client when to retry
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	cp := *e
+	cp.RetryAfter = d
+	return &cp
+}
+
+// Common errors shared by all modules
+var (
+	ErrInternal     = New(KindInternal, "internal_error", "internal server error")
+	ErrInvalidJSON  = New(KindInvalid, "invalid_json", "invalid json")
+	ErrInvalidQuery = New(KindInvalid, "invalid_query", "invalid query")
+	ErrValidation   = New(KindInvalid, "validation_failed", "validation failed")
+	ErrInvalidID    = New(KindInvalid, "invalid_id", "invalid id")
+	ErrUnauthorized = New(KindUnauthorized, "unauthorized", "unauthorized")
+)
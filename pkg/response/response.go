@@ -3,7 +3,9 @@ package response
 import "github.com/gin-gonic/gin"
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error   string      `json:"error"`
+	Code    string      `json:"code,omitempty"`    // stable machine-readable code
+	Details interface{} `json:"details,omitempty"` // optional extra context, e.g. field errors
 }
 
 type SuccessResponse struct {
@@ -15,6 +17,10 @@ func Error(c *gin.Context, code int, message string) {
 	c.JSON(code, ErrorResponse{Error: message})
 }
 
+func ErrorWithCode(c *gin.Context, status int, code, message string, details interface{}) {
+	c.JSON(status, ErrorResponse{Error: message, Code: code, Details: details})
+}
+
 func Success(c *gin.Context, code int, data interface{}) {
 	c.JSON(code, SuccessResponse{Data: data})
 }
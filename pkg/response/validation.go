@@ -0,0 +1,97 @@
+package response
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// FieldError describes a single failed validation rule
+type FieldError struct {
+	Field   string `json:"field"` // JSON (or query) name of the field
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// English is the fallback locale
+var uni = ut.New(en.New(), en.New(), id.New())
+
+// SetupValidator makes v report JSON field names and registers English and
+// Indonesian messages. Call it once at startup before validating anything.
+func SetupValidator(v *validator.Validate) error {
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(fld.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return fld.Name
+	})
+
+	enTrans, _ := uni.GetTranslator("en")
+	if err := en_translations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+	idTrans, _ := uni.GetTranslator("id")
+	return id_translations.RegisterDefaultTranslations(v, idTrans)
+}
+
+// ValidationErrors converts a validator error into field errors, with messages in
+// the language preferred by the request's Accept-Language header.
+// Returns nil if err is not a validator.ValidationErrors.
+func ValidationErrors(c *gin.Context, err error) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	trans, _ := uni.FindTranslator(acceptedLanguages(c.GetHeader("Accept-Language"))...)
+
+	result := make([]FieldError, len(verrs))
+	for i, fe := range verrs {
+		result[i] = FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		}
+	}
+	return result
+}
+
+// fieldPath strips the top-level struct name from the namespace, e.g.
+// "CreatePlaceReq.category_ids[0]" -> "category_ids[0]"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+// acceptedLanguages returns the base language tags of an Accept-Language header in order,
+// e.g. "id-ID,id;q=0.9,en;q=0.8" -> [id id en]
+func acceptedLanguages(header string) []string {
+	var langs []string
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		tag = strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if tag != "" && tag != "*" {
+			langs = append(langs, tag)
+		}
+	}
+	return langs
+}